@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+
+	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
+	component "github.com/nayotta/metathings/pkg/component"
+)
+
+// admin commands operate on module config file directly,
+// module is not launched, metathings is not required.
+
+type command_option struct {
+	Config  string
+	Stage   string
+	Verbose bool
+}
+
+type command struct {
+	name  string
+	usage string
+	flags func(*pflag.FlagSet)
+	run   func(opt *command_option, args []string) error
+}
+
+var commands = map[string]*command{}
+
+func register_command(cmd *command) {
+	commands[cmd.name] = cmd
+}
+
+func print_commands_usage() {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stderr, "Usage: %v [flags]  // launch module\n", os.Args[0])
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "       %v %v\n", os.Args[0], commands[name].usage)
+	}
+}
+
+func run_command(cmd *command, args []string) int {
+	opt := &command_option{}
+
+	fs := pflag.NewFlagSet(cmd.name, pflag.ContinueOnError)
+	fs.StringVarP(&opt.Config, "config", "c", "", "Config file")
+	fs.StringVar(&opt.Stage, "stage", "", "Config stage, default stage in config or METATHINGS_COMPONENT_STAGE")
+	fs.BoolVarP(&opt.Verbose, "verbose", "v", false, "Verbose output")
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %v %v\n", os.Args[0], cmd.usage)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	if err := cmd.run(opt, fs.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "%v: %v\n", cmd.name, err)
+		return 1
+	}
+
+	return 0
+}
+
+func new_command_logger(opt *command_option) log.FieldLogger {
+	logger := log.New()
+	logger.SetOutput(os.Stderr)
+	logger.SetLevel(log.WarnLevel)
+	if opt.Verbose {
+		logger.SetLevel(log.DebugLevel)
+	}
+	return logger
+}
+
+// load_driver_option loads driver options of stage in module config file.
+func load_driver_option(opt *command_option) (*driver.DigitVideoRecorderDriverOption, error) {
+	if opt.Config == "" {
+		return nil, errors.New("config required")
+	}
+
+	buf, err := ioutil.ReadFile(opt.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	kc, err := component.NewKernelConfigFromText(string(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	stage := opt.Stage
+	if stage == "" {
+		stage = kc.GetString("stage")
+	}
+	if stage == "" {
+		return nil, errors.New("stage required")
+	}
+
+	v := kc.Raw().Sub(stage)
+	if v == nil {
+		return nil, fmt.Errorf("stage %v not found", stage)
+	}
+
+	if v = v.Sub("driver"); v == nil {
+		return nil, fmt.Errorf("driver not found in stage %v", stage)
+	}
+
+	return &driver.DigitVideoRecorderDriverOption{Viper: v}, nil
+}
+
+// new_command_storage opens record storage by config file, for commands
+// not requiring driver, module should be stopped for leveldb and bbolt storage.
+func new_command_storage(opt *command_option) (*driver.DigitVideoRecorderDriverOption, driver.RecordStorage, error) {
+	drv_opt, err := load_driver_option(opt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stor, err := driver.OpenRecordStorage(drv_opt, new_command_logger(opt))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return drv_opt, stor, nil
+}
+
+// parse_command_time parses time flag, RFC3339 or `2006-01-02 15:04:05`
+// in local timezone, zero time if empty.
+func parse_command_time(val string) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return t, nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, val, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unexpected time %v, expect RFC3339 or 2006-01-02 15:04:05", val)
+}
+
+// new_command_driver creates driver by config file, recording is not started.
+func new_command_driver(opt *command_option) (driver.DigitVideoRecorderDriver, error) {
+	drv_opt, err := load_driver_option(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = driver.ValidateDigitVideoRecorderDriverOption(drv_opt); err != nil {
+		return nil, err
+	}
+
+	return driver.NewDigitVideoRecorderDriver(drv_opt.GetString("name"), drv_opt, "logger", new_command_logger(opt))
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
+)
+
+var export_option struct {
+	record_filter_option
+	Ids []string
+}
+
+func run_export(opt *command_option, args []string) error {
+	if len(args) != 1 {
+		return errors.New("destination directory required")
+	}
+
+	_, stor, err := new_command_storage(opt)
+	if err != nil {
+		return err
+	}
+	defer stor.Close()
+
+	rs, err := list_command_records(stor, &export_option.record_filter_option, export_option.Ids)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, r := range rs {
+		if r.Pending {
+			continue
+		}
+
+		dst, err := driver.ExportRecordFile(r, args[0])
+		if err != nil {
+			fmt.Printf("failed\t%v\t%v\t%v\n", r.Id, r.Path, err)
+			failed++
+			continue
+		}
+		fmt.Printf("exported\t%v\t%v\n", r.Id, dst)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d records failed to export", failed)
+	}
+
+	return nil
+}
+
+func init() {
+	register_command(&command{
+		name:  "export",
+		usage: "export -c <config> [--from <time>] [--to <time>] [--profile <profile>] [--id <id>]... <dir>",
+		flags: func(fs *pflag.FlagSet) {
+			export_option.record_filter_option.flags(fs)
+			fs.StringSliceVar(&export_option.Ids, "id", nil, "Record id, filter flags are ignored if set")
+		},
+		run: run_export,
+	})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
+)
+
+var gc_option struct {
+	DryRun bool
+	Force  bool
+}
+
+func run_gc(opt *command_option, args []string) error {
+	drv_opt, stor, err := new_command_storage(opt)
+	if err != nil {
+		return err
+	}
+	defer stor.Close()
+
+	ret, err := driver.GcRecords(drv_opt, stor, &driver.GcRecordsOption{
+		DryRun: gc_option.DryRun,
+		Force:  gc_option.Force,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range ret.Removed {
+		fmt.Printf("removed\t%v\t%v\n", r.Id, r.Path)
+	}
+
+	for _, r := range ret.Skipped {
+		fmt.Printf("skipped\t%v\t%v\n", r.Id, r.Path)
+	}
+
+	for _, path := range ret.TempFiles {
+		fmt.Printf("removed\t%v\n", path)
+	}
+
+	return nil
+}
+
+func init() {
+	register_command(&command{
+		name:  "gc",
+		usage: "gc -c <config> [--dry-run] [--force]",
+		flags: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&gc_option.DryRun, "dry-run", false, "Print records and files to remove only")
+			fs.BoolVar(&gc_option.Force, "force", false, "Remove records even if most records would be removed")
+		},
+		run: run_gc,
+	})
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
+)
+
+var import_option struct {
+	Mode       string
+	Profile    string
+	TimeLayout string
+}
+
+func run_import(opt *command_option, args []string) error {
+	if len(args) == 0 {
+		return errors.New("paths required")
+	}
+
+	drv, err := new_command_driver(opt)
+	if err != nil {
+		return err
+	}
+
+	ret, err := drv.ImportRecords(&driver.ImportRecordsOption{
+		Paths:      args,
+		Mode:       import_option.Mode,
+		Profile:    import_option.Profile,
+		TimeLayout: import_option.TimeLayout,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range ret.Imported {
+		fmt.Printf("imported\t%v\t%v\n", r.Id, r.Path)
+	}
+
+	for _, path := range ret.Skipped {
+		fmt.Printf("skipped\t%v\n", path)
+	}
+
+	for _, f := range ret.Failed {
+		fmt.Printf("failed\t%v\t%v\n", f.Path, f.Reason)
+	}
+
+	if len(ret.Failed) > 0 {
+		return fmt.Errorf("%d files failed to import", len(ret.Failed))
+	}
+
+	return nil
+}
+
+func init() {
+	register_command(&command{
+		name:  "import",
+		usage: "import -c <config> [--mode keep|copy|move] [--profile <profile>] [--time-layout <layout>] <path>...",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&import_option.Mode, "mode", driver.IMPORT_MODE_KEEP, "Register files in place (keep), or copy or move them into output layout")
+			fs.StringVar(&import_option.Profile, "profile", "", "Output profile, default the first profile")
+			fs.StringVar(&import_option.TimeLayout, "time-layout", "", "Go time layout of file name, like cam1-20060102-150405")
+		},
+		run: run_import,
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
+)
+
+func print_index_backup(bak *driver.IndexBackup) {
+	fmt.Printf("%v\t%d records\t%v\tsha256:%v\n", bak.Path, bak.Records, bak.CreatedAt.Format(time.RFC3339), bak.Checksum)
+}
+
+func run_backup_index(opt *command_option, args []string) error {
+	if len(args) != 1 {
+		return errors.New("backup file required")
+	}
+
+	drv, err := new_command_driver(opt)
+	if err != nil {
+		return err
+	}
+
+	bak, err := drv.BackupIndex(args[0])
+	if err != nil {
+		return err
+	}
+	print_index_backup(bak)
+
+	return nil
+}
+
+func run_restore_index(opt *command_option, args []string) error {
+	if len(args) != 1 {
+		return errors.New("backup file required")
+	}
+
+	drv, err := new_command_driver(opt)
+	if err != nil {
+		return err
+	}
+
+	bak, err := drv.RestoreIndex(args[0])
+	if err != nil {
+		return err
+	}
+	print_index_backup(bak)
+
+	return nil
+}
+
+func init() {
+	register_command(&command{
+		name:  "backup-index",
+		usage: "backup-index -c <config> <file>",
+		run:   run_backup_index,
+	})
+
+	register_command(&command{
+		name:  "restore-index",
+		usage: "restore-index -c <config> <file>",
+		run:   run_restore_index,
+	})
+}
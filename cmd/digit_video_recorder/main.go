@@ -8,6 +8,17 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			os.Exit(run_command(cmd, os.Args[2:]))
+		}
+
+		if os.Args[1] == "help" {
+			print_commands_usage()
+			return
+		}
+	}
+
 	mdl, err := component.NewModule(os.Args[0], new(service.DigitVideoRecorderService))
 	if err != nil {
 		panic(err)
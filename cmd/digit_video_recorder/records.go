@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+	yaml "gopkg.in/yaml.v2"
+
+	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
+)
+
+// record_filter_option is shared by commands selecting records.
+type record_filter_option struct {
+	From    string
+	To      string
+	Profile string
+}
+
+func (o *record_filter_option) flags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.From, "from", "", "Records end after the time, RFC3339 or 2006-01-02 15:04:05")
+	fs.StringVar(&o.To, "to", "", "Records start before the time, RFC3339 or 2006-01-02 15:04:05")
+	fs.StringVar(&o.Profile, "profile", "", "Output profile, all profiles if empty")
+}
+
+func (o *record_filter_option) filter() (driver.ListRecordsFitler, error) {
+	var flt driver.ListRecordsFitler
+	var err error
+
+	if flt.Range.StartAt, err = parse_command_time(o.From); err != nil {
+		return flt, err
+	}
+
+	if flt.Range.EndAt, err = parse_command_time(o.To); err != nil {
+		return flt, err
+	}
+
+	flt.Profile = o.Profile
+
+	return flt, nil
+}
+
+// list_command_records lists records by filter option,
+// records of ids are listed if ids not empty.
+func list_command_records(stor driver.RecordStorage, o *record_filter_option, ids []string) ([]*driver.Record, error) {
+	if len(ids) > 0 {
+		var rs []*driver.Record
+		for _, id := range ids {
+			r, err := stor.GetRecord(id)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", id, err)
+			}
+			rs = append(rs, r)
+		}
+		return rs, nil
+	}
+
+	flt, err := o.filter()
+	if err != nil {
+		return nil, err
+	}
+
+	return stor.ListRecords(flt)
+}
+
+var records_option struct {
+	record_filter_option
+	KeepFile bool
+}
+
+func run_records_list(stor driver.RecordStorage, args []string) error {
+	rs, err := list_command_records(stor, &records_option.record_filter_option, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rs {
+		line := fmt.Sprintf("%v\t%v\t%v\t%v\t%v",
+			r.Id, r.StartAt.Format(time.RFC3339), r.EndAt.Format(time.RFC3339), r.GetProfile(), r.Path)
+		if r.Pending {
+			line += "\tpending"
+		}
+		fmt.Println(line)
+	}
+
+	return nil
+}
+
+func run_records_get(stor driver.RecordStorage, args []string) error {
+	if len(args) != 1 {
+		return errors.New("record id required")
+	}
+
+	r, err := stor.GetRecord(args[0])
+	if err != nil {
+		return err
+	}
+
+	buf, err := yaml.Marshal(r)
+	if err != nil {
+		return err
+	}
+	os.Stdout.Write(buf)
+
+	return nil
+}
+
+func run_records_delete(stor driver.RecordStorage, args []string) error {
+	if len(args) == 0 {
+		return errors.New("record ids required")
+	}
+
+	for _, id := range args {
+		r, err := driver.DeleteRecord(stor, id, records_option.KeepFile)
+		if err != nil {
+			return fmt.Errorf("%v: %v", id, err)
+		}
+		fmt.Printf("deleted\t%v\t%v\n", r.Id, r.Path)
+	}
+
+	return nil
+}
+
+var records_subcommands = map[string]func(driver.RecordStorage, []string) error{
+	"list":   run_records_list,
+	"get":    run_records_get,
+	"delete": run_records_delete,
+}
+
+func run_records(opt *command_option, args []string) error {
+	if len(args) == 0 {
+		return errors.New("subcommand required, list, get or delete")
+	}
+
+	run, ok := records_subcommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown subcommand %v", args[0])
+	}
+
+	_, stor, err := new_command_storage(opt)
+	if err != nil {
+		return err
+	}
+	defer stor.Close()
+
+	return run(stor, args[1:])
+}
+
+func init() {
+	register_command(&command{
+		name:  "records",
+		usage: "records -c <config> list [--from <time>] [--to <time>] [--profile <profile>] | get <id> | delete [--keep-file] <id>...",
+		flags: func(fs *pflag.FlagSet) {
+			records_option.record_filter_option.flags(fs)
+			fs.BoolVar(&records_option.KeepFile, "keep-file", false, "Delete record only, keep the file")
+		},
+		run: run_records,
+	})
+}
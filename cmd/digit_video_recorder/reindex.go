@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+)
+
+func run_reindex(opt *command_option, args []string) error {
+	drv, err := new_command_driver(opt)
+	if err != nil {
+		return err
+	}
+
+	ret, err := drv.ReindexRecords()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range ret.Recovered {
+		fmt.Printf("recovered\t%v\t%v\n", r.Id, r.Path)
+	}
+
+	for _, f := range ret.Unmapped {
+		fmt.Printf("unmapped\t%v\t%v\n", f.Path, f.Reason)
+	}
+
+	fmt.Printf("%d recovered, %d indexed, %d unmapped\n", len(ret.Recovered), ret.Indexed, len(ret.Unmapped))
+
+	return nil
+}
+
+func init() {
+	register_command(&command{
+		name:  "reindex",
+		usage: "reindex -c <config>",
+		run:   run_reindex,
+	})
+}
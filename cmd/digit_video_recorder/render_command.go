@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
+)
+
+var render_command_option struct {
+	TmpDir string
+}
+
+var shell_safe_regexp = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+func shell_quote(arg string) string {
+	if shell_safe_regexp.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
+
+func run_render_command(opt *command_option, args []string) error {
+	drv_opt, err := load_driver_option(opt)
+	if err != nil {
+		return err
+	}
+
+	if err = driver.ValidateDigitVideoRecorderDriverOption(drv_opt); err != nil {
+		return err
+	}
+
+	argv, err := driver.RenderCommand(drv_opt.GetString("name"), drv_opt, render_command_option.TmpDir, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+
+	var ss []string
+	for _, arg := range argv {
+		ss = append(ss, shell_quote(arg))
+	}
+	fmt.Println(strings.Join(ss, " "))
+
+	return nil
+}
+
+func init() {
+	register_command(&command{
+		name:  "render-command",
+		usage: "render-command -c <config> [--tmp-dir <dir>]",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&render_command_option.TmpDir, "tmp-dir", filepath.Join(os.TempDir(), "mt_mdl_dvr"), "Segment directory in command")
+		},
+		run: run_render_command,
+	})
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
+)
+
+var verify_option struct {
+	record_filter_option
+	Probe bool
+}
+
+func run_verify(opt *command_option, args []string) error {
+	drv_opt, stor, err := new_command_storage(opt)
+	if err != nil {
+		return err
+	}
+	defer stor.Close()
+
+	rs, err := list_command_records(stor, &verify_option.record_filter_option, args)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, r := range rs {
+		if err = driver.VerifyRecord(drv_opt, r, verify_option.Probe); err != nil {
+			fmt.Printf("failed\t%v\t%v\t%v\n", r.Id, r.Path, err)
+			failed++
+		}
+	}
+
+	fmt.Printf("%d verified, %d failed\n", len(rs)-failed, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d records failed to verify", failed)
+	}
+
+	return nil
+}
+
+func init() {
+	register_command(&command{
+		name:  "verify",
+		usage: "verify -c <config> [--from <time>] [--to <time>] [--profile <profile>] [--probe] [<id>...]",
+		flags: func(fs *pflag.FlagSet) {
+			verify_option.record_filter_option.flags(fs)
+			fs.BoolVar(&verify_option.Probe, "probe", false, "Probe files by ffprobe")
+		},
+		run: run_verify,
+	})
+}
@@ -7,9 +7,13 @@ require (
 	github.com/golang/protobuf v1.3.2
 	github.com/mwitkow/go-proto-validators v0.2.0
 	github.com/nayotta/metathings v1.1.13
+	github.com/prometheus/client_golang v0.9.3
 	github.com/sirupsen/logrus v1.4.2
+	github.com/spf13/cast v1.3.0
+	github.com/spf13/pflag v1.0.3
 	github.com/spf13/viper v1.5.0
 	github.com/syndtr/goleveldb v1.0.0
+	go.etcd.io/bbolt v1.3.3
 	google.golang.org/grpc v1.23.0
 	gopkg.in/yaml.v2 v2.2.7
 )
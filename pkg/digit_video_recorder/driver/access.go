@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package digit_video_recorder_driver
+
+import "syscall"
+
+// access(2) mode bits, not exported by syscall.
+const (
+	access_w_ok = 0x2
+	access_x_ok = 0x1
+)
+
+// check_dir_writable checks files could be created in dir,
+// by permissions of process, nothing is written.
+func check_dir_writable(dir string) error {
+	return syscall.Access(dir, access_w_ok|access_x_ok)
+}
@@ -0,0 +1,21 @@
+//go:build windows
+// +build windows
+
+package digit_video_recorder_driver
+
+import "os"
+
+// check_dir_writable checks write permission bit of dir,
+// nothing is written.
+func check_dir_writable(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+
+	if info.Mode().Perm()&0200 == 0 {
+		return os.ErrPermission
+	}
+
+	return nil
+}
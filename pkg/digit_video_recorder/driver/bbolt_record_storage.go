@@ -0,0 +1,421 @@
+package digit_video_recorder_driver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	opt_helper "github.com/nayotta/metathings/pkg/common/option"
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+/*
+ * Driver: bbolt
+ *   bbolt record storage, records are indexed by start time,
+ *   range queries only scan records around the range.
+ * Options:
+ *   storage:
+ *     name: bbolt
+ *     file: <path>  // bbolt database file path, missing parent directories are created.
+ * Buckets:
+ *   records: record by id, see record_codec.go.
+ *   records_start: start time index, keys are start time with sign bit
+ *     flipped in big endian then record id, rebuilt on open if index
+ *     version changed.
+ *   meta: max record duration, storage schema version and index version.
+ */
+
+var (
+	bbolt_records_bucket       = []byte("records")
+	bbolt_records_start_bucket = []byte("records_start")
+	bbolt_meta_bucket          = []byte("meta")
+	bbolt_max_duration_key     = []byte("max_duration")
+	bbolt_schema_version_key   = []byte("schema_version")
+	bbolt_index_version_key    = []byte("index_version")
+)
+
+// version 1 flips sign bit of start time, version 0 sorts times
+// before 1970 after others.
+const BBOLT_INDEX_VERSION = 1
+
+type bboltRecordStorage struct {
+	db     *bolt.DB
+	opt    *RecordStorageOption
+	logger log.FieldLogger
+}
+
+func (s *bboltRecordStorage) get_logger() log.FieldLogger {
+	return s.logger
+}
+
+func (s *bboltRecordStorage) get_max_duration(tx *bolt.Tx) time.Duration {
+	buf := tx.Bucket(bbolt_meta_bucket).Get(bbolt_max_duration_key)
+	if len(buf) != 8 {
+		return 0
+	}
+	return time.Duration(binary.BigEndian.Uint64(buf))
+}
+
+func (s *bboltRecordStorage) get_record(tx *bolt.Tx, id string) (*Record, error) {
+	buf := tx.Bucket(bbolt_records_bucket).Get([]byte(id))
+	if buf == nil {
+		return nil, ErrNotFound
+	}
+
+	return decode_record(buf)
+}
+
+func (s *bboltRecordStorage) ListRecords(flt ListRecordsFitler) ([]*Record, error) {
+	var rs []*Record
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		cur := tx.Bucket(bbolt_records_start_bucket).Cursor()
+
+		var k []byte
+		if flt.Range.StartAt.IsZero() {
+			k, _ = cur.First()
+		} else {
+			// records started before range start may still overlap the range,
+			// but not the ones started before range start minus max duration.
+			k, _ = cur.Seek(encode_index_time(flt.Range.StartAt.Add(-s.get_max_duration(tx))))
+		}
+
+		var upper []byte
+		if !flt.Range.EndAt.IsZero() {
+			upper = encode_index_time(flt.Range.EndAt)
+		}
+
+		for ; k != nil; k, _ = cur.Next() {
+			if upper != nil && bytes.Compare(k[:8], upper) >= 0 {
+				break
+			}
+
+			r, err := s.get_record(tx, string(k[8:]))
+			if err != nil {
+				return err
+			}
+
+			if flt.match(r) {
+				rs = append(rs, r)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort_records(rs)
+
+	return rs, nil
+}
+
+func (s *bboltRecordStorage) GetRecord(id string) (*Record, error) {
+	var r *Record
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		r, err = s.get_record(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (s *bboltRecordStorage) SetRecord(r *Record) error {
+	buf, err := encode_record(r)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		idx := tx.Bucket(bbolt_records_start_bucket)
+
+		old, err := s.get_record(tx, r.Id)
+		if err == nil {
+			if err = idx.Delete(start_index_key(old)); err != nil {
+				return err
+			}
+		} else if err != ErrNotFound {
+			return err
+		}
+
+		if err = tx.Bucket(bbolt_records_bucket).Put([]byte(r.Id), buf); err != nil {
+			return err
+		}
+
+		if err = idx.Put(start_index_key(r), nil); err != nil {
+			return err
+		}
+
+		if dur := r.EndAt.Sub(r.StartAt); dur > s.get_max_duration(tx) {
+			dur_buf := make([]byte, 8)
+			binary.BigEndian.PutUint64(dur_buf, uint64(dur))
+			if err = tx.Bucket(bbolt_meta_bucket).Put(bbolt_max_duration_key, dur_buf); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *bboltRecordStorage) UnsetRecord(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		r, err := s.get_record(tx, id)
+		if err == ErrNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err = tx.Bucket(bbolt_records_start_bucket).Delete(start_index_key(r)); err != nil {
+			return err
+		}
+
+		return tx.Bucket(bbolt_records_bucket).Delete([]byte(id))
+	})
+}
+
+func (s *bboltRecordStorage) WalkSnapshot(fn func(*Record) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bbolt_records_bucket).ForEach(func(k, v []byte) error {
+			r, err := decode_record(v)
+			if err != nil {
+				return err
+			}
+			return fn(r)
+		})
+	})
+}
+
+// ReplaceRecords recreates record buckets in one transaction.
+func (s *bboltRecordStorage) ReplaceRecords(rs []*Record) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bbolt_records_bucket, bbolt_records_start_bucket} {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+
+		var max_dur time.Duration
+		for _, r := range rs {
+			buf, err := encode_record(r)
+			if err != nil {
+				return err
+			}
+
+			if err = tx.Bucket(bbolt_records_bucket).Put([]byte(r.Id), buf); err != nil {
+				return err
+			}
+
+			if err = tx.Bucket(bbolt_records_start_bucket).Put(start_index_key(r), nil); err != nil {
+				return err
+			}
+
+			if dur := r.EndAt.Sub(r.StartAt); dur > max_dur {
+				max_dur = dur
+			}
+		}
+
+		dur_buf := make([]byte, 8)
+		binary.BigEndian.PutUint64(dur_buf, uint64(max_dur))
+		return tx.Bucket(bbolt_meta_bucket).Put(bbolt_max_duration_key, dur_buf)
+	})
+}
+
+// migrate rewrites records in current schema version, in transactions
+// of RECORD_MIGRATION_BATCH_SIZE records.
+func (s *bboltRecordStorage) migrate() error {
+	var ver int
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		ver, err = parse_schema_version(tx.Bucket(bbolt_meta_bucket).Get(bbolt_schema_version_key))
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if err := check_schema_version(ver); err != nil {
+		return err
+	}
+
+	if ver == RECORD_SCHEMA_VERSION {
+		return nil
+	}
+
+	s.get_logger().WithField("version", ver).Infof("migrate record storage")
+
+	var count int
+	var next []byte
+	for done := false; !done; {
+		err := s.db.Update(func(tx *bolt.Tx) error {
+			bkt := tx.Bucket(bbolt_records_bucket)
+			cur := bkt.Cursor()
+
+			k, v := cur.First()
+			if next != nil {
+				k, v = cur.Seek(next)
+			}
+
+			// values are collected before put, cursor is invalidated by put.
+			keys, vals := [][]byte{}, [][]byte{}
+			for ; k != nil && len(keys) < RECORD_MIGRATION_BATCH_SIZE; k, v = cur.Next() {
+				val, err := migrate_record(v)
+				if err != nil {
+					return fmt.Errorf("%s: %v", k, err)
+				}
+
+				if val != nil {
+					keys = append(keys, append([]byte(nil), k...))
+					vals = append(vals, val)
+				}
+			}
+
+			for i := range keys {
+				if err := bkt.Put(keys[i], vals[i]); err != nil {
+					return err
+				}
+			}
+			count += len(keys)
+
+			if k == nil {
+				done = true
+				return tx.Bucket(bbolt_meta_bucket).Put(bbolt_schema_version_key, format_schema_version(RECORD_SCHEMA_VERSION))
+			}
+			next = append([]byte(nil), k...)
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	s.get_logger().WithFields(log.Fields{"version": RECORD_SCHEMA_VERSION, "records": count}).Infof("record storage migrated")
+
+	return nil
+}
+
+// migrate_start_index rebuilds start time index if index version changed.
+func (s *bboltRecordStorage) migrate_start_index() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(bbolt_meta_bucket)
+
+		ver, err := parse_schema_version(meta.Get(bbolt_index_version_key))
+		if err != nil {
+			return err
+		}
+
+		if ver == BBOLT_INDEX_VERSION {
+			return nil
+		}
+
+		if err = tx.DeleteBucket(bbolt_records_start_bucket); err != nil {
+			return err
+		}
+
+		idx, err := tx.CreateBucket(bbolt_records_start_bucket)
+		if err != nil {
+			return err
+		}
+
+		var count int
+		if err = tx.Bucket(bbolt_records_bucket).ForEach(func(k, v []byte) error {
+			r, err := decode_record(v)
+			if err != nil {
+				return fmt.Errorf("%s: %v", k, err)
+			}
+			count++
+			return idx.Put(start_index_key(r), nil)
+		}); err != nil {
+			return err
+		}
+
+		if count > 0 {
+			s.get_logger().WithFields(log.Fields{"version": BBOLT_INDEX_VERSION, "records": count}).Infof("record storage index rebuilt")
+		}
+
+		return meta.Put(bbolt_index_version_key, format_schema_version(BBOLT_INDEX_VERSION))
+	})
+}
+
+func (s *bboltRecordStorage) Close() error {
+	return s.db.Close()
+}
+
+func NewBboltRecordStorage(opt *RecordStorageOption, args ...interface{}) (RecordStorage, error) {
+	var logger log.FieldLogger
+	var db *bolt.DB
+	var err error
+
+	if err = opt_helper.Setopt(opt_helper.SetoptConds{
+		"logger": opt_helper.ToLogger(&logger),
+	})(args...); err != nil {
+		return nil, err
+	}
+
+	if val := opt.GetString("file"); val != "" {
+		if err = os.MkdirAll(filepath.Dir(val), 0755); err != nil {
+			return nil, err
+		}
+
+		if db, err = bolt.Open(val, 0600, &bolt.Options{Timeout: 3 * time.Second}); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, new_invalid_config_error("file")
+	}
+
+	if err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bbolt_records_bucket, bbolt_records_start_bucket, bbolt_meta_bucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	stor := &bboltRecordStorage{
+		db:     db,
+		opt:    opt,
+		logger: logger,
+	}
+
+	if err = stor.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err = stor.migrate_start_index(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return stor, nil
+}
+
+var register_bbolt_record_storage_once sync.Once
+
+func init() {
+	register_bbolt_record_storage_once.Do(func() {
+		register_record_storage_factory("bbolt", NewBboltRecordStorage)
+		register_record_storage_validator("bbolt", validate_record_storage_file)
+	})
+}
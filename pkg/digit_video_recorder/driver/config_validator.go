@@ -0,0 +1,237 @@
+package digit_video_recorder_driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+
+	id_helper "github.com/nayotta/metathings/pkg/common/id"
+)
+
+var (
+	config_frame_size_regexp = regexp.MustCompile(`^\d+x\d+$`)
+	config_frame_rate_regexp = regexp.MustCompile(`^\d+(\.\d+)?(/\d+)?$`)
+	config_bit_rate_regexp   = regexp.MustCompile(`^\d+(\.\d+)?[kKmMgG]?$`)
+)
+
+type config_getter interface {
+	IsSet(string) bool
+	GetString(string) string
+}
+
+// config_validator collects all config problems instead of
+// stopping at the first one, keys are prefixed with validator prefix.
+type config_validator struct {
+	prefix string
+	errs   *ConfigErrors
+}
+
+func new_config_validator() *config_validator {
+	return &config_validator{errs: new(ConfigErrors)}
+}
+
+func (v *config_validator) sub(key string) *config_validator {
+	return &config_validator{prefix: v.key(key), errs: v.errs}
+}
+
+func (v *config_validator) key(key string) string {
+	if v.prefix == "" {
+		return key
+	}
+	return v.prefix + "." + key
+}
+
+func (v *config_validator) invalid(key string, format string, args ...interface{}) {
+	*v.errs = append(*v.errs, &InvalidConfigError{Key: v.key(key), Reason: fmt.Sprintf(format, args...)})
+}
+
+func (v *config_validator) error() error {
+	if len(*v.errs) == 0 {
+		return nil
+	}
+	return *v.errs
+}
+
+func (v *config_validator) require_string(opt config_getter, key string) string {
+	val := opt.GetString(key)
+	if val == "" {
+		v.invalid(key, "required")
+	}
+	return val
+}
+
+func (v *config_validator) match_string(opt config_getter, key string, exp *regexp.Regexp, reason string) {
+	if val := opt.GetString(key); val != "" && !exp.MatchString(val) {
+		v.invalid(key, "%v, got `%v`", reason, val)
+	}
+}
+
+func (v *config_validator) require_positive_int(opt config_getter, key string) int {
+	val := opt.GetString(key)
+	if val == "" {
+		v.invalid(key, "required")
+		return 0
+	}
+
+	n, err := strconv.Atoi(val)
+	if err != nil || n <= 0 {
+		v.invalid(key, "expect positive integer, got `%v`", val)
+		return 0
+	}
+
+	return n
+}
+
+func (v *config_validator) optional_positive_int(opt config_getter, key string) int {
+	if !opt.IsSet(key) {
+		return 0
+	}
+	return v.require_positive_int(opt, key)
+}
+
+// writable_dir checks dir is an existing writable directory, without side effects.
+func (v *config_validator) writable_dir(key string, dir string) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		v.invalid(key, "directory %v not accessible: %v", dir, err)
+		return
+	}
+
+	if !info.IsDir() {
+		v.invalid(key, "%v is not a directory", dir)
+		return
+	}
+
+	if err = check_dir_writable(dir); err != nil {
+		v.invalid(key, "directory %v not writable: %v", dir, err)
+	}
+}
+
+// validate_output_file_template renders output file template of output profile
+// with a sample record, directories in template are created on commit,
+// so the nearest existing directory should be writable.
+func (v *config_validator) validate_output_file_template(key string, profile *DigitVideoRecorderDriverOption) {
+	tmpl, err := new_output_file_template(profile.GetString("file"))
+	if err != nil {
+		v.invalid(key, "bad template: %v", err)
+		return
+	}
+
+	loc, err := get_output_timezone(profile)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	r := &Record{
+		Id:      id_helper.NewId(),
+		StartAt: now,
+		EndAt:   now.Add(time.Duration(profile.GetInt("segment_time")) * time.Second),
+		Profile: profile.GetString("name"),
+	}
+
+	path, err := render_output_file(tmpl, r, profile, loc)
+	if err != nil {
+		v.invalid(key, "failed to render template: %v", err)
+		return
+	}
+
+	// base dir of relative template is working directory of module.
+	if !filepath.IsAbs(path) {
+		v.invalid(key, "expect absolute path, got `%v`", profile.GetString("file"))
+		return
+	}
+
+	v.writable_dir(key, existing_parent_dir(path))
+}
+
+func (v *config_validator) validate_record_storage_option(opt *DigitVideoRecorderDriverOption, key string) {
+	sub := opt.Sub(key)
+	if sub == nil {
+		v.invalid(key, "required")
+		return
+	}
+
+	name := sub.GetString("name")
+	vld, ok := record_storage_validators[name]
+	if !ok {
+		v.invalid(key+".name", "unknown record storage `%v`", name)
+		return
+	}
+
+	vld(v.sub(key), &RecordStorageOption{sub.Viper})
+}
+
+type digit_video_recorder_driver_validator func(*config_validator, *DigitVideoRecorderDriverOption)
+
+var digit_video_recorder_driver_validators map[string]digit_video_recorder_driver_validator
+var digit_video_recorder_driver_validators_once sync.Once
+
+func register_digit_video_recorder_driver_validator(name string, vld digit_video_recorder_driver_validator) {
+	digit_video_recorder_driver_validators_once.Do(func() {
+		digit_video_recorder_driver_validators = make(map[string]digit_video_recorder_driver_validator)
+	})
+	digit_video_recorder_driver_validators[name] = vld
+}
+
+type record_storage_validator func(*config_validator, *RecordStorageOption)
+
+var record_storage_validators map[string]record_storage_validator
+var record_storage_validators_once sync.Once
+
+func register_record_storage_validator(name string, vld record_storage_validator) {
+	record_storage_validators_once.Do(func() {
+		record_storage_validators = make(map[string]record_storage_validator)
+	})
+	record_storage_validators[name] = vld
+}
+
+// validate_record_storage_file checks storage file option, the file or
+// its nearest existing ancestor directory should be writable,
+// missing directories are created by storage.
+func validate_record_storage_file(v *config_validator, opt *RecordStorageOption) {
+	file := v.require_string(opt, "file")
+	if file == "" {
+		return
+	}
+
+	if _, err := os.Stat(file); err == nil {
+		return
+	}
+
+	v.writable_dir("file", existing_parent_dir(file))
+}
+
+// ValidateDigitVideoRecorderDriverOption checks whole driver options,
+// returns ConfigErrors contains all problems, or nil if options are valid.
+func ValidateDigitVideoRecorderDriverOption(opt *DigitVideoRecorderDriverOption) error {
+	v := new_config_validator()
+
+	name := opt.GetString("name")
+	vld, ok := digit_video_recorder_driver_validators[name]
+	if !ok {
+		v.invalid("name", "unknown digit video recorder driver `%v`", name)
+		return v.error()
+	}
+
+	vld(v, opt)
+
+	return v.error()
+}
+
+func NewDigitVideoRecorderDriverOptionFromText(text string) (*DigitVideoRecorderDriverOption, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(text)); err != nil {
+		return nil, err
+	}
+
+	return &DigitVideoRecorderDriverOption{v}, nil
+}
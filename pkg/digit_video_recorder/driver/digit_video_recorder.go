@@ -9,11 +9,38 @@ import (
 	"github.com/spf13/viper"
 )
 
+// DEFAULT_OUTPUT_PROFILE is profile of records recorded without output profiles.
+const DEFAULT_OUTPUT_PROFILE = "default"
+
 type Record struct {
 	Id      string    `yaml:"id"`
 	StartAt time.Time `yaml:"start_at"`
 	EndAt   time.Time `yaml:"end_at"`
 	Path    string    `yaml:"path"`
+	Profile string    `yaml:"profile,omitempty"`
+	// recorded in audio mode, no video stream.
+	AudioOnly bool `yaml:"audio_only,omitempty"`
+	// segment moving to Path, see ffmpeg_commit.go.
+	Pending bool   `yaml:"pending,omitempty"`
+	Source  string `yaml:"source,omitempty"`
+	// post-processing results by hook name, see ffmpeg_hook.go.
+	Hooks map[string]*HookResult `yaml:"hooks,omitempty"`
+}
+
+type HookResult struct {
+	// ok, failed, timeout or skipped.
+	Status     string    `yaml:"status"`
+	Error      string    `yaml:"error,omitempty"`
+	FinishedAt time.Time `yaml:"finished_at"`
+}
+
+// GetProfile returns output profile of record, records stored before
+// output profiles supported belong to default profile.
+func (r *Record) GetProfile() string {
+	if r.Profile == "" {
+		return DEFAULT_OUTPUT_PROFILE
+	}
+	return r.Profile
 }
 
 func (r *Record) Reader() (io.Reader, error) {
@@ -22,10 +49,12 @@ func (r *Record) Reader() (io.Reader, error) {
 
 func (r *Record) Data() map[string]interface{} {
 	return map[string]interface{}{
-		"id":       r.Id,
-		"start_at": r.StartAt.Unix(),
-		"end_at":   r.EndAt.Unix(),
-		"path":     r.Path,
+		"id":         r.Id,
+		"start_at":   r.StartAt.Unix(),
+		"end_at":     r.EndAt.Unix(),
+		"path":       r.Path,
+		"profile":    r.GetProfile(),
+		"audio_only": r.AudioOnly,
 	}
 }
 
@@ -52,14 +81,52 @@ func (s *DigitVideoRecorderState) String() string {
 var (
 	DIGITI_VIDEO_RECORDER_STATE_ON  = &DigitVideoRecorderState{state: "on"}
 	DIGITI_VIDEO_RECORDER_STATE_OFF = &DigitVideoRecorderState{state: "off"}
+	// ffmpeg stalled and failed to restart.
+	DIGITI_VIDEO_RECORDER_STATE_STALLED = &DigitVideoRecorderState{state: "stalled"}
 )
 
+// RecordingCounters are cumulative since driver created.
+type RecordingCounters struct {
+	Restarts          int64
+	SegmentsCommitted int64
+	SegmentFailures   int64
+	BytesWritten      int64
+	Stalls            int64
+	LastStallAt       time.Time
+}
+
+type RecordingStats struct {
+	RecordingCounters
+	State       string
+	Frames      int64
+	Fps         float64
+	Bitrate     float64 // kbits/s
+	TotalSize   int64
+	OutTime     time.Duration
+	DupFrames   int64
+	DropFrames  int64
+	Speed       float64
+	SegmentFile string
+	SegmentSize int64
+	StartedAt   time.Time
+	UpdatedAt   time.Time
+}
+
 type DigitVideoRecorderDriver interface {
 	Start() error
 	Stop() error
+	Reconfigure(*DigitVideoRecorderDriverOption) error
 	State() *DigitVideoRecorderState
+	Stats() (*RecordingStats, error)
 	GetRecord(id string) (*Record, error)
 	ListRecords(ListRecordsFitler) ([]*Record, error)
+	ImportRecords(*ImportRecordsOption) (*ImportRecordsResult, error)
+	ReindexRecords() (*ReindexRecordsResult, error)
+	// name of backup file is relative to backup dir.
+	BackupIndex(name string) (*IndexBackup, error)
+	RestoreIndex(name string) (*IndexBackup, error)
+	// OnEvent adds event handler, see event.go.
+	OnEvent(EventHandler)
 }
 
 type DigitVideoRecorderDriverFactory func(opt *DigitVideoRecorderDriverOption, args ...interface{}) (DigitVideoRecorderDriver, error)
@@ -3,15 +3,54 @@ package digit_video_recorder_driver
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 var (
 	ErrInvalidDigitVideoRecorderDriver = errors.New("invalid digit video recorder driver")
 	ErrInvalidRecordStorage            = errors.New("invalid record storage")
 	ErrNotStartable                    = errors.New("not startable")
+	ErrNotStopped                      = errors.New("not stopped")
 	ErrNotFound                        = errors.New("record not found")
+	ErrInvalidTimelineRange            = errors.New("invalid timeline range")
+	ErrTooManyTimelineBuckets          = errors.New("too many timeline buckets")
+	ErrUnsupportedSchemaVersion        = errors.New("unsupported schema version")
+	ErrIndexBackupDisabled             = errors.New("index backup disabled, backup_dir not set")
+	ErrInvalidIndexBackupPath          = errors.New("invalid index backup path, expect relative path in backup_dir")
+	ErrRelativeOutputFile              = errors.New("relative output file template, expect absolute path")
 )
 
+type InvalidConfigError struct {
+	Key    string
+	Reason string
+}
+
+func (e *InvalidConfigError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("invalid config: %s", e.Key)
+	}
+	return fmt.Sprintf("invalid config: %s: %s", e.Key, e.Reason)
+}
+
+type ConfigErrors []*InvalidConfigError
+
+func (es ConfigErrors) Error() string {
+	var ss []string
+	for _, e := range es {
+		ss = append(ss, e.Error())
+	}
+	return strings.Join(ss, "; ")
+}
+
+type InvalidImportPathError struct {
+	Path   string
+	Reason string
+}
+
+func (e *InvalidImportPathError) Error() string {
+	return fmt.Sprintf("invalid import path: %s: %s", e.Path, e.Reason)
+}
+
 func new_invalid_config_error(key string) error {
-	return errors.New(fmt.Sprintf("invalid config: %s", key))
+	return &InvalidConfigError{Key: key}
 }
@@ -0,0 +1,64 @@
+package digit_video_recorder_driver
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+ * Events:
+ *   segment.finalized: segment committed to record, data is `Record.Data()` and `size`.
+ *   state.changed: recorder state changed, data is `state` and `previous`.
+ *   error: recording failed, data is `error` and `source`, source is
+ *          `ffmpeg` for ffmpeg exited with error, `segment` for segment
+ *          failed to commit, `watchdog` for ffmpeg failed to restart after stall.
+ *   handlers are called synchronously by driver, maybe with driver locked,
+ *   handlers should not block or call driver.
+ */
+
+const (
+	EVENT_SEGMENT_FINALIZED = "segment.finalized"
+	EVENT_STATE_CHANGED     = "state.changed"
+	EVENT_ERROR             = "error"
+
+	EVENT_ERROR_SOURCE_FFMPEG   = "ffmpeg"
+	EVENT_ERROR_SOURCE_SEGMENT  = "segment"
+	EVENT_ERROR_SOURCE_WATCHDOG = "watchdog"
+)
+
+type Event struct {
+	Type string
+	Time time.Time
+	Data map[string]interface{}
+}
+
+type EventHandler func(*Event)
+
+type event_emitter struct {
+	mtx      sync.RWMutex
+	handlers []EventHandler
+}
+
+func (e *event_emitter) OnEvent(fn EventHandler) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.handlers = append(e.handlers, fn)
+}
+
+func (e *event_emitter) emit_event(typ string, data map[string]interface{}) {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+
+	evt := &Event{Type: typ, Time: time.Now(), Data: data}
+	for _, fn := range e.handlers {
+		fn(evt)
+	}
+}
+
+func (e *event_emitter) emit_error_event(source string, err error) {
+	e.emit_event(EVENT_ERROR, map[string]interface{}{
+		"source": source,
+		"error":  err.Error(),
+	})
+}
@@ -0,0 +1,49 @@
+package digit_video_recorder_driver
+
+/*
+ * Audio mode:
+ *   record audio only from microphones or streams, video options are ignored,
+ *   segments are committed to the same storage, records are marked audio only.
+ * Options:
+ *   driver:
+ *     mode: audio  // `video` or `audio`, default `video`.
+ *     input:
+ *       format: <format>  // input file format, like `alsa` or `pulse`.
+ *       file: <path>  // like `hw:0` or `rtsp://...`.
+ *     output:
+ *       format: <format>  // audio container, like `m4a`, `ogg`, `wav`.
+ *     audio:  // required.
+ *       codec:
+ *         name: <codec>  // like `aac` for m4a, `libopus` for ogg, `pcm_s16le` for wav.
+ *         [ bit_rate: <rate> ]  // audio bitrate, like `64k`.
+ */
+
+const (
+	FFMPEG_MODE_VIDEO = "video"
+	FFMPEG_MODE_AUDIO = "audio"
+)
+
+// ffmpeg muxers of output formats which are not muxer names,
+// output format is still used as file extension.
+var ffmpeg_segment_formats = map[string]string{
+	"m4a": "ipod",
+}
+
+func is_audio_mode(opt *DigitVideoRecorderDriverOption) bool {
+	return opt.GetString("mode") == FFMPEG_MODE_AUDIO
+}
+
+func get_ffmpeg_segment_format(format string) string {
+	if val, ok := ffmpeg_segment_formats[format]; ok {
+		return val
+	}
+	return format
+}
+
+func validate_ffmpeg_mode(v *config_validator, opt *DigitVideoRecorderDriverOption) {
+	switch mode := opt.GetString("mode"); mode {
+	case "", FFMPEG_MODE_VIDEO, FFMPEG_MODE_AUDIO:
+	default:
+		v.invalid("mode", "expect `video` or `audio`, got `%v`", mode)
+	}
+}
@@ -0,0 +1,230 @@
+package digit_video_recorder_driver
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+/*
+ * Segment commit:
+ *   1. record is stored as pending, with segment source path.
+ *   2. segment is renamed to output file, or copied and synced if
+ *      output file is on another filesystem.
+ *   3. record is confirmed.
+ *   pending records are recovered when driver created, records are confirmed
+ *   if output file exists, segments are moved again if source exists,
+ *   records are removed if both are missing.
+ */
+
+const FFMPEG_COMMIT_TEMP_PREFIX = ".mtdvr-commit-"
+
+func is_cross_device_error(err error) bool {
+	if e, ok := err.(*os.LinkError); ok {
+		return e.Err == syscall.EXDEV
+	}
+	return false
+}
+
+// sync_dir flushes directory entries, errors are ignored on
+// platforms not supporting directory sync.
+func sync_dir(dir string) {
+	if f, err := os.Open(dir); err == nil {
+		f.Sync()
+		f.Close()
+	}
+}
+
+// FFMPEG_COMMIT_COPY_BUFFER_SIZE is the chunk size of file copy,
+// copy is canceled between chunks.
+const FFMPEG_COMMIT_COPY_BUFFER_SIZE = 1 << 20
+
+// copy_context copies src to dst in chunks, returns ctx error if ctx is
+// done between chunks.
+func copy_context(ctx context.Context, dst io.Writer, src io.Reader) error {
+	buf := make([]byte, FFMPEG_COMMIT_COPY_BUFFER_SIZE)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// copy_file copies src to a temp file beside dst, syncs it
+// and renames it to dst, dst is complete or not existing.
+func copy_file(src, dst string) error {
+	return copy_file_context(context.Background(), src, dst)
+}
+
+// copy_file_context is copy_file canceled by ctx, temp file is removed
+// if canceled.
+func copy_file_context(ctx context.Context, src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := ioutil.TempFile(filepath.Dir(dst), FFMPEG_COMMIT_TEMP_PREFIX)
+	if err != nil {
+		return err
+	}
+	tmp := out.Name()
+
+	if err = copy_context(ctx, out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err = out.Sync(); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err = out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err = os.Chmod(tmp, 0644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err = os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+// move_file renames src to dst, falls back to copy
+// if src and dst on different filesystems.
+func move_file(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		sync_dir(filepath.Dir(dst))
+		return nil
+	}
+
+	if !is_cross_device_error(err) {
+		return err
+	}
+
+	if err = copy_file(src, dst); err != nil {
+		return err
+	}
+	sync_dir(filepath.Dir(dst))
+
+	return os.Remove(src)
+}
+
+// commit_record moves or copies file to record path and stores record
+// by pending and confirm steps, source of copy is not recorded, so it
+// is never removed by recovery.
+func (drv *FFmpegDigitVideoRecorderDriver) commit_record(r *Record, src string, keep_source bool) error {
+	var err error
+
+	r.Pending, r.Source = true, src
+	if keep_source {
+		r.Source = ""
+	}
+	if err = drv.storage.SetRecord(r); err != nil {
+		return err
+	}
+
+	if keep_source {
+		err = copy_file(src, r.Path)
+		sync_dir(filepath.Dir(r.Path))
+	} else {
+		err = move_file(src, r.Path)
+	}
+	if err != nil {
+		if e := drv.storage.UnsetRecord(r.Id); e != nil {
+			drv.get_logger().WithError(e).WithField("record", r.Id).Warningf("failed to unset pending record")
+		}
+		return err
+	}
+
+	r.Pending, r.Source = false, ""
+	return drv.storage.SetRecord(r)
+}
+
+func is_file_exist(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// remove_commit_temp_files removes temp files left by interrupted copy.
+func remove_commit_temp_files(dir string) {
+	names, err := filepath.Glob(filepath.Join(dir, FFMPEG_COMMIT_TEMP_PREFIX+"*"))
+	if err != nil {
+		return
+	}
+
+	for _, name := range names {
+		os.Remove(name)
+	}
+}
+
+// recover_pending_records finishes or rollbacks commits interrupted by crash.
+func (drv *FFmpegDigitVideoRecorderDriver) recover_pending_records() error {
+	rs, err := drv.storage.ListRecords(ListRecordsFitler{})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rs {
+		if !r.Pending {
+			continue
+		}
+
+		logger := drv.get_logger().WithField("record", r.Id)
+		remove_commit_temp_files(filepath.Dir(r.Path))
+
+		switch {
+		case is_file_exist(r.Path):
+			// output file renamed or copied completely.
+			if r.Source != "" {
+				os.Remove(r.Source)
+			}
+		case r.Source != "" && is_file_exist(r.Source):
+			if err = move_file(r.Source, r.Path); err != nil {
+				logger.WithError(err).Warningf("failed to move segment of pending record")
+				continue
+			}
+		default:
+			logger.Warningf("segment of pending record lost, remove record")
+			if err = drv.storage.UnsetRecord(r.Id); err != nil {
+				return err
+			}
+			continue
+		}
+
+		r.Pending, r.Source = false, ""
+		if err = drv.storage.SetRecord(r); err != nil {
+			return err
+		}
+		logger.Infof("pending record recovered")
+	}
+
+	return nil
+}
@@ -3,12 +3,11 @@ package digit_video_recorder_driver
 import (
 	"context"
 	"fmt"
-	"html/template"
 	"io/ioutil"
 	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
@@ -26,61 +25,93 @@ import (
  * Options:
  *   driver:
  *     name: ffmpeg
+ *     [ mode: <mode> ]  // `video` or `audio`, default `video`, see ffmpeg_audio.go.
+ *     [ binary: <path> ]  // ffmpeg binary, default `ffmpeg`.
+ *     [ stop_timeout: <sec> ]  // wait ffmpeg to finish current segment when stopping, default 10.
+ *     [ channel: <name> ]  // channel name for output file template, see ffmpeg_template.go.
+ *     [ timezone: <tz> ]  // timezone for output file template, see ffmpeg_template.go.
  *     input:
  *       format: <format>  // input file format, like `v4l2`.
  *       file: <path>  // file path, like `/dev/video0` etc.
  *       [ frame_size: <width>x<height> ]  // frame size, like `640x480`.
  *       [ frame_rate: <rate> ]  // frame rate, like `30`.
+ *       [ extra: [ ... ] ]  // list of extra arguments for input, like `-re`.
  *     output:
  *       format: <format>  // output file format, like `mp4`.
  *       segment_time: <sec>  // segment time
- *       file: <path>  // video file, path template supported.
- *                     // fields:
- *                     //   id: video id, 32 bytes.
- *                     //   start_at: timestamp, recording start at the time
- *                     //   end_at: timestamp, recording end at the time
- *                     // example: /myvideo/{.id}-{.start_at}-{.end_at}.mp4
+ *       file: <path>  // video file, path template supported, see ffmpeg_template.go.
+ *                     // example: /myvideo/{{.id}}-{{.start_at}}-{{.end_at}}.mp4
  *     video:
  *       codec:
  *         name: <codec>  // video codec, like `h264_omx` for raspberry pi.
  *         [ bit_rate: <rate> ]  // video bitrate, like `2000k`.
  *         [ extra: [ ... ] ]  // list of extra arguments for codec.
+ *       [ overlay: ... ]  // timestamp and text burned into video, see ffmpeg_overlay.go.
  *     audio:
  *       codec:
  *         name: <codec>  // audio codec, like `copy` for copy rtsp to file
+ *         [ bit_rate: <rate> ]  // audio bitrate, like `64k`.
+ *     [ outputs: [ ... ] ]  // multiple output profiles, see ffmpeg_output.go.
+ *     [ privacy_masks: [ ... ] ]  // regions blanked in video, see ffmpeg_privacy_mask.go.
+ *     [ watchdog: ... ]  // see ffmpeg_watchdog.go.
+ *     [ live: ... ]  // see ffmpeg_live.go.
+ *     [ ffprobe: <path> ]  // ffprobe binary for importing records, see ffmpeg_import.go.
+ *     [ import_roots: [ <path>, ... ] ]  // directories of importable files, see ffmpeg_import.go.
+ *     [ hooks: [ ... ] ]  // post-processing hooks on committed segments, see ffmpeg_hook.go.
+ *     [ backup_dir: <path> ]  // directory of index backups, see index_backup.go.
  */
 
 const (
-	FFMPEG_DEFAULT_BINARY = `ffmpeg`
+	FFMPEG_DEFAULT_BINARY       = `ffmpeg`
+	FFMPEG_DEFAULT_STOP_TIMEOUT = 10 * time.Second
 )
 
 type FFmpegDigitVideoRecorderDriver struct {
-	op_mtx            sync.Mutex
-	cfn               context.CancelFunc
-	tmp_dir           string
-	watcher           *fsnotify.Watcher
-	fs_evt_ch         chan fsnotify.Event
-	cmd               *exec.Cmd
-	logger            log.FieldLogger
-	opt               *DigitVideoRecorderDriverOption
-	st                *DigitVideoRecorderState
-	tmpl              *template.Template
-	storage           RecordStorage
-	writing_file_chan chan string
+	event_emitter
+	op_mtx    sync.Mutex
+	cfn       context.CancelFunc
+	tmp_dir   string
+	watcher   *fsnotify.Watcher
+	fs_evt_ch chan fsnotify.Event
+	cmd       *exec.Cmd
+	cmd_done  chan struct{}
+	logger    log.FieldLogger
+	opt       *DigitVideoRecorderDriverOption
+	st        *DigitVideoRecorderState
+	profiles  []*ffmpeg_output_profile
+	hooks     *ffmpeg_hook_pipeline
+	hook_mtx  sync.Mutex
+	storage   RecordStorage
+	stats_mtx sync.Mutex
+	stats     RecordingStats
 }
 
 func (drv *FFmpegDigitVideoRecorderDriver) get_logger() log.FieldLogger {
 	return drv.logger
 }
 
+// set_state sets recorder state, emits event if state changed.
+func (drv *FFmpegDigitVideoRecorderDriver) set_state(st *DigitVideoRecorderState) {
+	prev := drv.st
+	drv.st = st
+
+	if prev != st {
+		drv.emit_event(EVENT_STATE_CHANGED, map[string]interface{}{
+			"state":    st.String(),
+			"previous": prev.String(),
+		})
+	}
+}
+
 func (drv *FFmpegDigitVideoRecorderDriver) is_valid_file(name string) bool {
 	base := filepath.Base(name)
 	return strings.HasPrefix(base, "mtdvr-")
 }
 
-func (drv *FFmpegDigitVideoRecorderDriver) watch_file_loop() {
-	var cur string
-	ch := drv.writing_file_chan
+// watch_file_loop commits segment when next segment of the same profile
+// starts writing, stats follow segments of the first profile.
+func (drv *FFmpegDigitVideoRecorderDriver) watch_file_loop(ch chan string, profiles []*ffmpeg_output_profile, hooks *ffmpeg_hook_pipeline, tmp_dir string) {
+	curs := map[string]string{}
 _watch_file_loop:
 	for {
 		select {
@@ -89,131 +120,69 @@ _watch_file_loop:
 				break _watch_file_loop
 			}
 
-			if cur == name || !drv.is_valid_file(name) {
+			if !drv.is_valid_file(name) {
 				continue
 			}
 
-			if cur != "" {
-				if err := drv.process_file(cur); err != nil {
-					drv.get_logger().WithError(err).WithField("file", name).Warningf("failed to process file")
-				}
+			_, profile, _, err := parse_ffmpeg_segment_file(name)
+			if err != nil || curs[profile] == name {
+				continue
 			}
-			cur = name
-		}
-	}
-	drv.get_logger().Debugf("watch file loop exit")
-}
-
-func (drv *FFmpegDigitVideoRecorderDriver) parse_ffmpeg_command() (string, error) {
-	var cmd_str string
-	var err error
-
-	if val := drv.opt.GetString("binary"); val != "" {
-		cmd_str = val
-	} else {
-		cmd_str = FFMPEG_DEFAULT_BINARY
-	}
 
-	cmd_str += " -y"
-
-	// INPUT
-	input := drv.opt.Sub("input")
-	if input == nil {
-		return "", new_invalid_config_error("input")
-	}
-
-	if val := input.GetString("format"); val != "" {
-		cmd_str += " -f " + val
-	}
+			if profile == profiles[0].name {
+				drv.update_stats(func(stats *RecordingStats) {
+					stats.SegmentFile = name
+				})
+			}
 
-	if val := input.GetString("file"); val != "" {
-		cmd_str += " -i \"" + val + "\""
-	} else {
-		return "", new_invalid_config_error("input.file")
+			if cur := curs[profile]; cur != "" {
+				drv.commit_segment(profiles, hooks, cur)
+			}
+			curs[profile] = name
+		}
 	}
 
-	if val := input.GetString("frame_size"); val != "" {
-		cmd_str += " -s " + val
+	// ffmpeg exited, last segments are finished.
+	for _, cur := range curs {
+		if info, err := os.Stat(cur); err == nil && info.Size() > 0 {
+			drv.commit_segment(profiles, hooks, cur)
+		}
 	}
 
-	if val := input.GetString("frame_rate"); val != "" {
-		cmd_str += " -r " + val
-	}
+	// remove tmp dir if all segments processed.
+	remove_privacy_mask_files(tmp_dir)
+	os.Remove(tmp_dir)
 
-	// VIDEO
-	video := drv.opt.Sub("video")
-	if video == nil {
-		return "", new_invalid_config_error("video")
-	}
+	drv.get_logger().Debugf("watch file loop exit")
+}
 
-	video_codec := video.Sub("codec")
-	if video_codec == nil {
-		return "", new_invalid_config_error("video.codec")
-	}
+func (drv *FFmpegDigitVideoRecorderDriver) parse_ffmpeg_command() ([]string, error) {
+	var err error
 
-	if val := video_codec.GetString("name"); val != "" {
-		cmd_str += " -c:v " + val
-	} else {
-		return "", new_invalid_config_error("video.codec.name")
+	drv.tmp_dir, err = ioutil.TempDir("", "mt_mdl_dvr")
+	if err != nil {
+		return nil, err
 	}
 
-	if val := video_codec.GetString("bit_rate"); val != "" {
-		cmd_str += " -b:v " + val
+	argv, err := build_ffmpeg_command(drv.opt, drv.tmp_dir, time.Now().Unix())
+	if err != nil {
+		os.RemoveAll(drv.tmp_dir)
+		return nil, err
 	}
 
-	if val := video_codec.GetStringSlice("extra"); val != nil {
-		cmd_str += " " + strings.Join(val, " ")
+	if err = write_privacy_mask_files(drv.opt, drv.tmp_dir); err != nil {
+		os.RemoveAll(drv.tmp_dir)
+		return nil, err
 	}
 
-	// AUDIO
-	audio := drv.opt.Sub("audio")
-	if audio == nil {
-		cmd_str += " -an"
-	} else {
-		audio_codec := audio.Sub("codec")
-		if audio_codec == nil {
-			return "", new_invalid_config_error("audio.codec")
+	if live := drv.opt.Sub("live"); is_live_enabled(live) {
+		if err = prepare_live_dir(live); err != nil {
+			os.RemoveAll(drv.tmp_dir)
+			return nil, err
 		}
-
-		if val := audio_codec.GetString("name"); val != "" {
-			cmd_str += " -c:a " + val
-		} else {
-			return "", new_invalid_config_error("audio.codec.name")
-		}
-
-		if val := audio_codec.GetStringSlice("extra"); val != nil {
-			cmd_str += " " + strings.Join(val, " ")
-		}
-	}
-
-	// OUTPUT
-	output := drv.opt.Sub("output")
-	if output == nil {
-		return "", new_invalid_config_error("output")
-	}
-
-	var segment_format string
-	cmd_str += " -f segment"
-	if segment_format = output.GetString("format"); segment_format != "" {
-		cmd_str += " -segment_format " + segment_format
-	} else {
-		return "", new_invalid_config_error("output.format")
-	}
-
-	if val := output.GetString("segment_time"); val != "" {
-		cmd_str += " -segment_time " + val
-	} else {
-		return "", new_invalid_config_error("output.segment_time")
 	}
 
-	drv.tmp_dir, err = ioutil.TempDir("", "mt_mdl_dvr")
-	if err != nil {
-		return "", err
-	}
-
-	cmd_str += " " + path.Join(drv.tmp_dir, fmt.Sprintf("mtdvr-%d-%%08d.%v", time.Now().Unix(), segment_format))
-
-	return cmd_str, nil
+	return argv, nil
 }
 
 func (drv *FFmpegDigitVideoRecorderDriver) Reset() error {
@@ -226,15 +195,7 @@ func (drv *FFmpegDigitVideoRecorderDriver) Reset() error {
 func (drv *FFmpegDigitVideoRecorderDriver) reset() error {
 	var err error
 
-	if drv.writing_file_chan != nil {
-		close(drv.writing_file_chan)
-		drv.writing_file_chan = nil
-	}
-
-	if drv.cfn != nil {
-		drv.cfn()
-		drv.cfn = nil
-	}
+	drv.stop_ffmpeg()
 
 	if drv.watcher != nil {
 		if err = drv.watcher.Close(); err != nil {
@@ -243,67 +204,162 @@ func (drv *FFmpegDigitVideoRecorderDriver) reset() error {
 		drv.watcher = nil
 	}
 
-	drv.st = DIGITI_VIDEO_RECORDER_STATE_OFF
+	drv.set_state(DIGITI_VIDEO_RECORDER_STATE_OFF)
 
 	return nil
 }
 
-func (drv *FFmpegDigitVideoRecorderDriver) get_output_file_template() *template.Template {
-	if drv.tmpl == nil {
-		drv.tmpl = template.Must(template.New("driver").Parse(drv.opt.GetString("output.file")))
+// stop_ffmpeg interrupts ffmpeg to finish current segment,
+// and kills it if not exited in `stop_timeout` seconds.
+func (drv *FFmpegDigitVideoRecorderDriver) stop_ffmpeg() {
+	if drv.cfn == nil {
+		return
 	}
-	return drv.tmpl
+
+	if drv.cmd != nil && drv.cmd.Process != nil {
+		timeout := FFMPEG_DEFAULT_STOP_TIMEOUT
+		if val := drv.opt.GetInt("stop_timeout"); val > 0 {
+			timeout = time.Duration(val) * time.Second
+		}
+
+		if err := drv.cmd.Process.Signal(os.Interrupt); err == nil {
+			select {
+			case <-drv.cmd_done:
+			case <-time.After(timeout):
+				drv.get_logger().Warningf("ffmpeg not exited after interrupt, kill it")
+			}
+		}
+	}
+
+	drv.cfn()
+	drv.cfn = nil
+	drv.cmd = nil
+	drv.cmd_done = nil
 }
 
-func (drv *FFmpegDigitVideoRecorderDriver) process_file(path string) error {
-	var ts, idx int
+func (drv *FFmpegDigitVideoRecorderDriver) get_output_profiles() ([]*ffmpeg_output_profile, error) {
 	var err error
-	var buf strings.Builder
 
-	base := filepath.Base(path)
-	ext := filepath.Ext(base)
+	if drv.profiles == nil {
+		if drv.profiles, err = new_output_profiles(drv.opt); err != nil {
+			return nil, err
+		}
+	}
 
-	if _, err = fmt.Sscanf(base, "mtdvr-%d-%d"+ext, &ts, &idx); err != nil {
-		return err
+	return drv.profiles, nil
+}
+
+// commit_segment processes finished segment file, counts result
+// and runs hooks on record.
+func (drv *FFmpegDigitVideoRecorderDriver) commit_segment(profiles []*ffmpeg_output_profile, hooks *ffmpeg_hook_pipeline, path string) {
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		size = info.Size()
+	}
+
+	r, err := drv.process_file(profiles, path)
+	if err != nil {
+		drv.get_logger().WithError(err).WithField("file", path).Warningf("failed to process file")
+		drv.update_stats(func(stats *RecordingStats) {
+			stats.SegmentFailures++
+		})
+		drv.emit_error_event(EVENT_ERROR_SOURCE_SEGMENT, err)
+		return
+	}
+
+	drv.update_stats(func(stats *RecordingStats) {
+		stats.SegmentsCommitted++
+		stats.BytesWritten += size
+	})
+
+	data := r.Data()
+	data["size"] = size
+	drv.emit_event(EVENT_SEGMENT_FINALIZED, data)
+
+	if hooks != nil {
+		hooks.submit(drv, r)
+	}
+}
+
+func (drv *FFmpegDigitVideoRecorderDriver) process_file(profiles []*ffmpeg_output_profile, path string) (*Record, error) {
+	ts, name, idx, err := parse_ffmpeg_segment_file(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var profile *ffmpeg_output_profile
+	for _, p := range profiles {
+		if p.name == name {
+			profile = p
+			break
+		}
+	}
+	if profile == nil {
+		return nil, fmt.Errorf("unknown output profile %v", name)
 	}
 
 	id := id_helper.NewId()
-	segment_time := drv.opt.GetInt("output.segment_time")
-	start_at := ts + idx*segment_time
+	segment_time := int64(profile.segment_time())
+	start_at := ts + int64(idx)*segment_time
 	end_at := start_at + segment_time
 
 	r := &Record{
-		Id:      id,
-		StartAt: time.Unix(int64(start_at), 0),
-		EndAt:   time.Unix(int64(end_at), 0),
+		Id:        id,
+		StartAt:   time.Unix(start_at, 0),
+		EndAt:     time.Unix(end_at, 0),
+		Profile:   profile.name,
+		AudioOnly: profile.audio_only,
 	}
 
-	if err = drv.get_output_file_template().Execute(&buf, r.Data()); err != nil {
-		return err
+	dst, err := render_output_file(profile.tmpl, r, profile.opt, profile.loc)
+	if err != nil {
+		return nil, err
 	}
-	r.Path = buf.String()
 
-	if err = os.Rename(path, r.Path); err != nil {
-		return err
+	if err = os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return nil, err
 	}
 
-	if err = drv.storage.SetRecord(r); err != nil {
-		return err
+	if r.Path, err = resolve_output_file_collision(dst); err != nil {
+		return nil, err
+	}
+	if r.Path != dst {
+		drv.get_logger().WithFields(log.Fields{"file": dst, "path": r.Path}).Warningf("output file exists, rename segment")
 	}
 
-	return nil
+	if err = drv.commit_record(r, path, false); err != nil {
+		return nil, err
+	}
+
+	return r, nil
 }
 
 func (drv *FFmpegDigitVideoRecorderDriver) Start() error {
 	drv.op_mtx.Lock()
 	defer drv.op_mtx.Unlock()
 
+	return drv.start()
+}
+
+func (drv *FFmpegDigitVideoRecorderDriver) start() error {
 	if drv.cfn != nil {
 		drv.get_logger().WithError(ErrNotStartable).Debugf("ffmpeg not startable")
 		return ErrNotStartable
 	}
 
-	cmd_str, err := drv.parse_ffmpeg_command()
+	profiles, err := drv.get_output_profiles()
+	if err != nil {
+		drv.get_logger().WithError(err).Debugf("failed to parse output profiles")
+		return err
+	}
+
+	hooks, err := drv.get_hook_pipeline()
+	if err != nil {
+		drv.get_logger().WithError(err).Debugf("failed to parse hooks")
+		return err
+	}
+
+	argv, err := drv.parse_ffmpeg_command()
 	if err != nil {
 		drv.get_logger().WithError(err).Debugf("failed to parse ffmpeg command")
 		return err
@@ -318,18 +374,17 @@ func (drv *FFmpegDigitVideoRecorderDriver) Start() error {
 	err = drv.watcher.Add(drv.tmp_dir)
 	if err != nil {
 		drv.get_logger().WithError(err).Debugf("failed to watch filesystem")
+		drv.watcher.Close()
+		drv.watcher = nil
 		return err
 	}
 
-	// init write file channel
-	if drv.writing_file_chan == nil {
-		drv.writing_file_chan = make(chan string)
-	}
-
-	go drv.watch_file_loop()
-	go func() {
-		defer drv.Reset()
-		watcher := drv.watcher
+	// writing file channel closed by filesystem watcher goroutine,
+	// then watch file loop processes the last segment.
+	ch := make(chan string)
+	go drv.watch_file_loop(ch, profiles, hooks, drv.tmp_dir)
+	go func(watcher *fsnotify.Watcher) {
+		defer close(ch)
 	_fsnotify_loop:
 		for {
 			select {
@@ -338,7 +393,7 @@ func (drv *FFmpegDigitVideoRecorderDriver) Start() error {
 					break _fsnotify_loop
 				}
 				if event.Op&fsnotify.Write == fsnotify.Write {
-					drv.writing_file_chan <- event.Name
+					ch <- event.Name
 				}
 			case err, ok := <-watcher.Errors:
 				if !ok {
@@ -348,34 +403,55 @@ func (drv *FFmpegDigitVideoRecorderDriver) Start() error {
 			}
 		}
 		drv.get_logger().Debugf("filesystem watcher exited")
-	}()
+	}(drv.watcher)
 
 	ctx := context.TODO()
 	ctx, drv.cfn = context.WithCancel(ctx)
-	drv.cmd = exec.CommandContext(ctx, "/bin/bash", "-c", cmd_str)
+	drv.cmd = exec.CommandContext(ctx, argv[0], argv[1:]...)
+	if tz := drv.opt.GetString("timezone"); tz != "" {
+		// time of overlay.
+		drv.cmd.Env = append(os.Environ(), "TZ="+tz)
+	}
+	drv.cmd.Stdout = drv.new_progress_writer()
+	drv.cmd.Stderr = new_ffmpeg_line_writer(func(line string) {
+		drv.get_logger().WithField("ffmpeg", redact_ffmpeg_arg(line)).Debugf("ffmpeg output")
+	})
+	drv.get_logger().WithField("argv", redact_ffmpeg_argv(argv)).Debugf("start ffmpeg")
 
 	err = drv.cmd.Start()
 	if err != nil {
+		drv.reset()
 		return err
 	}
-	drv.st = DIGITI_VIDEO_RECORDER_STATE_ON
+	drv.cmd_done = make(chan struct{})
+	drv.set_state(DIGITI_VIDEO_RECORDER_STATE_ON)
+	drv.update_stats(func(stats *RecordingStats) {
+		*stats = RecordingStats{RecordingCounters: stats.RecordingCounters, StartedAt: time.Now()}
+	})
 
-	go func() {
-		err := drv.cmd.Wait()
+	if drv.is_watchdog_enabled(drv.opt) {
+		go drv.watchdog_loop(ctx, drv.cmd, drv.opt)
+	}
+
+	go func(cmd *exec.Cmd, done chan struct{}) {
+		err := cmd.Wait()
+		close(done)
 
 		drv.op_mtx.Lock()
 		defer drv.op_mtx.Unlock()
 
-		if drv.st == DIGITI_VIDEO_RECORDER_STATE_OFF {
+		// stopped by driver, or another ffmpeg started.
+		if drv.cmd != cmd {
 			return
 		}
 
 		if err != nil {
 			drv.logger.WithError(err).Warningf("ffmpeg exit with error")
+			drv.emit_error_event(EVENT_ERROR_SOURCE_FFMPEG, err)
 		}
 
 		drv.reset()
-	}()
+	}(drv.cmd, drv.cmd_done)
 
 	return nil
 }
@@ -384,6 +460,66 @@ func (drv *FFmpegDigitVideoRecorderDriver) Stop() error {
 	return drv.Reset()
 }
 
+func (drv *FFmpegDigitVideoRecorderDriver) Reconfigure(opt *DigitVideoRecorderDriverOption) error {
+	if err := drv.check_reconfigure_option(opt); err != nil {
+		return err
+	}
+
+	drv.op_mtx.Lock()
+	defer drv.op_mtx.Unlock()
+
+	return drv.reconfigure(opt)
+}
+
+// check_reconfigure_option validates new options,
+// driver and storage are not changeable without restarting module.
+func (drv *FFmpegDigitVideoRecorderDriver) check_reconfigure_option(opt *DigitVideoRecorderDriverOption) error {
+	if err := ValidateDigitVideoRecorderDriverOption(opt); err != nil {
+		return err
+	}
+
+	if opt.GetString("name") != drv.opt.GetString("name") {
+		return ConfigErrors{{Key: "name", Reason: "driver not reconfigurable"}}
+	}
+
+	if !reflect.DeepEqual(opt.Sub("storage").AllSettings(), drv.opt.Sub("storage").AllSettings()) {
+		return ConfigErrors{{Key: "storage", Reason: "storage not reconfigurable"}}
+	}
+
+	return nil
+}
+
+// reconfigure stops ffmpeg, swaps options and output profiles,
+// restarts ffmpeg if it was recording, rollbacks to old options on failure.
+func (drv *FFmpegDigitVideoRecorderDriver) reconfigure(opt *DigitVideoRecorderDriverOption) error {
+	was_on := drv.st == DIGITI_VIDEO_RECORDER_STATE_ON
+	old_opt, old_profiles, old_hooks := drv.opt, drv.profiles, drv.hooks
+
+	if err := drv.reset(); err != nil {
+		return err
+	}
+
+	drv.opt, drv.profiles, drv.hooks = opt, nil, nil
+
+	if was_on {
+		drv.update_stats(func(stats *RecordingStats) {
+			stats.Restarts++
+		})
+		if err := drv.start(); err != nil {
+			drv.get_logger().WithError(err).Warningf("failed to start ffmpeg with new config, rollback")
+			drv.opt, drv.profiles, drv.hooks = old_opt, old_profiles, old_hooks
+			if err := drv.start(); err != nil {
+				drv.get_logger().WithError(err).Errorf("failed to start ffmpeg with old config")
+			}
+			return err
+		}
+	}
+
+	drv.get_logger().Debugf("ffmpeg reconfigured")
+
+	return nil
+}
+
 func (drv *FFmpegDigitVideoRecorderDriver) State() *DigitVideoRecorderState {
 	drv.op_mtx.Lock()
 	defer drv.op_mtx.Unlock()
@@ -391,18 +527,74 @@ func (drv *FFmpegDigitVideoRecorderDriver) State() *DigitVideoRecorderState {
 	return drv.st
 }
 
+func (drv *FFmpegDigitVideoRecorderDriver) update_stats(fn func(*RecordingStats)) {
+	drv.stats_mtx.Lock()
+	defer drv.stats_mtx.Unlock()
+
+	fn(&drv.stats)
+}
+
+// new_progress_writer parses ffmpeg `-progress` output into stats.
+func (drv *FFmpegDigitVideoRecorderDriver) new_progress_writer() *ffmpeg_line_writer {
+	progress := new_ffmpeg_progress()
+	return new_ffmpeg_line_writer(func(line string) {
+		if progress.feed(line) {
+			drv.update_stats(progress.apply)
+		}
+	})
+}
+
+func (drv *FFmpegDigitVideoRecorderDriver) Stats() (*RecordingStats, error) {
+	st := drv.State()
+
+	drv.stats_mtx.Lock()
+	stats := drv.stats
+	drv.stats_mtx.Unlock()
+
+	stats.State = st.String()
+	if stats.SegmentFile != "" {
+		if info, err := os.Stat(stats.SegmentFile); err == nil {
+			stats.SegmentSize = info.Size()
+		}
+	}
+
+	return &stats, nil
+}
+
 func (drv *FFmpegDigitVideoRecorderDriver) GetRecord(id string) (*Record, error) {
 	drv.op_mtx.Lock()
 	defer drv.op_mtx.Unlock()
 
-	return drv.storage.GetRecord(id)
+	r, err := drv.storage.GetRecord(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Pending {
+		return nil, ErrNotFound
+	}
+
+	return r, nil
 }
 
+// ListRecords lists committed records, pending records are skipped.
 func (drv *FFmpegDigitVideoRecorderDriver) ListRecords(flt ListRecordsFitler) ([]*Record, error) {
 	drv.op_mtx.Lock()
 	defer drv.op_mtx.Unlock()
 
-	return drv.storage.ListRecords(flt)
+	rs, err := drv.storage.ListRecords(flt)
+	if err != nil {
+		return nil, err
+	}
+
+	var ys []*Record
+	for _, r := range rs {
+		if !r.Pending {
+			ys = append(ys, r)
+		}
+	}
+
+	return ys, nil
 }
 
 func NewFFmpegDigitVideoRecorderDriver(opt *DigitVideoRecorderDriverOption, args ...interface{}) (DigitVideoRecorderDriver, error) {
@@ -412,8 +604,7 @@ func NewFFmpegDigitVideoRecorderDriver(opt *DigitVideoRecorderDriverOption, args
 		"logger": opt_helper.ToLogger(&logger),
 	})(args...)
 
-	stor_opt := &RecordStorageOption{opt.Sub("storage").Viper}
-	stor, err := NewRecordStorage(stor_opt.GetString("name"), stor_opt, "logger", logger)
+	stor, err := OpenRecordStorage(opt, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -425,15 +616,73 @@ func NewFFmpegDigitVideoRecorderDriver(opt *DigitVideoRecorderDriverOption, args
 		st:      DIGITI_VIDEO_RECORDER_STATE_OFF,
 	}
 
+	if err = drv.recover_pending_records(); err != nil {
+		drv.logger.WithError(err).Warningf("failed to recover pending records")
+	}
+
 	drv.logger.Debugf("new ffmpeg digit video recorder")
 
 	return drv, nil
 }
 
+func validate_ffmpeg_digit_video_recorder_driver_option(v *config_validator, opt *DigitVideoRecorderDriverOption) {
+	v.require_string(opt, "input.file")
+	v.match_string(opt, "input.frame_size", config_frame_size_regexp, "expect <width>x<height>, like `640x480`")
+	v.match_string(opt, "input.frame_rate", config_frame_rate_regexp, "expect number or fraction, like `30` or `30000/1001`")
+
+	validate_ffmpeg_mode(v, opt)
+
+	validate_output_timezone(v, opt)
+
+	if opt.IsSet("outputs") {
+		validate_output_profiles(v, opt)
+	} else {
+		if !is_audio_mode(opt) {
+			v.require_string(opt, "video.codec.name")
+			v.match_string(opt, "video.codec.bit_rate", config_bit_rate_regexp, "expect bitrate, like `2000k`")
+			validate_video_overlay(v, opt)
+		}
+
+		if opt.IsSet("audio") || is_audio_mode(opt) {
+			v.require_string(opt, "audio.codec.name")
+			v.match_string(opt, "audio.codec.bit_rate", config_bit_rate_regexp, "expect bitrate, like `64k`")
+		}
+
+		v.require_string(opt, "output.format")
+		v.require_positive_int(opt, "output.segment_time")
+		if text := v.require_string(opt, "output.file"); text != "" {
+			if opts, err := get_output_profile_options(opt); err == nil {
+				v.validate_output_file_template("output.file", opts[0])
+			}
+		}
+	}
+
+	v.writable_dir("tmp_dir", os.TempDir())
+
+	v.optional_positive_int(opt, "stop_timeout")
+	v.optional_positive_int(opt, "watchdog.stall_timeout")
+	v.optional_positive_int(opt, "watchdog.check_interval")
+
+	validate_live_option(v, opt)
+
+	validate_privacy_masks(v, opt)
+
+	validate_hooks(v, opt)
+
+	validate_import_roots(v, opt)
+
+	if dir := opt.GetString("backup_dir"); dir != "" {
+		v.writable_dir("backup_dir", existing_parent_dir(dir+string(os.PathSeparator)))
+	}
+
+	v.validate_record_storage_option(opt, "storage")
+}
+
 var register_ffmpeg_digit_video_recorder_driver_once sync.Once
 
 func init() {
 	register_ffmpeg_digit_video_recorder_driver_once.Do(func() {
 		register_digit_video_recorder_driver_factory("ffmpeg", NewFFmpegDigitVideoRecorderDriver)
+		register_digit_video_recorder_driver_validator("ffmpeg", validate_ffmpeg_digit_video_recorder_driver_option)
 	})
 }
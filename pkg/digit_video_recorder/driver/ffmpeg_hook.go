@@ -0,0 +1,290 @@
+package digit_video_recorder_driver
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+/*
+ * Hooks:
+ *   post-processing pipeline run on each committed segment, hooks of a record
+ *   run in order, later hooks are skipped if a hook failed, result of each
+ *   hook is stored in record `hooks` by hook name.
+ *   pipelines of records run concurrently up to `hook_concurrency`,
+ *   segments are not processed if too many pipelines pending,
+ *   pending pipelines are lost when module exits.
+ *   hook timeout does not wait for processor, processor left running
+ *   should stop on ctx done, like `copy` does between chunks.
+ *   processors of other packages are registered by RegisterRecordProcessor
+ *   in init, and used by hook `type`.
+ * Options:
+ *   driver:
+ *     [ hook_concurrency: <n> ]  // concurrent pipelines, default 1.
+ *     hooks:
+ *       - name: <name>  // hook name, letters, digits and underscore, unique.
+ *         type: <type>  // record processor, `command`, `copy` or registered, see record_processor.go.
+ *         [ timeout: <sec> ]  // default 60.
+ *         [ profiles: [ <profile>, ... ] ]  // run for records of profiles, default all profiles.
+ *         ...  // options of record processor.
+ */
+
+const (
+	FFMPEG_HOOK_DEFAULT_TIMEOUT     = 60 * time.Second
+	FFMPEG_HOOK_DEFAULT_CONCURRENCY = 1
+	FFMPEG_HOOK_MAX_PENDING         = 1000
+
+	HOOK_STATUS_OK      = "ok"
+	HOOK_STATUS_FAILED  = "failed"
+	HOOK_STATUS_TIMEOUT = "timeout"
+	HOOK_STATUS_SKIPPED = "skipped"
+)
+
+type ffmpeg_hook struct {
+	name     string
+	timeout  time.Duration
+	profiles map[string]bool
+	proc     RecordProcessor
+}
+
+func (h *ffmpeg_hook) match(r *Record) bool {
+	return len(h.profiles) == 0 || h.profiles[r.GetProfile()]
+}
+
+type ffmpeg_hook_pipeline struct {
+	hooks   []*ffmpeg_hook
+	sem     chan struct{}
+	pending int32
+}
+
+func get_hook_options(opt *DigitVideoRecorderDriverOption) ([]*DigitVideoRecorderDriverOption, error) {
+	if !opt.IsSet("hooks") {
+		return nil, nil
+	}
+
+	items, err := cast.ToSliceE(opt.Get("hooks"))
+	if err != nil {
+		return nil, new_invalid_config_error("hooks")
+	}
+
+	var opts []*DigitVideoRecorderDriverOption
+	for i, item := range items {
+		m, err := cast.ToStringMapE(item)
+		if err != nil {
+			return nil, new_invalid_config_error(fmt.Sprintf("hooks.%d", i))
+		}
+
+		v := viper.New()
+		if err = v.MergeConfigMap(m); err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, &DigitVideoRecorderDriverOption{v})
+	}
+
+	return opts, nil
+}
+
+// new_hook_pipeline returns nil if no hooks.
+func new_hook_pipeline(opt *DigitVideoRecorderDriverOption) (*ffmpeg_hook_pipeline, error) {
+	opts, err := get_hook_options(opt)
+	if err != nil || len(opts) == 0 {
+		return nil, err
+	}
+
+	var hooks []*ffmpeg_hook
+	for _, h_opt := range opts {
+		proc, err := NewRecordProcessor(h_opt.GetString("type"), h_opt)
+		if err != nil {
+			return nil, err
+		}
+
+		timeout := FFMPEG_HOOK_DEFAULT_TIMEOUT
+		if val := h_opt.GetInt("timeout"); val > 0 {
+			timeout = time.Duration(val) * time.Second
+		}
+
+		profiles := map[string]bool{}
+		for _, p := range cast.ToStringSlice(h_opt.Get("profiles")) {
+			profiles[p] = true
+		}
+
+		hooks = append(hooks, &ffmpeg_hook{
+			name:     h_opt.GetString("name"),
+			timeout:  timeout,
+			profiles: profiles,
+			proc:     proc,
+		})
+	}
+
+	concurrency := FFMPEG_HOOK_DEFAULT_CONCURRENCY
+	if val := opt.GetInt("hook_concurrency"); val > 0 {
+		concurrency = val
+	}
+
+	return &ffmpeg_hook_pipeline{
+		hooks: hooks,
+		sem:   make(chan struct{}, concurrency),
+	}, nil
+}
+
+// run_hook runs hook with timeout, returns on timeout without waiting
+// for processor, processor panic is recovered as failure.
+func run_hook(h *ffmpeg_hook, r *Record) *HookResult {
+	ctx, cfn := context.WithTimeout(context.Background(), h.timeout)
+	defer cfn()
+
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic: %v", p)
+			}
+			done <- err
+		}()
+
+		err = h.proc.Process(ctx, r)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+	}
+
+	res := &HookResult{Status: HOOK_STATUS_OK, FinishedAt: time.Now()}
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		res.Status = HOOK_STATUS_TIMEOUT
+		res.Error = fmt.Sprintf("timeout after %v", h.timeout)
+	case err != nil:
+		res.Status = HOOK_STATUS_FAILED
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+// submit runs hooks of record in background.
+func (p *ffmpeg_hook_pipeline) submit(drv *FFmpegDigitVideoRecorderDriver, r *Record) {
+	if atomic.AddInt32(&p.pending, 1) > FFMPEG_HOOK_MAX_PENDING {
+		atomic.AddInt32(&p.pending, -1)
+		drv.get_logger().WithField("record", r.Id).Warningf("too many pending hooks, skip record")
+		return
+	}
+
+	go func() {
+		defer atomic.AddInt32(&p.pending, -1)
+
+		p.sem <- struct{}{}
+		defer func() { <-p.sem }()
+
+		p.run(drv, r)
+	}()
+}
+
+func (p *ffmpeg_hook_pipeline) run(drv *FFmpegDigitVideoRecorderDriver, r *Record) {
+	failed := false
+	for _, h := range p.hooks {
+		if !h.match(r) {
+			continue
+		}
+
+		logger := drv.get_logger().WithFields(map[string]interface{}{"record": r.Id, "hook": h.name})
+
+		var res *HookResult
+		if failed {
+			res = &HookResult{Status: HOOK_STATUS_SKIPPED, FinishedAt: time.Now()}
+		} else {
+			res = run_hook(h, r)
+		}
+
+		if res.Status == HOOK_STATUS_OK || res.Status == HOOK_STATUS_SKIPPED {
+			logger.WithField("status", res.Status).Debugf("hook finished")
+		} else {
+			failed = true
+			logger.WithField("status", res.Status).Warningf("hook failed: %v", res.Error)
+		}
+
+		if err := drv.set_hook_result(r.Id, h.name, res); err != nil {
+			logger.WithError(err).Warningf("failed to store hook result")
+		}
+	}
+}
+
+// set_hook_result stores hook result on record, record removed
+// while hooks running is ignored.
+func (drv *FFmpegDigitVideoRecorderDriver) set_hook_result(id string, name string, res *HookResult) error {
+	drv.hook_mtx.Lock()
+	defer drv.hook_mtx.Unlock()
+
+	r, err := drv.storage.GetRecord(id)
+	if err == ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if r.Hooks == nil {
+		r.Hooks = map[string]*HookResult{}
+	}
+	r.Hooks[name] = res
+
+	return drv.storage.SetRecord(r)
+}
+
+func (drv *FFmpegDigitVideoRecorderDriver) get_hook_pipeline() (*ffmpeg_hook_pipeline, error) {
+	var err error
+
+	if drv.hooks == nil {
+		if drv.hooks, err = new_hook_pipeline(drv.opt); err != nil {
+			return nil, err
+		}
+	}
+
+	return drv.hooks, nil
+}
+
+func validate_hooks(v *config_validator, opt *DigitVideoRecorderDriverOption) {
+	v.optional_positive_int(opt, "hook_concurrency")
+
+	opts, err := get_hook_options(opt)
+	if err != nil {
+		if e, ok := err.(*InvalidConfigError); ok {
+			v.invalid(e.Key, "expect list of hooks")
+		} else {
+			v.invalid("hooks", "%v", err)
+		}
+		return
+	}
+
+	names := map[string]bool{}
+	for i, h_opt := range opts {
+		hv := v.sub(fmt.Sprintf("hooks.%d", i))
+
+		if name := hv.require_string(h_opt, "name"); name != "" {
+			if !ffmpeg_output_profile_name_regexp.MatchString(name) {
+				hv.invalid("name", "expect letters, digits and underscore, got `%v`", name)
+			} else if names[name] {
+				hv.invalid("name", "duplicated hook `%v`", name)
+			}
+			names[name] = true
+		}
+
+		hv.optional_positive_int(h_opt, "timeout")
+
+		if typ := hv.require_string(h_opt, "type"); typ != "" {
+			vld, ok := record_processor_validators[typ]
+			if !ok {
+				hv.invalid("type", "unknown record processor `%v`", typ)
+				continue
+			}
+			vld(hv, h_opt)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package digit_video_recorder_driver
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// test_record_processor calls fn, records ids of processed records.
+type test_record_processor struct {
+	fn  func(ctx context.Context) error
+	ids []string
+}
+
+func (p *test_record_processor) Process(ctx context.Context, r *Record) error {
+	p.ids = append(p.ids, r.Id)
+	if p.fn == nil {
+		return nil
+	}
+	return p.fn(ctx)
+}
+
+func new_test_hook(name string, fn func(ctx context.Context) error, profiles ...string) (*ffmpeg_hook, *test_record_processor) {
+	proc := &test_record_processor{fn: fn}
+	h := &ffmpeg_hook{name: name, timeout: 50 * time.Millisecond, profiles: map[string]bool{}, proc: proc}
+	for _, p := range profiles {
+		h.profiles[p] = true
+	}
+	return h, proc
+}
+
+func TestRunHook(t *testing.T) {
+	r := new_test_record("a", 0, 10, "")
+
+	for _, tc := range []struct {
+		name   string
+		fn     func(ctx context.Context) error
+		status string
+		error  string
+	}{
+		{"ok", nil, HOOK_STATUS_OK, ""},
+		{"failed", func(context.Context) error { return errors.New("upload refused") }, HOOK_STATUS_FAILED, "upload refused"},
+		{"timeout", func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }, HOOK_STATUS_TIMEOUT, "timeout after"},
+		{"panic", func(context.Context) error { panic("boom") }, HOOK_STATUS_FAILED, "panic: boom"},
+	} {
+		h, _ := new_test_hook(tc.name, tc.fn)
+		res := run_hook(h, r)
+
+		if res == nil {
+			t.Errorf("%v: expect result, got nil", tc.name)
+			continue
+		}
+		if res.Status != tc.status {
+			t.Errorf("%v: expect status %v, got %v", tc.name, tc.status, res.Status)
+		}
+		if (tc.error == "") != (res.Error == "") || !strings.Contains(res.Error, tc.error) {
+			t.Errorf("%v: expect error %q, got %q", tc.name, tc.error, res.Error)
+		}
+		if res.FinishedAt.IsZero() {
+			t.Errorf("%v: expect finished_at set", tc.name)
+		}
+	}
+}
+
+func TestRunHookNotWaitingProcessor(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	// processor ignoring context, like a copy hung on NAS.
+	h, _ := new_test_hook("hung", func(context.Context) error {
+		<-release
+		return errors.New("late")
+	})
+
+	start := time.Now()
+	res := run_hook(h, new_test_record("a", 0, 10, ""))
+	elapsed := time.Since(start)
+
+	if res.Status != HOOK_STATUS_TIMEOUT {
+		t.Errorf("expect status %v, got %v", HOOK_STATUS_TIMEOUT, res.Status)
+	}
+	if elapsed < h.timeout || elapsed > h.timeout+time.Second {
+		t.Errorf("expect run_hook returned in about %v, got %v", h.timeout, elapsed)
+	}
+}
+
+func TestRegisterRecordProcessor(t *testing.T) {
+	RegisterRecordProcessor("test_upload", func(opt *DigitVideoRecorderDriverOption) (RecordProcessor, error) {
+		return &test_record_processor{}, nil
+	}, func(opt *DigitVideoRecorderDriverOption) error {
+		if opt.GetString("bucket") == "" {
+			return ConfigErrors{{Key: "bucket", Reason: "required"}}
+		}
+		return nil
+	})
+
+	for _, tc := range []struct {
+		hook   map[string]interface{}
+		expect []string
+	}{
+		{map[string]interface{}{"name": "upload", "type": "test_upload", "bucket": "records"}, nil},
+		{map[string]interface{}{"name": "upload", "type": "test_upload"}, []string{"hooks.0.bucket"}},
+		{map[string]interface{}{"name": "upload", "type": "unknown"}, []string{"hooks.0.type"}},
+	} {
+		v := new_config_validator()
+		validate_hooks(v, new_test_ffmpeg_option(map[string]interface{}{"hooks": []interface{}{tc.hook}}))
+
+		var keys []string
+		for _, e := range *v.errs {
+			keys = append(keys, e.Key)
+		}
+		if !equal_strings(keys, tc.expect) {
+			t.Errorf("%v: expect errors of %v, got %v", tc.hook, tc.expect, keys)
+		}
+	}
+
+	proc, err := NewRecordProcessor("test_upload", new_test_ffmpeg_option(map[string]interface{}{"bucket": "records"}))
+	if err != nil || proc == nil {
+		t.Errorf("expect registered processor, got %v", err)
+	}
+}
+
+func TestCopyRecordProcessorCanceled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtdvr-hook-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := new_test_record("a", 0, 10, "")
+	r.Path = filepath.Join(dir, "a.mp4")
+	if err = ioutil.WriteFile(r.Path, []byte("mp4"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cfn := context.WithCancel(context.Background())
+	cfn()
+
+	nas := filepath.Join(dir, "nas")
+	proc := &copy_record_processor{dir: nas}
+	if err = proc.Process(ctx, r); err != context.Canceled {
+		t.Errorf("expect copy canceled, got %v", err)
+	}
+	if names, _ := filepath.Glob(filepath.Join(nas, "*")); len(names) != 0 {
+		t.Errorf("expect no files left by canceled copy, got %v", names)
+	}
+
+	if err = proc.Process(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if !is_file_exist(filepath.Join(nas, "a.mp4")) {
+		t.Errorf("expect record copied")
+	}
+}
+
+func TestHookPipelineRun(t *testing.T) {
+	drv := &FFmpegDigitVideoRecorderDriver{
+		logger: new_test_logger(),
+		storage: new_test_memory_record_storage(t,
+			new_test_record("a", 0, 10, ""),
+			new_test_record("b", 10, 20, "proxy"),
+		),
+	}
+	defer drv.storage.Close()
+
+	first, first_proc := new_test_hook("first", nil)
+	failing, _ := new_test_hook("failing", func(context.Context) error { return errors.New("failed") }, DEFAULT_OUTPUT_PROFILE)
+	proxy, proxy_proc := new_test_hook("proxy", nil, "proxy")
+	last, last_proc := new_test_hook("last", nil)
+
+	p := &ffmpeg_hook_pipeline{hooks: []*ffmpeg_hook{first, failing, proxy, last}}
+
+	for _, id := range []string{"a", "b"} {
+		r, err := drv.storage.GetRecord(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.run(drv, r)
+	}
+
+	if !equal_strings(first_proc.ids, []string{"a", "b"}) {
+		t.Errorf("expect first hook run for all records, got %v", first_proc.ids)
+	}
+	// hooks after failure are skipped, not run.
+	if !equal_strings(last_proc.ids, []string{"b"}) {
+		t.Errorf("expect last hook skipped after failure of a, got %v", last_proc.ids)
+	}
+	if !equal_strings(proxy_proc.ids, []string{"b"}) {
+		t.Errorf("expect proxy hook run for proxy records, got %v", proxy_proc.ids)
+	}
+
+	for _, tc := range []struct {
+		id     string
+		expect map[string]string
+	}{
+		{"a", map[string]string{"first": HOOK_STATUS_OK, "failing": HOOK_STATUS_FAILED, "last": HOOK_STATUS_SKIPPED}},
+		{"b", map[string]string{"first": HOOK_STATUS_OK, "proxy": HOOK_STATUS_OK, "last": HOOK_STATUS_OK}},
+	} {
+		r, err := drv.storage.GetRecord(tc.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := map[string]string{}
+		for name, res := range r.Hooks {
+			got[name] = res.Status
+		}
+
+		if len(got) != len(tc.expect) {
+			t.Errorf("%v: expect hooks %v, got %v", tc.id, tc.expect, got)
+			continue
+		}
+		for name, status := range tc.expect {
+			if got[name] != status {
+				t.Errorf("%v: expect hooks %v, got %v", tc.id, tc.expect, got)
+				break
+			}
+		}
+	}
+}
+
+func TestSetHookResult(t *testing.T) {
+	drv := &FFmpegDigitVideoRecorderDriver{
+		logger:  new_test_logger(),
+		storage: new_test_memory_record_storage(t, new_test_record("a", 0, 10, "")),
+	}
+	defer drv.storage.Close()
+
+	ok := &HookResult{Status: HOOK_STATUS_OK, FinishedAt: test_record_at(20)}
+	failed := &HookResult{Status: HOOK_STATUS_FAILED, Error: "failed", FinishedAt: test_record_at(30)}
+
+	for _, res := range []struct {
+		name string
+		res  *HookResult
+	}{{"upload", ok}, {"notify", ok}, {"upload", failed}} {
+		if err := drv.set_hook_result("a", res.name, res.res); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := drv.storage.GetRecord("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Hooks) != 2 || r.Hooks["notify"].Status != HOOK_STATUS_OK || r.Hooks["upload"].Status != HOOK_STATUS_FAILED {
+		t.Errorf("expect results by hook name, last result kept, got %+v", r.Hooks)
+	}
+	if r.Path != "/records/a.mp4" || !r.EndAt.Equal(test_record_at(10)) {
+		t.Errorf("expect record unchanged, got %+v", r)
+	}
+
+	// record removed while hooks running.
+	if err = drv.set_hook_result("removed", "upload", ok); err != nil {
+		t.Errorf("expect removed record ignored, got %v", err)
+	}
+	if _, err = drv.storage.GetRecord("removed"); err != ErrNotFound {
+		t.Errorf("expect removed record not recreated, got %v", err)
+	}
+}
@@ -0,0 +1,441 @@
+package digit_video_recorder_driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	id_helper "github.com/nayotta/metathings/pkg/common/id"
+)
+
+/*
+ * Import:
+ *   adopt existing media files into record storage,
+ *   start time is probed from container `creation_time`, file name or modification time,
+ *   duration is probed from container, or segment time of output profile,
+ *   record id is derived from source file, so imported files are skipped on next import.
+ *   only files under import roots with media extensions of output profile format
+ *   are imported, symlinks are resolved, other files in directories are ignored.
+ * Options:
+ *   driver:
+ *     [ ffprobe: <path> ]  // ffprobe binary, default `ffprobe`.
+ *     [ import_roots: [ <path>, ... ] ]  // absolute directories of importable files,
+ *                                        // import is disabled if not set.
+ */
+
+const (
+	FFPROBE_DEFAULT_BINARY = `ffprobe`
+
+	// register files in place.
+	IMPORT_MODE_KEEP = "keep"
+	// copy or move files into output file template layout.
+	IMPORT_MODE_COPY = "copy"
+	IMPORT_MODE_MOVE = "move"
+)
+
+// media extensions of output formats besides `.<format>`.
+var import_media_extensions = map[string][]string{
+	"mp4":  {".m4v"},
+	"mkv":  {".mka"},
+	"ts":   {".mts", ".m2ts"},
+	"mpeg": {".mpg"},
+}
+
+// like `20261018-140500`, `2026-10-18_14-05-00` or `20261018T140500`.
+var import_file_name_time_regexp = regexp.MustCompile(`(\d{4})-?(\d{2})-?(\d{2})[-_T ]?(\d{2})[-:]?(\d{2})[-:]?(\d{2})`)
+
+type ImportRecordsOption struct {
+	// files or directories, directories are walked recursively.
+	Paths []string
+	// keep, copy or move, default keep.
+	Mode string
+	// output profile of imported records, default the first profile.
+	Profile string
+	// time layout of file name, like `cam1-20060102-150405`,
+	// common date time in file name is detected if empty.
+	TimeLayout string
+}
+
+type ImportRecordsFailure struct {
+	Path   string
+	Reason string
+}
+
+type ImportRecordsResult struct {
+	Imported []*Record
+	// files imported before.
+	Skipped []string
+	Failed  []*ImportRecordsFailure
+}
+
+type media_file_info struct {
+	CreationTime time.Time
+	Duration     time.Duration
+}
+
+type ffprobe_output struct {
+	Format struct {
+		Duration string            `json:"duration"`
+		Tags     map[string]string `json:"tags"`
+	} `json:"format"`
+}
+
+// probe_media_file reads creation time and duration from container,
+// fields are zero if not available.
+func probe_media_file(ffprobe string, path string) (*media_file_info, error) {
+	buf, err := exec.Command(ffprobe,
+		"-v", "error",
+		"-print_format", "json",
+		"-show_entries", "format=duration:format_tags=creation_time",
+		path).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var out ffprobe_output
+	if err = json.Unmarshal(buf, &out); err != nil {
+		return nil, err
+	}
+
+	info := &media_file_info{}
+	if val, err := strconv.ParseFloat(out.Format.Duration, 64); err == nil {
+		info.Duration = time.Duration(val * float64(time.Second))
+	}
+
+	if val, ok := out.Format.Tags["creation_time"]; ok {
+		if t, err := time.Parse(time.RFC3339Nano, val); err == nil {
+			info.CreationTime = t
+		}
+	}
+
+	return info, nil
+}
+
+func get_ffprobe_binary(opt *DigitVideoRecorderDriverOption) string {
+	if val := opt.GetString("ffprobe"); val != "" {
+		return val
+	}
+	return FFPROBE_DEFAULT_BINARY
+}
+
+// probe_media_file_by_option probes file by ffprobe in driver options,
+// fields are zero if ffprobe not available.
+func probe_media_file_by_option(opt *DigitVideoRecorderDriverOption, path string) (*media_file_info, error) {
+	media, err := probe_media_file(get_ffprobe_binary(opt), path)
+	if err != nil {
+		if _, ok := err.(*exec.Error); !ok {
+			return nil, fmt.Errorf("failed to probe media file: %v", err)
+		}
+		return &media_file_info{}, nil
+	}
+
+	return media, nil
+}
+
+func parse_import_file_name_time(name string, layout string, loc *time.Location) (time.Time, bool) {
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+
+	if layout != "" {
+		t, err := time.ParseInLocation(layout, stem, loc)
+		return t, err == nil
+	}
+
+	m := import_file_name_time_regexp.FindStringSubmatch(stem)
+	if m == nil {
+		return time.Time{}, false
+	}
+
+	t, err := time.ParseInLocation("20060102150405", strings.Join(m[1:], ""), loc)
+	return t, err == nil
+}
+
+// get_import_roots returns import roots in driver options, symlinks resolved,
+// missing roots are ignored.
+func get_import_roots(opt *DigitVideoRecorderDriverOption) []string {
+	var roots []string
+
+	for _, root := range opt.GetStringSlice("import_roots") {
+		if !filepath.IsAbs(root) {
+			continue
+		}
+
+		if root, err := filepath.EvalSymlinks(root); err == nil {
+			roots = append(roots, root)
+		}
+	}
+
+	return roots
+}
+
+// resolve_import_path returns absolute path with symlinks resolved,
+// path should be one of roots or under them.
+func resolve_import_path(roots []string, path string) (string, error) {
+	if len(roots) == 0 {
+		return "", &InvalidImportPathError{Path: path, Reason: "import_roots not set"}
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", &InvalidImportPathError{Path: path, Reason: err.Error()}
+	}
+
+	if abs, err = filepath.EvalSymlinks(abs); err != nil {
+		return "", &InvalidImportPathError{Path: path, Reason: err.Error()}
+	}
+
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, abs)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return abs, nil
+		}
+	}
+
+	return "", &InvalidImportPathError{Path: path, Reason: "not in import_roots"}
+}
+
+// is_import_media_file reports whether file extension matches output profile format.
+func is_import_media_file(profile *ffmpeg_output_profile, path string) bool {
+	format := strings.ToLower(profile.opt.GetString("format"))
+	ext := strings.ToLower(filepath.Ext(path))
+
+	if ext == "."+format {
+		return true
+	}
+
+	for _, val := range import_media_extensions[format] {
+		if ext == val {
+			return true
+		}
+	}
+
+	return false
+}
+
+// walk_import_paths returns regular files in paths, files in directories
+// are ignored if not accepted, all files are accepted if accept is nil.
+func walk_import_paths(paths []string, accept func(path string) bool) ([]string, error) {
+	var files []string
+
+	for _, p := range paths {
+		err := filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			// skip hidden files and directories, like commit temp files.
+			if path != p && strings.HasPrefix(info.Name(), ".") {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if info.Mode().IsRegular() && (path == p || accept == nil || accept(path)) {
+				files = append(files, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// get_record_paths returns paths of all records, for skipping
+// files already in record storage.
+func (drv *FFmpegDigitVideoRecorderDriver) get_record_paths() (map[string]bool, error) {
+	rs, err := drv.storage.ListRecords(ListRecordsFitler{})
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]bool, len(rs))
+	for _, r := range rs {
+		paths[r.Path] = true
+	}
+
+	return paths, nil
+}
+
+// import_file imports a file, returns nil record if imported before.
+func (drv *FFmpegDigitVideoRecorderDriver) import_file(opt *DigitVideoRecorderDriverOption, profile *ffmpeg_output_profile, iopt *ImportRecordsOption, paths map[string]bool, path string) (*Record, error) {
+	path, err := resolve_import_path(get_import_roots(opt), path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !is_import_media_file(profile, path) {
+		return nil, fmt.Errorf("unexpected file extension, expect media file of format %v", profile.opt.GetString("format"))
+	}
+
+	if paths[path] {
+		return nil, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	id := id_helper.NewNamedId(fmt.Sprintf("import:%v:%d:%d", path, info.Size(), info.ModTime().UnixNano()))
+	if _, err = drv.storage.GetRecord(id); err == nil {
+		return nil, nil
+	} else if err != ErrNotFound {
+		return nil, err
+	}
+
+	media, err := probe_media_file_by_option(opt, path)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := media.Duration
+	if duration <= 0 {
+		duration = time.Duration(profile.segment_time()) * time.Second
+	}
+
+	start_at := media.CreationTime
+	if start_at.IsZero() {
+		var ok bool
+		if start_at, ok = parse_import_file_name_time(info.Name(), iopt.TimeLayout, profile.loc); !ok {
+			start_at = info.ModTime().Add(-duration)
+		}
+	}
+
+	r := &Record{
+		Id:        id,
+		StartAt:   start_at,
+		EndAt:     start_at.Add(duration),
+		Profile:   profile.name,
+		AudioOnly: profile.audio_only,
+	}
+
+	switch iopt.Mode {
+	case "", IMPORT_MODE_KEEP:
+		r.Path = path
+		if err = drv.storage.SetRecord(r); err != nil {
+			return nil, err
+		}
+	case IMPORT_MODE_COPY, IMPORT_MODE_MOVE:
+		dst, err := render_output_file(profile.tmpl, r, profile.opt, profile.loc)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return nil, err
+		}
+
+		if r.Path, err = resolve_output_file_collision(dst); err != nil {
+			return nil, err
+		}
+
+		if err = drv.commit_record(r, path, iopt.Mode == IMPORT_MODE_COPY); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown import mode %v", iopt.Mode)
+	}
+	paths[r.Path] = true
+
+	return r, nil
+}
+
+func (drv *FFmpegDigitVideoRecorderDriver) ImportRecords(iopt *ImportRecordsOption) (*ImportRecordsResult, error) {
+	drv.op_mtx.Lock()
+	opt := drv.opt
+	drv.op_mtx.Unlock()
+
+	switch iopt.Mode {
+	case "", IMPORT_MODE_KEEP, IMPORT_MODE_COPY, IMPORT_MODE_MOVE:
+	default:
+		return nil, fmt.Errorf("unknown import mode %v", iopt.Mode)
+	}
+
+	profiles, err := new_output_profiles(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := profiles[0]
+	if iopt.Profile != "" {
+		profile = nil
+		for _, p := range profiles {
+			if p.name == iopt.Profile {
+				profile = p
+				break
+			}
+		}
+		if profile == nil {
+			return nil, fmt.Errorf("unknown output profile %v", iopt.Profile)
+		}
+	}
+
+	// paths out of import roots are refused before walking.
+	roots := get_import_roots(opt)
+	for _, path := range iopt.Paths {
+		if _, err = resolve_import_path(roots, path); err != nil {
+			return nil, err
+		}
+	}
+
+	files, err := walk_import_paths(iopt.Paths, func(path string) bool {
+		return is_import_media_file(profile, path)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := drv.get_record_paths()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ImportRecordsResult{}
+	for _, file := range files {
+		r, err := drv.import_file(opt, profile, iopt, paths, file)
+		switch {
+		case err != nil:
+			drv.get_logger().WithError(err).WithField("file", file).Debugf("failed to import file")
+			res.Failed = append(res.Failed, &ImportRecordsFailure{Path: file, Reason: err.Error()})
+		case r == nil:
+			res.Skipped = append(res.Skipped, file)
+		default:
+			res.Imported = append(res.Imported, r)
+		}
+	}
+
+	drv.get_logger().WithFields(map[string]interface{}{
+		"imported": len(res.Imported),
+		"skipped":  len(res.Skipped),
+		"failed":   len(res.Failed),
+	}).Infof("import records")
+
+	return res, nil
+}
+
+func validate_import_roots(v *config_validator, opt *DigitVideoRecorderDriverOption) {
+	for i, root := range opt.GetStringSlice("import_roots") {
+		key := fmt.Sprintf("import_roots.%d", i)
+
+		if !filepath.IsAbs(root) {
+			v.invalid(key, "expect absolute path, got `%v`", root)
+			continue
+		}
+
+		if info, err := os.Stat(root); err != nil {
+			v.invalid(key, "directory %v not accessible: %v", root, err)
+		} else if !info.IsDir() {
+			v.invalid(key, "%v is not a directory", root)
+		}
+	}
+}
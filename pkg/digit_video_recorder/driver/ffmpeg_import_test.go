@@ -0,0 +1,130 @@
+package digit_video_recorder_driver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestResolveImportPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtdvr-import-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// symlinks of temp dir are resolved, like /tmp on macos.
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	root := filepath.Join(dir, "import")
+	for _, name := range []string{"import/cam1", "import-other", "outside"} {
+		if err = os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"import/cam1/a.mp4", "import-other/b.mp4", "outside/c.mp4"} {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = os.Symlink(filepath.Join(dir, "outside"), filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	roots := get_import_roots(new_test_ffmpeg_option(map[string]interface{}{
+		"import_roots": []string{root, "relative", filepath.Join(dir, "missing")},
+	}))
+	if len(roots) != 1 || roots[0] != root {
+		t.Fatalf("expect import roots [%v], got %v", root, roots)
+	}
+
+	for _, tc := range []struct {
+		path string
+		ok   bool
+	}{
+		{root, true},
+		{filepath.Join(root, "cam1"), true},
+		{filepath.Join(root, "cam1", "a.mp4"), true},
+		{filepath.Join(root, "cam1", "..", "cam1", "a.mp4"), true},
+		{filepath.Join(root, "..", "outside", "c.mp4"), false},
+		{filepath.Join(dir, "import-other", "b.mp4"), false},
+		{filepath.Join(root, "link", "c.mp4"), false},
+		{filepath.Join(root, "missing.mp4"), false},
+		{"/dev/video0", false},
+	} {
+		path, err := resolve_import_path(roots, tc.path)
+		if tc.ok && err != nil {
+			t.Errorf("%v: expect accepted, got %v", tc.path, err)
+		}
+		if !tc.ok {
+			if _, ok := err.(*InvalidImportPathError); !ok {
+				t.Errorf("%v: expect InvalidImportPathError, got %v, %v", tc.path, path, err)
+			}
+		}
+	}
+
+	if _, err = resolve_import_path(nil, filepath.Join(root, "cam1", "a.mp4")); err == nil {
+		t.Errorf("expect import refused without import roots")
+	}
+}
+
+func TestImportMediaFile(t *testing.T) {
+	profile := &ffmpeg_output_profile{opt: new_test_ffmpeg_option(map[string]interface{}{"format": "mp4"})}
+
+	for _, tc := range []struct {
+		path string
+		ok   bool
+	}{
+		{"/import/a.mp4", true},
+		{"/import/a.MP4", true},
+		{"/import/a.m4v", true},
+		{"/import/a.mkv", false},
+		{"/import/a.mp4.txt", false},
+		{"/import/mp4", false},
+	} {
+		if ok := is_import_media_file(profile, tc.path); ok != tc.ok {
+			t.Errorf("%v: expect %v, got %v", tc.path, tc.ok, ok)
+		}
+	}
+}
+
+func TestWalkImportPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtdvr-import-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err = os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.mp4", "notes.txt", "sub/b.mp4", ".tmp.mp4"} {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	profile := &ffmpeg_output_profile{opt: new_test_ffmpeg_option(map[string]interface{}{"format": "mp4"})}
+	accept := func(path string) bool { return is_import_media_file(profile, path) }
+
+	// explicit files are kept, rejected by import_file.
+	files, err := walk_import_paths([]string{dir, filepath.Join(dir, "notes.txt")}, accept)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, file := range files {
+		rel, _ := filepath.Rel(dir, file)
+		names = append(names, filepath.ToSlash(rel))
+	}
+	sort.Strings(names)
+
+	if expect := []string{"a.mp4", "notes.txt", "sub/b.mp4"}; !equal_strings(names, expect) {
+		t.Errorf("expect files %v, got %v", expect, names)
+	}
+}
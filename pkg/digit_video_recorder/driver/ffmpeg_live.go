@@ -0,0 +1,115 @@
+package digit_video_recorder_driver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+ * Live:
+ *   rolling hls playlist for live view, written by the same ffmpeg as a second output,
+ *   in tee muxer with `onfail=ignore`, failure of live output does not stop archive
+ *   segments, served by module http server.
+ * Options:
+ *   driver:
+ *     live:
+ *       enable: <bool>
+ *       listen: <host>:<port>  // live http server listen address.
+ *       token: <token>  // access token, by `Authorization: Bearer <token>` header or `token` query.
+ *       [ dir: <path> ]  // scratch directory for playlist and segments, default `<tmp>/mtdvr-live`, without `:` and `]`.
+ *       [ hls_time: <sec> ]  // hls segment time, default 2.
+ *       [ list_size: <n> ]  // segments in playlist, default 6.
+ *       [ codec: ... ]  // same as `video.codec`, default `video.codec`, audio only in audio mode.
+ *   playlist url: http://<listen>/live/index.m3u8
+ */
+
+const (
+	LIVE_PLAYLIST          = "index.m3u8"
+	LIVE_DEFAULT_HLS_TIME  = 2
+	LIVE_DEFAULT_LIST_SIZE = 6
+)
+
+func is_live_enabled(live *DigitVideoRecorderDriverOption) bool {
+	return live != nil && live.GetBool("enable")
+}
+
+func get_live_dir(live *DigitVideoRecorderDriverOption) string {
+	if val := live.GetString("dir"); val != "" {
+		return val
+	}
+	return filepath.Join(os.TempDir(), "mtdvr-live")
+}
+
+func get_live_hls_time(live *DigitVideoRecorderDriverOption) int {
+	if val := live.GetInt("hls_time"); val > 0 {
+		return val
+	}
+	return LIVE_DEFAULT_HLS_TIME
+}
+
+func get_live_list_size(live *DigitVideoRecorderDriverOption) int {
+	if val := live.GetInt("list_size"); val > 0 {
+		return val
+	}
+	return LIVE_DEFAULT_LIST_SIZE
+}
+
+// IsLiveEnabled reports whether live output enabled in driver options.
+func IsLiveEnabled(opt *DigitVideoRecorderDriverOption) bool {
+	return is_live_enabled(opt.Sub("live"))
+}
+
+// GetLiveDir returns live playlist directory in driver options.
+func GetLiveDir(opt *DigitVideoRecorderDriverOption) string {
+	return get_live_dir(opt.Sub("live"))
+}
+
+// prepare_live_dir creates live directory and removes playlist and
+// segments left by last ffmpeg.
+func prepare_live_dir(live *DigitVideoRecorderDriverOption) error {
+	dir := get_live_dir(live)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	names, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		base := filepath.Base(name)
+		if base == LIVE_PLAYLIST || (strings.HasPrefix(base, "live-") && strings.HasSuffix(base, ".ts")) {
+			os.Remove(name)
+		}
+	}
+
+	return nil
+}
+
+func validate_live_option(v *config_validator, opt *DigitVideoRecorderDriverOption) {
+	live := opt.Sub("live")
+	if !is_live_enabled(live) {
+		return
+	}
+
+	v.require_string(opt, "live.listen")
+	v.require_string(opt, "live.token")
+	v.optional_positive_int(opt, "live.hls_time")
+	v.optional_positive_int(opt, "live.list_size")
+
+	if opt.IsSet("live.codec") {
+		v.require_string(opt, "live.codec.name")
+		v.match_string(opt, "live.codec.bit_rate", config_bit_rate_regexp, "expect bitrate, like `2000k`")
+	}
+
+	// live dir is created when recording started.
+	dir := get_live_dir(live)
+	if strings.ContainsAny(dir, FFMPEG_TEE_OPTION_SPECIAL_CHARS) {
+		v.invalid("live.dir", "chars `%v` are not available", FFMPEG_TEE_OPTION_SPECIAL_CHARS)
+		return
+	}
+	v.writable_dir("live.dir", existing_parent_dir(dir+string(os.PathSeparator)))
+}
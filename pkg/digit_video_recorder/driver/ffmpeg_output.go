@@ -0,0 +1,221 @@
+package digit_video_recorder_driver
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+/*
+ * Outputs:
+ *   multiple output profiles from one input, like full resolution archive
+ *   and low bitrate proxy, records are linked by profile name.
+ * Options:
+ *   driver:
+ *     outputs:  // replaces `output`, single `default` profile made by `output`, `video` and `audio` if not set.
+ *       - name: <profile>  // profile name, letters, digits and underscore, unique.
+ *         format: <format>  // same as `output.format`.
+ *         segment_time: <sec>  // same as `output.segment_time`.
+ *         file: <path>  // same as `output.file`.
+ *         [ scale: <width>x<height> ]  // output frame size, default `input.frame_size`.
+ *         [ video: ... ]  // same as `video`, default `video`, ignored in audio mode.
+ *         [ audio: ... ]  // same as `audio`, default `audio`.
+ */
+
+var ffmpeg_output_profile_name_regexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+type ffmpeg_output_profile struct {
+	name       string
+	opt        *DigitVideoRecorderDriverOption
+	tmpl       *template.Template
+	loc        *time.Location
+	audio_only bool
+}
+
+func (p *ffmpeg_output_profile) segment_time() int {
+	return p.opt.GetInt("segment_time")
+}
+
+// get_output_profile_options returns options of output profiles, with keys
+// `name`, `format`, `segment_time`, `file`, `scale`, `video`, `audio`
+// and driver `mode`, `channel`, `timezone`, `privacy_masks`.
+func get_output_profile_options(opt *DigitVideoRecorderDriverOption) ([]*DigitVideoRecorderDriverOption, error) {
+	if !opt.IsSet("outputs") {
+		m := cast.ToStringMap(opt.Get("output"))
+		m["name"] = DEFAULT_OUTPUT_PROFILE
+		set_output_profile_driver_options(m, opt)
+		m["video"] = opt.Get("video")
+		if opt.IsSet("audio") {
+			m["audio"] = opt.Get("audio")
+		}
+
+		v := viper.New()
+		if err := v.MergeConfigMap(m); err != nil {
+			return nil, err
+		}
+
+		return []*DigitVideoRecorderDriverOption{{v}}, nil
+	}
+
+	items, err := cast.ToSliceE(opt.Get("outputs"))
+	if err != nil || len(items) == 0 {
+		return nil, new_invalid_config_error("outputs")
+	}
+
+	var opts []*DigitVideoRecorderDriverOption
+	for i, item := range items {
+		m, err := cast.ToStringMapE(item)
+		if err != nil {
+			return nil, new_invalid_config_error(fmt.Sprintf("outputs.%d", i))
+		}
+
+		set_output_profile_driver_options(m, opt)
+		if _, ok := m["video"]; !ok {
+			m["video"] = opt.Get("video")
+		}
+		if _, ok := m["audio"]; !ok && opt.IsSet("audio") {
+			m["audio"] = opt.Get("audio")
+		}
+
+		v := viper.New()
+		if err = v.MergeConfigMap(m); err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, &DigitVideoRecorderDriverOption{v})
+	}
+
+	return opts, nil
+}
+
+func set_output_profile_driver_options(m map[string]interface{}, opt *DigitVideoRecorderDriverOption) {
+	for _, key := range []string{"mode", "channel", "timezone"} {
+		m[key] = opt.GetString(key)
+	}
+	if opt.IsSet("privacy_masks") {
+		m["privacy_masks"] = opt.Get("privacy_masks")
+	}
+}
+
+func new_output_profiles(opt *DigitVideoRecorderDriverOption) ([]*ffmpeg_output_profile, error) {
+	opts, err := get_output_profile_options(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []*ffmpeg_output_profile
+	for _, p_opt := range opts {
+		tmpl, err := new_output_file_template(p_opt.GetString("file"))
+		if err != nil {
+			return nil, err
+		}
+
+		loc, err := get_output_timezone(p_opt)
+		if err != nil {
+			return nil, err
+		}
+
+		profiles = append(profiles, &ffmpeg_output_profile{
+			name:       p_opt.GetString("name"),
+			opt:        p_opt,
+			tmpl:       tmpl,
+			loc:        loc,
+			audio_only: is_audio_mode(p_opt),
+		})
+	}
+
+	return profiles, nil
+}
+
+// OutputFiles returns output file templates of all output profiles.
+func OutputFiles(opt *DigitVideoRecorderDriverOption) []string {
+	opts, err := get_output_profile_options(opt)
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, p_opt := range opts {
+		files = append(files, p_opt.GetString("file"))
+	}
+
+	return files
+}
+
+func ffmpeg_segment_file_pattern(tmp_dir string, ts int64, profile string, format string) string {
+	return filepath.Join(tmp_dir, fmt.Sprintf("mtdvr-%d-%v-%%08d.%v", ts, profile, format))
+}
+
+// parse_ffmpeg_segment_file parses segment file name
+// `mtdvr-<ts>-<profile>-<index>.<format>`.
+func parse_ffmpeg_segment_file(path string) (ts int64, profile string, idx int, err error) {
+	base := filepath.Base(path)
+	parts := strings.Split(strings.TrimSuffix(base, filepath.Ext(base)), "-")
+	if len(parts) != 4 || parts[0] != "mtdvr" {
+		err = fmt.Errorf("unexpected segment file %v", base)
+		return
+	}
+
+	if ts, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+		return
+	}
+
+	if idx, err = strconv.Atoi(parts[3]); err != nil {
+		return
+	}
+
+	profile = parts[2]
+
+	return
+}
+
+func validate_output_profiles(v *config_validator, opt *DigitVideoRecorderDriverOption) {
+	opts, err := get_output_profile_options(opt)
+	if err != nil {
+		if e, ok := err.(*InvalidConfigError); ok {
+			v.invalid(e.Key, "expect list of output profiles")
+		} else {
+			v.invalid("outputs", "%v", err)
+		}
+		return
+	}
+
+	names := map[string]bool{}
+	for i, p_opt := range opts {
+		pv := v.sub(fmt.Sprintf("outputs.%d", i))
+
+		if name := pv.require_string(p_opt, "name"); name != "" {
+			if !ffmpeg_output_profile_name_regexp.MatchString(name) {
+				pv.invalid("name", "expect letters, digits and underscore, got `%v`", name)
+			} else if names[name] {
+				pv.invalid("name", "duplicated profile `%v`", name)
+			}
+			names[name] = true
+		}
+
+		pv.require_string(p_opt, "format")
+		pv.require_positive_int(p_opt, "segment_time")
+		if text := pv.require_string(p_opt, "file"); text != "" {
+			pv.validate_output_file_template("file", p_opt)
+		}
+
+		if !is_audio_mode(p_opt) {
+			pv.match_string(p_opt, "scale", config_frame_size_regexp, "expect <width>x<height>, like `640x360`")
+			pv.require_string(p_opt, "video.codec.name")
+			pv.match_string(p_opt, "video.codec.bit_rate", config_bit_rate_regexp, "expect bitrate, like `2000k`")
+			validate_video_overlay(pv, p_opt)
+		}
+
+		if p_opt.IsSet("audio") || is_audio_mode(p_opt) {
+			pv.require_string(p_opt, "audio.codec.name")
+			pv.match_string(p_opt, "audio.codec.bit_rate", config_bit_rate_regexp, "expect bitrate, like `64k`")
+		}
+	}
+}
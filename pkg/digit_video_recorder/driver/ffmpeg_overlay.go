@@ -0,0 +1,240 @@
+package digit_video_recorder_driver
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+/*
+ * Overlay:
+ *   static text and wall-clock time burned into video frames by ffmpeg
+ *   drawtext filter, time is local time of ffmpeg, in driver `timezone` if set.
+ *   overlay needs re-encoding, `codec` is used if video codec is `copy`.
+ * Options:
+ *   driver:
+ *     video:
+ *       overlay:  // overlay disabled if not set, `true` for defaults.
+ *         [ text: <text> ]  // static text before time, like site and camera name.
+ *         [ timestamp: <format> ]  // strftime format, default `%Y-%m-%d %H:%M:%S`, `none` to hide time.
+ *         [ position: <position> ]  // `top_left`, `top_right`, `bottom_left` or `bottom_right`, default `top_left`.
+ *         [ font_size: <n> ]  // default 24.
+ *         [ font_color: <color> ]  // ffmpeg color, like `white` or `yellow@0.8`, default `white`.
+ *         [ font_file: <path> ]  // font file, default font of ffmpeg fontconfig.
+ *         [ box: <bool> ]  // draw background box, default true.
+ *         [ codec: ... ]  // same as `video.codec`, used if video codec is `copy`, default `libx264`.
+ */
+
+const (
+	FFMPEG_OVERLAY_DEFAULT_TIMESTAMP  = "%Y-%m-%d %H:%M:%S"
+	FFMPEG_OVERLAY_NO_TIMESTAMP       = "none"
+	FFMPEG_OVERLAY_DEFAULT_POSITION   = "top_left"
+	FFMPEG_OVERLAY_DEFAULT_FONT_SIZE  = 24
+	FFMPEG_OVERLAY_DEFAULT_FONT_COLOR = "white"
+	FFMPEG_OVERLAY_DEFAULT_CODEC      = "libx264"
+	// distance between text and frame edges in pixels.
+	FFMPEG_OVERLAY_MARGIN = 16
+)
+
+var ffmpeg_overlay_positions = map[string]string{
+	"top_left":     fmt.Sprintf("x=%d:y=%d", FFMPEG_OVERLAY_MARGIN, FFMPEG_OVERLAY_MARGIN),
+	"top_right":    fmt.Sprintf("x=w-tw-%d:y=%d", FFMPEG_OVERLAY_MARGIN, FFMPEG_OVERLAY_MARGIN),
+	"bottom_left":  fmt.Sprintf("x=%d:y=h-th-%d", FFMPEG_OVERLAY_MARGIN, FFMPEG_OVERLAY_MARGIN),
+	"bottom_right": fmt.Sprintf("x=w-tw-%d:y=h-th-%d", FFMPEG_OVERLAY_MARGIN, FFMPEG_OVERLAY_MARGIN),
+}
+
+// get_overlay_option returns overlay options of output profile,
+// nil if overlay disabled, `overlay: true` enables overlay with defaults.
+func get_overlay_option(profile *DigitVideoRecorderDriverOption) *DigitVideoRecorderDriverOption {
+	if !profile.IsSet("video.overlay") {
+		return nil
+	}
+
+	if overlay := profile.Sub("video.overlay"); overlay != nil {
+		return overlay
+	}
+
+	if profile.GetBool("video.overlay") {
+		return &DigitVideoRecorderDriverOption{viper.New()}
+	}
+
+	return nil
+}
+
+// escape_ffmpeg_chars escapes chars by backslash.
+func escape_ffmpeg_chars(s string, chars string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(chars, c) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// escape_ffmpeg_filter_option escapes value of filter option
+// in filtergraph description, option level then filtergraph level.
+func escape_ffmpeg_filter_option(s string) string {
+	return escape_ffmpeg_chars(escape_ffmpeg_chars(s, `\':`), `\'[],;`)
+}
+
+// ffmpeg_overlay_text returns drawtext text with expansion,
+// static text is escaped against expansion.
+func ffmpeg_overlay_text(overlay *DigitVideoRecorderDriverOption) string {
+	var parts []string
+
+	if val := overlay.GetString("text"); val != "" {
+		parts = append(parts, escape_ffmpeg_chars(val, `\%`))
+	}
+
+	format := FFMPEG_OVERLAY_DEFAULT_TIMESTAMP
+	if overlay.IsSet("timestamp") {
+		format = overlay.GetString("timestamp")
+	}
+	if format != "" && format != FFMPEG_OVERLAY_NO_TIMESTAMP {
+		parts = append(parts, "%{localtime:"+escape_ffmpeg_chars(format, `\:}`)+"}")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// ffmpeg_overlay_filter returns drawtext filter of overlay options.
+func ffmpeg_overlay_filter(overlay *DigitVideoRecorderDriverOption) (string, error) {
+	text := ffmpeg_overlay_text(overlay)
+	if text == "" {
+		return "", new_invalid_config_error("video.overlay.text")
+	}
+
+	args := []string{"text=" + escape_ffmpeg_filter_option(text)}
+
+	position := FFMPEG_OVERLAY_DEFAULT_POSITION
+	if val := overlay.GetString("position"); val != "" {
+		position = val
+	}
+	xy, ok := ffmpeg_overlay_positions[position]
+	if !ok {
+		return "", new_invalid_config_error("video.overlay.position")
+	}
+	args = append(args, xy)
+
+	font_size := FFMPEG_OVERLAY_DEFAULT_FONT_SIZE
+	if val := overlay.GetInt("font_size"); val > 0 {
+		font_size = val
+	}
+	args = append(args, fmt.Sprintf("fontsize=%d", font_size))
+
+	font_color := FFMPEG_OVERLAY_DEFAULT_FONT_COLOR
+	if val := overlay.GetString("font_color"); val != "" {
+		font_color = val
+	}
+	args = append(args, "fontcolor="+escape_ffmpeg_filter_option(font_color))
+
+	if val := overlay.GetString("font_file"); val != "" {
+		args = append(args, "fontfile="+escape_ffmpeg_filter_option(val))
+	}
+
+	if !overlay.IsSet("box") || overlay.GetBool("box") {
+		args = append(args, "box=1", "boxcolor=black@0.5", "boxborderw=4")
+	}
+
+	return "drawtext=" + strings.Join(args, ":"), nil
+}
+
+// get_ffmpeg_video_filters returns video filters of output profile,
+// applied in order, privacy masks before overlay.
+func get_ffmpeg_video_filters(profile *DigitVideoRecorderDriverOption, input *DigitVideoRecorderDriverOption, tmp_dir string) ([]string, error) {
+	var filters []string
+
+	masks, err := ffmpeg_privacy_mask_filter(profile, input, tmp_dir)
+	if err != nil {
+		return nil, err
+	}
+	if masks != "" {
+		filters = append(filters, masks)
+	}
+
+	if overlay := get_overlay_option(profile); overlay != nil {
+		filter, err := ffmpeg_overlay_filter(overlay)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+
+	return filters, nil
+}
+
+// get_ffmpeg_video_codec returns video codec of output profile,
+// codec `copy` is replaced by overlay codec if overlay enabled.
+func get_ffmpeg_video_codec(profile *DigitVideoRecorderDriverOption, codec *DigitVideoRecorderDriverOption, key string) (*DigitVideoRecorderDriverOption, string) {
+	overlay := get_overlay_option(profile)
+	if codec == nil || codec.GetString("name") != "copy" || overlay == nil {
+		return codec, key
+	}
+
+	if c := overlay.Sub("codec"); c != nil {
+		return c, "video.overlay.codec"
+	}
+
+	v := viper.New()
+	v.Set("name", FFMPEG_OVERLAY_DEFAULT_CODEC)
+	return &DigitVideoRecorderDriverOption{v}, "video.overlay.codec"
+}
+
+// append_ffmpeg_video_args appends video filters and codec of output profile,
+// privacy masks with `copy` codec are refused.
+func append_ffmpeg_video_args(argv []string, profile *DigitVideoRecorderDriverOption, input *DigitVideoRecorderDriverOption, tmp_dir string, codec *DigitVideoRecorderDriverOption, key string) ([]string, error) {
+	filters, err := get_ffmpeg_video_filters(profile, input, tmp_dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(filters) > 0 {
+		argv = append(argv, "-vf", strings.Join(filters, ","))
+	}
+
+	codec, key = get_ffmpeg_video_codec(profile, codec, key)
+
+	if len(filters) > 0 && codec != nil && codec.GetString("name") == "copy" {
+		return nil, &InvalidConfigError{Key: key + ".name", Reason: "privacy masks need re-encoding, `copy` is not available"}
+	}
+
+	return append_ffmpeg_video_codec_args(argv, codec, key)
+}
+
+func validate_video_overlay(v *config_validator, profile *DigitVideoRecorderDriverOption) {
+	overlay := get_overlay_option(profile)
+	if overlay == nil {
+		return
+	}
+
+	ov := v.sub("video.overlay")
+
+	if ffmpeg_overlay_text(overlay) == "" {
+		ov.invalid("text", "expect text or timestamp")
+	}
+
+	if val := overlay.GetString("position"); val != "" {
+		if _, ok := ffmpeg_overlay_positions[val]; !ok {
+			ov.invalid("position", "expect `top_left`, `top_right`, `bottom_left` or `bottom_right`, got `%v`", val)
+		}
+	}
+
+	ov.optional_positive_int(overlay, "font_size")
+
+	if val := overlay.GetString("font_file"); val != "" {
+		if _, err := os.Stat(val); err != nil {
+			ov.invalid("font_file", "%v", err)
+		}
+	}
+
+	if overlay.IsSet("codec") {
+		if name := ov.require_string(overlay, "codec.name"); name == "copy" {
+			ov.invalid("codec.name", "overlay needs re-encoding, `copy` is not available")
+		}
+		ov.match_string(overlay, "codec.bit_rate", config_bit_rate_regexp, "expect bitrate, like `2000k`")
+	}
+}
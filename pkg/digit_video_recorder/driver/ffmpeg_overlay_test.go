@@ -0,0 +1,107 @@
+package digit_video_recorder_driver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEscapeFFmpegFilterOption(t *testing.T) {
+	for _, tc := range []struct {
+		s      string
+		expect string
+	}{
+		{"plain text", "plain text"},
+		{"a:b", `a\\:b`},
+		{"it's", `it\\\'s`},
+		{`a\b`, `a\\\\b`},
+		// expansion is escaped in drawtext text, not in filter option.
+		{"50%", "50%"},
+		{"[a],b;c", `\[a\]\,b\;c`},
+	} {
+		if val := escape_ffmpeg_filter_option(tc.s); val != tc.expect {
+			t.Errorf("%q: expect %q, got %q", tc.s, tc.expect, val)
+		}
+	}
+}
+
+func TestFFmpegOverlayText(t *testing.T) {
+	for _, tc := range []struct {
+		overlay map[string]interface{}
+		expect  string
+	}{
+		{map[string]interface{}{"text": "100%", "timestamp": "none"}, `100\%`},
+		{map[string]interface{}{"text": `a\b`, "timestamp": "none"}, `a\\b`},
+		{map[string]interface{}{"text": "it's: x", "timestamp": "none"}, "it's: x"},
+		{map[string]interface{}{"timestamp": "%H:%M"}, `%{localtime:%H\:%M}`},
+		{map[string]interface{}{"timestamp": `%H}\`}, `%{localtime:%H\}\\}`},
+		{map[string]interface{}{"text": "cam"}, `cam %{localtime:%Y-%m-%d %H\:%M\:%S}`},
+		{map[string]interface{}{"timestamp": ""}, ""},
+	} {
+		if val := ffmpeg_overlay_text(new_test_ffmpeg_option(tc.overlay)); val != tc.expect {
+			t.Errorf("%v: expect %q, got %q", tc.overlay, tc.expect, val)
+		}
+	}
+}
+
+func TestFFmpegOverlayFilter(t *testing.T) {
+	filter, err := ffmpeg_overlay_filter(new_test_ffmpeg_option(map[string]interface{}{
+		"text":       "it's 50%: cam",
+		"timestamp":  "none",
+		"position":   "bottom_right",
+		"font_color": "yellow@0.8",
+		"box":        false,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expect := `drawtext=text=it\\\'s 50\\\\%\\: cam:x=w-tw-16:y=h-th-16:fontsize=24:fontcolor=yellow@0.8`; filter != expect {
+		t.Errorf("expect filter %q, got %q", expect, filter)
+	}
+
+	for _, overlay := range []map[string]interface{}{
+		{"timestamp": "none"},
+		{"text": "cam", "position": "center"},
+	} {
+		if _, err = ffmpeg_overlay_filter(new_test_ffmpeg_option(overlay)); err == nil {
+			t.Errorf("%v: expect error", overlay)
+		}
+	}
+}
+
+func TestBuildFFmpegCommandOverlayCodec(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		codec   string
+		overlay interface{}
+		expect  string
+		filter  bool
+	}{
+		{"copy without overlay", "copy", nil, "copy", false},
+		{"copy with overlay", "copy", true, FFMPEG_OVERLAY_DEFAULT_CODEC, true},
+		{"copy with overlay codec", "copy", map[string]interface{}{"codec": map[string]interface{}{"name": "libx265"}}, "libx265", true},
+		{"encoding with overlay", "h264_v4l2m2m", true, "h264_v4l2m2m", true},
+	} {
+		m := new_test_ffmpeg_option_map()
+		video := m["video"].(map[string]interface{})
+		video["codec"] = map[string]interface{}{"name": tc.codec}
+		if tc.overlay != nil {
+			video["overlay"] = tc.overlay
+		}
+
+		argv, err := build_ffmpeg_command(new_test_ffmpeg_option(m), "/tmp/mtdvr", 1)
+		if err != nil {
+			t.Errorf("%v: %v", tc.name, err)
+			continue
+		}
+
+		if val := argv_value(argv, "-c:v"); val != tc.expect {
+			t.Errorf("%v: expect video codec %v, got %v", tc.name, tc.expect, val)
+		}
+
+		vf := argv_value(argv, "-vf")
+		if tc.filter != strings.HasPrefix(vf, "drawtext=") {
+			t.Errorf("%v: expect drawtext filter %v, got %q", tc.name, tc.filter, vf)
+		}
+	}
+}
@@ -0,0 +1,540 @@
+package digit_video_recorder_driver
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+/*
+ * Privacy masks:
+ *   regions of input frame blanked by solid fill or pixelated in every video
+ *   output and live, before scaling and overlay, coordinates are pixels of
+ *   `input.frame_size`, frames are scaled to `input.frame_size` first.
+ *   mask images are rendered into segment directory when recording started,
+ *   not when ffmpeg command is built.
+ *   masks need re-encoding, recording is refused with `copy` video codec.
+ * Options:
+ *   driver:
+ *     privacy_masks:  // changeable at runtime by `SetPrivacyMasks`, ignored in audio mode.
+ *       - [ name: <name> ]  // mask name, for reference only.
+ *         rect: [ <x>, <y>, <width>, <height> ]  // rectangle, or
+ *         polygon: [ [ <x>, <y> ], ... ]  // polygon of at least 3 points.
+ *         [ mode: <mode> ]  // `fill` or `pixelate`, default `fill`.
+ *         [ color: <#rrggbb> ]  // fill color, default `#000000`.
+ *         [ pixel_size: <n> ]  // pixel block size of pixelate, default 16.
+ */
+
+const (
+	PRIVACY_MASK_MODE_FILL     = "fill"
+	PRIVACY_MASK_MODE_PIXELATE = "pixelate"
+
+	PRIVACY_MASK_DEFAULT_COLOR      = "#000000"
+	PRIVACY_MASK_DEFAULT_PIXEL_SIZE = 16
+
+	PRIVACY_MASK_FILE_PREFIX = "privacy-mask-"
+)
+
+var privacy_mask_color_regexp = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+type PrivacyMaskPoint struct {
+	X int
+	Y int
+}
+
+type PrivacyMaskRect struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// PrivacyMask is a rectangle or polygon region of input frame.
+type PrivacyMask struct {
+	Name      string
+	Mode      string
+	Rect      *PrivacyMaskRect
+	Polygon   []PrivacyMaskPoint
+	Color     string
+	PixelSize int
+}
+
+func (m *PrivacyMask) get_mode() string {
+	if m.Mode == "" {
+		return PRIVACY_MASK_MODE_FILL
+	}
+	return m.Mode
+}
+
+func (m *PrivacyMask) get_pixel_size() int {
+	if m.PixelSize > 0 {
+		return m.PixelSize
+	}
+	return PRIVACY_MASK_DEFAULT_PIXEL_SIZE
+}
+
+func (m *PrivacyMask) get_color() color.NRGBA {
+	text := m.Color
+	if !privacy_mask_color_regexp.MatchString(text) {
+		text = PRIVACY_MASK_DEFAULT_COLOR
+	}
+
+	n, _ := strconv.ParseUint(text[1:], 16, 32)
+	return color.NRGBA{R: uint8(n >> 16), G: uint8(n >> 8), B: uint8(n), A: 0xff}
+}
+
+// equal reports whether masks are same with defaults applied, so mask
+// of options without mode equals to mask of `fill` mode.
+func (m *PrivacyMask) equal(o *PrivacyMask) bool {
+	return m.Name == o.Name &&
+		m.get_mode() == o.get_mode() &&
+		m.get_color() == o.get_color() &&
+		m.get_pixel_size() == o.get_pixel_size() &&
+		reflect.DeepEqual(m.Rect, o.Rect) &&
+		reflect.DeepEqual(m.Polygon, o.Polygon)
+}
+
+// bounds returns bounding box of mask.
+func (m *PrivacyMask) bounds() image.Rectangle {
+	if m.Rect != nil {
+		return image.Rect(m.Rect.X, m.Rect.Y, m.Rect.X+m.Rect.Width, m.Rect.Y+m.Rect.Height)
+	}
+
+	var r image.Rectangle
+	for i, p := range m.Polygon {
+		if i == 0 || p.X < r.Min.X {
+			r.Min.X = p.X
+		}
+		if i == 0 || p.Y < r.Min.Y {
+			r.Min.Y = p.Y
+		}
+		if i == 0 || p.X > r.Max.X {
+			r.Max.X = p.X
+		}
+		if i == 0 || p.Y > r.Max.Y {
+			r.Max.Y = p.Y
+		}
+	}
+
+	return r
+}
+
+// contains reports whether center of pixel (x, y) in mask, by even-odd rule.
+func (m *PrivacyMask) contains(x, y int) bool {
+	if m.Rect != nil {
+		return image.Pt(x, y).In(m.bounds())
+	}
+
+	px, py := float64(x)+0.5, float64(y)+0.5
+	in := false
+	for i, j := 0, len(m.Polygon)-1; i < len(m.Polygon); j, i = i, i+1 {
+		a, b := m.Polygon[i], m.Polygon[j]
+		ax, ay, bx, by := float64(a.X), float64(a.Y), float64(b.X), float64(b.Y)
+		if (ay > py) != (by > py) && px < (bx-ax)*(py-ay)/(by-ay)+ax {
+			in = !in
+		}
+	}
+
+	return in
+}
+
+// draw sets pixels in mask and frame to c.
+func (m *PrivacyMask) draw(img interface{ Set(x, y int, c color.Color) }, frame image.Rectangle, c color.Color) {
+	r := m.bounds().Intersect(frame)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if m.contains(x, y) {
+				img.Set(x, y, c)
+			}
+		}
+	}
+}
+
+func (m *PrivacyMask) option() map[string]interface{} {
+	o := map[string]interface{}{}
+
+	if m.Name != "" {
+		o["name"] = m.Name
+	}
+	if m.Mode != "" {
+		o["mode"] = m.Mode
+	}
+	if m.Rect != nil {
+		o["rect"] = []interface{}{m.Rect.X, m.Rect.Y, m.Rect.Width, m.Rect.Height}
+	}
+	if len(m.Polygon) > 0 {
+		var pts []interface{}
+		for _, p := range m.Polygon {
+			pts = append(pts, []interface{}{p.X, p.Y})
+		}
+		o["polygon"] = pts
+	}
+	if m.Color != "" {
+		o["color"] = m.Color
+	}
+	if m.PixelSize != 0 {
+		o["pixel_size"] = m.PixelSize
+	}
+
+	return o
+}
+
+func parse_privacy_mask(item interface{}, key string) (*PrivacyMask, error) {
+	o, err := cast.ToStringMapE(item)
+	if err != nil {
+		return nil, new_invalid_config_error(key)
+	}
+
+	m := &PrivacyMask{
+		Name:      cast.ToString(o["name"]),
+		Mode:      cast.ToString(o["mode"]),
+		Color:     cast.ToString(o["color"]),
+		PixelSize: cast.ToInt(o["pixel_size"]),
+	}
+
+	if val, ok := o["rect"]; ok {
+		xs, err := cast.ToIntSliceE(val)
+		if err != nil || len(xs) != 4 {
+			return nil, &InvalidConfigError{Key: key + ".rect", Reason: "expect [ <x>, <y>, <width>, <height> ]"}
+		}
+		m.Rect = &PrivacyMaskRect{X: xs[0], Y: xs[1], Width: xs[2], Height: xs[3]}
+	}
+
+	if val, ok := o["polygon"]; ok {
+		pts, err := cast.ToSliceE(val)
+		if err != nil {
+			return nil, &InvalidConfigError{Key: key + ".polygon", Reason: "expect list of [ <x>, <y> ]"}
+		}
+
+		for i, pt := range pts {
+			xy, err := cast.ToIntSliceE(pt)
+			if err != nil || len(xy) != 2 {
+				return nil, &InvalidConfigError{Key: fmt.Sprintf("%v.polygon.%d", key, i), Reason: "expect [ <x>, <y> ]"}
+			}
+			m.Polygon = append(m.Polygon, PrivacyMaskPoint{X: xy[0], Y: xy[1]})
+		}
+	}
+
+	return m, nil
+}
+
+// get_privacy_masks returns privacy masks in driver options.
+func get_privacy_masks(opt *DigitVideoRecorderDriverOption) ([]*PrivacyMask, error) {
+	if !opt.IsSet("privacy_masks") {
+		return nil, nil
+	}
+
+	items, err := cast.ToSliceE(opt.Get("privacy_masks"))
+	if err != nil {
+		return nil, &InvalidConfigError{Key: "privacy_masks", Reason: "expect list of privacy masks"}
+	}
+
+	var masks []*PrivacyMask
+	for i, item := range items {
+		m, err := parse_privacy_mask(item, fmt.Sprintf("privacy_masks.%d", i))
+		if err != nil {
+			return nil, err
+		}
+		masks = append(masks, m)
+	}
+
+	return masks, nil
+}
+
+// WithPrivacyMasks returns a copy of driver options with privacy masks
+// replaced by masks, no masks if masks is empty.
+func WithPrivacyMasks(opt *DigitVideoRecorderDriverOption, masks []*PrivacyMask) *DigitVideoRecorderDriverOption {
+	v := viper.New()
+	for key, val := range opt.AllSettings() {
+		v.Set(key, val)
+	}
+
+	items := []interface{}{}
+	for _, m := range masks {
+		items = append(items, m.option())
+	}
+	v.Set("privacy_masks", items)
+
+	return &DigitVideoRecorderDriverOption{v}
+}
+
+// EqualPrivacyMasks reports whether privacy masks of driver options are same
+// with defaults applied, invalid masks are never same.
+func EqualPrivacyMasks(a, b *DigitVideoRecorderDriverOption) bool {
+	ma, err := get_privacy_masks(a)
+	if err != nil {
+		return false
+	}
+
+	mb, err := get_privacy_masks(b)
+	if err != nil {
+		return false
+	}
+
+	if len(ma) != len(mb) {
+		return false
+	}
+
+	for i := range ma {
+		if !ma[i].equal(mb[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// get_privacy_mask_frame returns input frame of privacy masks.
+func get_privacy_mask_frame(input *DigitVideoRecorderDriverOption) (image.Rectangle, error) {
+	text := input.GetString("frame_size")
+	if !config_frame_size_regexp.MatchString(text) {
+		return image.Rectangle{}, &InvalidConfigError{Key: "input.frame_size", Reason: "required by privacy masks"}
+	}
+
+	wh := strings.Split(text, "x")
+	w, _ := strconv.Atoi(wh[0])
+	h, _ := strconv.Atoi(wh[1])
+
+	return image.Rect(0, 0, w, h), nil
+}
+
+func privacy_mask_fill_file(tmp_dir string) string {
+	return filepath.Join(tmp_dir, PRIVACY_MASK_FILE_PREFIX+"fill.png")
+}
+
+func privacy_mask_pixelate_file(tmp_dir string, pixel_size int) string {
+	return filepath.Join(tmp_dir, fmt.Sprintf("%vpixelate-%d.png", PRIVACY_MASK_FILE_PREFIX, pixel_size))
+}
+
+// group_privacy_masks returns fill masks and pixelate masks by pixel size.
+func group_privacy_masks(masks []*PrivacyMask) ([]*PrivacyMask, map[int][]*PrivacyMask) {
+	var fills []*PrivacyMask
+	pixelates := map[int][]*PrivacyMask{}
+	for _, m := range masks {
+		if m.get_mode() == PRIVACY_MASK_MODE_PIXELATE {
+			pixelates[m.get_pixel_size()] = append(pixelates[m.get_pixel_size()], m)
+		} else {
+			fills = append(fills, m)
+		}
+	}
+	return fills, pixelates
+}
+
+func get_pixel_sizes(pixelates map[int][]*PrivacyMask) []int {
+	var sizes []int
+	for size := range pixelates {
+		sizes = append(sizes, size)
+	}
+	sort.Ints(sizes)
+	return sizes
+}
+
+func write_png_file(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = png.Encode(f, img)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+
+	return err
+}
+
+// write_privacy_mask_files renders mask images into tmp_dir, fill masks
+// into one image with transparent background, pixelate masks into
+// a grayscale image for each pixel size.
+func write_privacy_mask_files(opt *DigitVideoRecorderDriverOption, tmp_dir string) error {
+	if is_audio_mode(opt) {
+		return nil
+	}
+
+	masks, err := get_privacy_masks(opt)
+	if err != nil || len(masks) == 0 {
+		return err
+	}
+
+	frame, err := get_privacy_mask_frame(opt.Sub("input"))
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(tmp_dir, 0755); err != nil {
+		return err
+	}
+
+	fills, pixelates := group_privacy_masks(masks)
+
+	if len(fills) > 0 {
+		img := image.NewNRGBA(frame)
+		for _, m := range fills {
+			m.draw(img, frame, m.get_color())
+		}
+		if err = write_png_file(privacy_mask_fill_file(tmp_dir), img); err != nil {
+			return err
+		}
+	}
+
+	for size, ms := range pixelates {
+		img := image.NewGray(frame)
+		for _, m := range ms {
+			m.draw(img, frame, color.White)
+		}
+		if err = write_png_file(privacy_mask_pixelate_file(tmp_dir, size), img); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func remove_privacy_mask_files(tmp_dir string) {
+	names, _ := filepath.Glob(filepath.Join(tmp_dir, PRIVACY_MASK_FILE_PREFIX+"*.png"))
+	for _, name := range names {
+		os.Remove(name)
+	}
+}
+
+// ffmpeg_privacy_mask_filter returns filtergraph of privacy masks with
+// single input and output, empty if no masks. pixelated frame is merged
+// by mask image in planar rgb, fill image is overlaid.
+func ffmpeg_privacy_mask_filter(profile *DigitVideoRecorderDriverOption, input *DigitVideoRecorderDriverOption, tmp_dir string) (string, error) {
+	masks, err := get_privacy_masks(profile)
+	if err != nil || len(masks) == 0 {
+		return "", err
+	}
+
+	frame, err := get_privacy_mask_frame(input)
+	if err != nil {
+		return "", err
+	}
+	w, h := frame.Dx(), frame.Dy()
+
+	fills, pixelates := group_privacy_masks(masks)
+
+	graph := fmt.Sprintf("scale=%d:%d", w, h)
+	for i, size := range get_pixel_sizes(pixelates) {
+		l := fmt.Sprintf("pm%d", i)
+		graph += fmt.Sprintf(",split[%[1]va][%[1]vb];"+
+			"[%[1]vb]scale=%[2]d:%[3]d,scale=%[4]d:%[5]d:flags=neighbor,format=gbrp[%[1]vp];"+
+			"movie=%[6]v,format=gbrp[%[1]vm];"+
+			"[%[1]va]format=gbrp[%[1]vc];"+
+			"[%[1]vc][%[1]vp][%[1]vm]maskedmerge,format=yuv420p",
+			l, max_int(1, w/size), max_int(1, h/size), w, h,
+			escape_ffmpeg_filter_option(privacy_mask_pixelate_file(tmp_dir, size)))
+	}
+
+	if len(fills) > 0 {
+		graph += fmt.Sprintf("[pmf];movie=%v[pmfm];[pmf][pmfm]overlay=0:0",
+			escape_ffmpeg_filter_option(privacy_mask_fill_file(tmp_dir)))
+	}
+
+	return graph, nil
+}
+
+func max_int(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func validate_privacy_mask(v *config_validator, m *PrivacyMask, frame image.Rectangle) {
+	switch {
+	case m.Rect != nil && len(m.Polygon) > 0:
+		v.invalid("rect", "expect one of `rect` and `polygon`")
+	case m.Rect != nil:
+		if m.Rect.Width <= 0 || m.Rect.Height <= 0 {
+			v.invalid("rect", "expect positive width and height")
+		} else if !m.bounds().In(frame) {
+			v.invalid("rect", "out of input frame %vx%v", frame.Dx(), frame.Dy())
+		}
+	case len(m.Polygon) > 0:
+		if len(m.Polygon) < 3 {
+			v.invalid("polygon", "expect at least 3 points")
+		}
+		for i, p := range m.Polygon {
+			if p.X < 0 || p.Y < 0 || p.X > frame.Dx() || p.Y > frame.Dy() {
+				v.invalid(fmt.Sprintf("polygon.%d", i), "out of input frame %vx%v", frame.Dx(), frame.Dy())
+			}
+		}
+	default:
+		v.invalid("rect", "expect `rect` or `polygon`")
+	}
+
+	if m.Mode != "" && m.Mode != PRIVACY_MASK_MODE_FILL && m.Mode != PRIVACY_MASK_MODE_PIXELATE {
+		v.invalid("mode", "expect `fill` or `pixelate`, got `%v`", m.Mode)
+	}
+
+	if m.Color != "" && !privacy_mask_color_regexp.MatchString(m.Color) {
+		v.invalid("color", "expect `#rrggbb`, got `%v`", m.Color)
+	}
+
+	if m.PixelSize < 0 {
+		v.invalid("pixel_size", "expect positive integer, got `%v`", m.PixelSize)
+	}
+}
+
+// validate_privacy_masks validates masks against input frame size,
+// and video codecs of outputs and live, which should not be `copy`.
+func validate_privacy_masks(v *config_validator, opt *DigitVideoRecorderDriverOption) {
+	masks, err := get_privacy_masks(opt)
+	if err != nil {
+		if e, ok := err.(*InvalidConfigError); ok {
+			v.invalid(e.Key, "%v", e.Reason)
+		} else {
+			v.invalid("privacy_masks", "%v", err)
+		}
+		return
+	}
+
+	if len(masks) == 0 || is_audio_mode(opt) {
+		return
+	}
+
+	frame, err := get_privacy_mask_frame(opt.Sub("input"))
+	if err != nil {
+		v.invalid("input.frame_size", "required by privacy masks")
+		return
+	}
+
+	for i, m := range masks {
+		validate_privacy_mask(v.sub(fmt.Sprintf("privacy_masks.%d", i)), m, frame)
+	}
+
+	opts, err := get_output_profile_options(opt)
+	if err != nil {
+		return
+	}
+
+	for i, p_opt := range opts {
+		key := "video.codec.name"
+		if opt.IsSet("outputs") {
+			key = fmt.Sprintf("outputs.%d.video.codec.name", i)
+		}
+
+		if codec, _ := get_ffmpeg_video_codec(p_opt, p_opt.Sub("video.codec"), ""); codec != nil && codec.GetString("name") == "copy" {
+			v.invalid(key, "privacy masks need re-encoding, `copy` is not available")
+		}
+	}
+
+	// live is encoded with overlay of the first output profile.
+	if live := opt.Sub("live"); len(opts) > 0 && is_live_enabled(live) {
+		if codec, _ := get_ffmpeg_video_codec(opts[0], live.Sub("codec"), ""); codec != nil && codec.GetString("name") == "copy" {
+			v.invalid("live.codec.name", "privacy masks need re-encoding, `copy` is not available")
+		}
+	}
+}
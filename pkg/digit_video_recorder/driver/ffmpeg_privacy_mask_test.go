@@ -0,0 +1,310 @@
+package digit_video_recorder_driver
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// new_test_privacy_mask_option_map returns driver options of 640x480 input with masks.
+func new_test_privacy_mask_option_map(masks ...map[string]interface{}) map[string]interface{} {
+	m := new_test_ffmpeg_option_map()
+	m["input"].(map[string]interface{})["frame_size"] = "640x480"
+
+	items := []interface{}{}
+	for _, mask := range masks {
+		items = append(items, mask)
+	}
+	m["privacy_masks"] = items
+
+	return m
+}
+
+// test_polygon returns polygon option of points as decoded from yaml.
+func test_polygon(xys ...int) []interface{} {
+	var pts []interface{}
+	for i := 0; i+1 < len(xys); i += 2 {
+		pts = append(pts, []interface{}{xys[i], xys[i+1]})
+	}
+	return pts
+}
+
+// validate_test_privacy_masks returns keys of invalid config errors.
+func validate_test_privacy_masks(m map[string]interface{}) []string {
+	v := new_config_validator()
+	validate_privacy_masks(v, new_test_ffmpeg_option(m))
+
+	var keys []string
+	for _, e := range *v.errs {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+func TestValidatePrivacyMasks(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		mask   map[string]interface{}
+		expect []string
+	}{
+		{"rect", map[string]interface{}{"rect": []int{0, 0, 640, 480}}, nil},
+		{"rect out of frame", map[string]interface{}{"rect": []int{600, 0, 41, 10}}, []string{"privacy_masks.0.rect"}},
+		{"rect negative", map[string]interface{}{"rect": []int{-1, 0, 10, 10}}, []string{"privacy_masks.0.rect"}},
+		{"rect empty", map[string]interface{}{"rect": []int{0, 0, 0, 10}}, []string{"privacy_masks.0.rect"}},
+		{"rect malformed", map[string]interface{}{"rect": []int{0, 0, 10}}, []string{"privacy_masks.0.rect"}},
+		{"polygon", map[string]interface{}{"polygon": test_polygon(0, 0, 640, 0, 640, 480)}, nil},
+		{"polygon out of frame", map[string]interface{}{"polygon": test_polygon(0, 0, 641, 0, 0, 480)}, []string{"privacy_masks.0.polygon.1"}},
+		{"polygon of 2 points", map[string]interface{}{"polygon": test_polygon(0, 0, 10, 10)}, []string{"privacy_masks.0.polygon"}},
+		{"rect and polygon", map[string]interface{}{"rect": []int{0, 0, 10, 10}, "polygon": test_polygon(0, 0, 10, 0, 0, 10)}, []string{"privacy_masks.0.rect"}},
+		{"no region", map[string]interface{}{"mode": "fill"}, []string{"privacy_masks.0.rect"}},
+		{"mode", map[string]interface{}{"rect": []int{0, 0, 10, 10}, "mode": "blur"}, []string{"privacy_masks.0.mode"}},
+		{"color", map[string]interface{}{"rect": []int{0, 0, 10, 10}, "color": "black"}, []string{"privacy_masks.0.color"}},
+		{"pixel size", map[string]interface{}{"rect": []int{0, 0, 10, 10}, "mode": "pixelate", "pixel_size": -1}, []string{"privacy_masks.0.pixel_size"}},
+	} {
+		keys := validate_test_privacy_masks(new_test_privacy_mask_option_map(tc.mask))
+		if !equal_strings(keys, tc.expect) {
+			t.Errorf("%v: expect errors of %v, got %v", tc.name, tc.expect, keys)
+		}
+	}
+
+	m := new_test_privacy_mask_option_map(map[string]interface{}{"rect": []int{0, 0, 10, 10}})
+	delete(m["input"].(map[string]interface{}), "frame_size")
+	if keys := validate_test_privacy_masks(m); !equal_strings(keys, []string{"input.frame_size"}) {
+		t.Errorf("no frame size: expect errors of [input.frame_size], got %v", keys)
+	}
+}
+
+func TestValidatePrivacyMasksCodec(t *testing.T) {
+	mask := map[string]interface{}{"rect": []int{0, 0, 10, 10}}
+	copy_codec := map[string]interface{}{"name": "copy"}
+
+	for _, tc := range []struct {
+		name   string
+		update func(m map[string]interface{})
+		expect []string
+	}{
+		{"encoding", func(m map[string]interface{}) {}, nil},
+		{"copy", func(m map[string]interface{}) {
+			m["video"].(map[string]interface{})["codec"] = copy_codec
+		}, []string{"video.codec.name"}},
+		// overlay codec replaces copy.
+		{"copy with overlay", func(m map[string]interface{}) {
+			m["video"].(map[string]interface{})["codec"] = copy_codec
+			m["video"].(map[string]interface{})["overlay"] = true
+		}, nil},
+		{"outputs", func(m map[string]interface{}) {
+			m["outputs"] = []interface{}{
+				map[string]interface{}{"name": "main", "format": "mp4", "segment_time": 60, "file": "/records/{{.id}}.mp4", "video": map[string]interface{}{"codec": map[string]interface{}{"name": "libx264"}}},
+				map[string]interface{}{"name": "proxy", "format": "mp4", "segment_time": 60, "file": "/proxy/{{.id}}.mp4", "video": map[string]interface{}{"codec": copy_codec}},
+			}
+		}, []string{"outputs.1.video.codec.name"}},
+		{"live copy", func(m map[string]interface{}) {
+			m["live"] = map[string]interface{}{"enable": true, "codec": copy_codec}
+		}, []string{"live.codec.name"}},
+		// live uses overlay of the first profile.
+		{"live copy with overlay", func(m map[string]interface{}) {
+			m["video"].(map[string]interface{})["overlay"] = true
+			m["live"] = map[string]interface{}{"enable": true, "codec": copy_codec}
+		}, nil},
+		{"live disabled", func(m map[string]interface{}) {
+			m["live"] = map[string]interface{}{"enable": false, "codec": copy_codec}
+		}, nil},
+		// invalid outputs are reported by output validation, live is not checked.
+		{"live without outputs", func(m map[string]interface{}) {
+			m["outputs"] = []interface{}{}
+			m["live"] = map[string]interface{}{"enable": true, "codec": copy_codec}
+		}, nil},
+		{"audio mode", func(m map[string]interface{}) {
+			m["mode"] = "audio"
+			m["video"].(map[string]interface{})["codec"] = copy_codec
+		}, nil},
+	} {
+		m := new_test_privacy_mask_option_map(mask)
+		tc.update(m)
+
+		if keys := validate_test_privacy_masks(m); !equal_strings(keys, tc.expect) {
+			t.Errorf("%v: expect errors of %v, got %v", tc.name, tc.expect, keys)
+		}
+	}
+}
+
+func TestFFmpegPrivacyMaskFilter(t *testing.T) {
+	fill := map[string]interface{}{"rect": []int{0, 0, 10, 10}}
+	pixelate := map[string]interface{}{"rect": []int{10, 10, 10, 10}, "mode": "pixelate"}
+	pixelate_8 := map[string]interface{}{"rect": []int{20, 20, 10, 10}, "mode": "pixelate", "pixel_size": 8}
+
+	merge := func(l string, w, h int, size int) string {
+		return fmt.Sprintf(",split[%[1]va][%[1]vb];"+
+			"[%[1]vb]scale=%[2]d:%[3]d,scale=640:480:flags=neighbor,format=gbrp[%[1]vp];"+
+			"movie=/tmp/mtdvr/privacy-mask-pixelate-%[4]d.png,format=gbrp[%[1]vm];"+
+			"[%[1]va]format=gbrp[%[1]vc];"+
+			"[%[1]vc][%[1]vp][%[1]vm]maskedmerge,format=yuv420p", l, w, h, size)
+	}
+	overlay := "[pmf];movie=/tmp/mtdvr/privacy-mask-fill.png[pmfm];[pmf][pmfm]overlay=0:0"
+
+	for _, tc := range []struct {
+		name   string
+		masks  []map[string]interface{}
+		expect string
+	}{
+		{"no masks", nil, ""},
+		{"fill", []map[string]interface{}{fill}, "scale=640:480" + overlay},
+		{"pixelate", []map[string]interface{}{pixelate}, "scale=640:480" + merge("pm0", 40, 30, 16)},
+		// pixelate masks by pixel size in order, then fill overlaid.
+		{"mixed", []map[string]interface{}{fill, pixelate, pixelate_8}, "scale=640:480" + merge("pm0", 80, 60, 8) + merge("pm1", 40, 30, 16) + overlay},
+	} {
+		m := new_test_privacy_mask_option_map(tc.masks...)
+		opt := new_test_ffmpeg_option(m)
+
+		graph, err := ffmpeg_privacy_mask_filter(opt, opt.Sub("input"), "/tmp/mtdvr")
+		if err != nil {
+			t.Errorf("%v: %v", tc.name, err)
+			continue
+		}
+
+		if graph != tc.expect {
+			t.Errorf("%v: expect filter\n%v\ngot\n%v", tc.name, tc.expect, graph)
+		}
+	}
+
+	m := new_test_privacy_mask_option_map(fill)
+	delete(m["input"].(map[string]interface{}), "frame_size")
+	opt := new_test_ffmpeg_option(m)
+	if _, err := ffmpeg_privacy_mask_filter(opt, opt.Sub("input"), "/tmp/mtdvr"); err == nil {
+		t.Errorf("expect error without input frame size")
+	}
+}
+
+func read_test_png_file(t *testing.T, path string) image.Image {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func TestWritePrivacyMaskFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtdvr-privacy-mask-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := new_test_privacy_mask_option_map(
+		map[string]interface{}{"rect": []int{10, 10, 20, 20}, "color": "#ff0000"},
+		map[string]interface{}{"polygon": test_polygon(100, 100, 200, 100, 100, 200), "mode": "pixelate"},
+	)
+	if err = write_privacy_mask_files(new_test_ffmpeg_option(m), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	fill := read_test_png_file(t, privacy_mask_fill_file(dir))
+	if b := fill.Bounds(); b.Dx() != 640 || b.Dy() != 480 {
+		t.Errorf("expect fill image of input frame size, got %v", b)
+	}
+	for _, tc := range []struct {
+		x, y int
+		c    color.NRGBA
+	}{
+		{10, 10, color.NRGBA{R: 0xff, A: 0xff}},
+		{29, 29, color.NRGBA{R: 0xff, A: 0xff}},
+		{30, 30, color.NRGBA{}},
+		{9, 10, color.NRGBA{}},
+	} {
+		if c := color.NRGBAModel.Convert(fill.At(tc.x, tc.y)); c != tc.c {
+			t.Errorf("fill (%v, %v): expect %v, got %v", tc.x, tc.y, tc.c, c)
+		}
+	}
+
+	pixelate := read_test_png_file(t, privacy_mask_pixelate_file(dir, PRIVACY_MASK_DEFAULT_PIXEL_SIZE))
+	for _, tc := range []struct {
+		x, y int
+		c    color.Gray
+	}{
+		{101, 101, color.Gray{Y: 0xff}},
+		{148, 148, color.Gray{Y: 0xff}},
+		// outside of hypotenuse.
+		{160, 160, color.Gray{}},
+		{99, 101, color.Gray{}},
+	} {
+		if c := color.GrayModel.Convert(pixelate.At(tc.x, tc.y)); c != tc.c {
+			t.Errorf("pixelate (%v, %v): expect %v, got %v", tc.x, tc.y, tc.c, c)
+		}
+	}
+
+	remove_privacy_mask_files(dir)
+	if names, _ := filepath.Glob(filepath.Join(dir, "*")); len(names) != 0 {
+		t.Errorf("expect mask files removed, got %v", names)
+	}
+}
+
+func TestBuildFFmpegCommandPrivacyMaskFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtdvr-privacy-mask-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// segment directory not created yet, like render-command.
+	tmp_dir := filepath.Join(dir, "mt_mdl_dvr")
+	m := new_test_privacy_mask_option_map(map[string]interface{}{"rect": []int{0, 0, 10, 10}})
+	argv, err := build_ffmpeg_command(new_test_ffmpeg_option(m), tmp_dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if vf := argv_value(argv, "-vf"); !strings.Contains(vf, privacy_mask_fill_file(tmp_dir)) {
+		t.Errorf("expect mask file in filter, got %q", vf)
+	}
+	if _, err = os.Stat(tmp_dir); !os.IsNotExist(err) {
+		t.Errorf("expect no files written by building command, got %v", err)
+	}
+}
+
+func TestEqualPrivacyMasks(t *testing.T) {
+	rect := []int{0, 0, 10, 10}
+
+	for _, tc := range []struct {
+		name   string
+		a, b   map[string]interface{}
+		expect bool
+	}{
+		{"same", map[string]interface{}{"rect": rect}, map[string]interface{}{"rect": rect}, true},
+		{"default mode", map[string]interface{}{"rect": rect}, map[string]interface{}{"rect": rect, "mode": "fill"}, true},
+		{"default color", map[string]interface{}{"rect": rect}, map[string]interface{}{"rect": rect, "color": "#000000"}, true},
+		{"color case", map[string]interface{}{"rect": rect, "color": "#ffffff"}, map[string]interface{}{"rect": rect, "color": "#FFFFFF"}, true},
+		{"default pixel size", map[string]interface{}{"rect": rect, "mode": "pixelate"}, map[string]interface{}{"rect": rect, "mode": "pixelate", "pixel_size": 16}, true},
+		{"mode", map[string]interface{}{"rect": rect}, map[string]interface{}{"rect": rect, "mode": "pixelate"}, false},
+		{"color", map[string]interface{}{"rect": rect}, map[string]interface{}{"rect": rect, "color": "#ff0000"}, false},
+		{"rect", map[string]interface{}{"rect": rect}, map[string]interface{}{"rect": []int{0, 0, 20, 10}}, false},
+	} {
+		a := new_test_ffmpeg_option(new_test_privacy_mask_option_map(tc.a))
+		b := new_test_ffmpeg_option(new_test_privacy_mask_option_map(tc.b))
+		if val := EqualPrivacyMasks(a, b); val != tc.expect {
+			t.Errorf("%v: expect equal %v, got %v", tc.name, tc.expect, val)
+		}
+	}
+
+	// masks parsed from options equal to masks set by WithPrivacyMasks.
+	opt := new_test_ffmpeg_option(new_test_privacy_mask_option_map(map[string]interface{}{"rect": rect}))
+	masks, err := get_privacy_masks(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	masks[0].Mode = PRIVACY_MASK_MODE_FILL
+	if !EqualPrivacyMasks(opt, WithPrivacyMasks(opt, masks)) {
+		t.Errorf("expect masks with explicit default mode equal")
+	}
+}
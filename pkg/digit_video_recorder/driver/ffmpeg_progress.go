@@ -0,0 +1,89 @@
+package digit_video_recorder_driver
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ffmpeg_line_writer splits ffmpeg output into lines,
+// used as exec.Cmd stdout and stderr, so no pipe reading goroutine needed.
+type ffmpeg_line_writer struct {
+	buf []byte
+	fn  func(line string)
+}
+
+func (w *ffmpeg_line_writer) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexAny(w.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+
+		if line := strings.TrimSpace(string(w.buf[:i])); line != "" {
+			w.fn(line)
+		}
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+func new_ffmpeg_line_writer(fn func(string)) *ffmpeg_line_writer {
+	return &ffmpeg_line_writer{fn: fn}
+}
+
+// ffmpeg_progress collects key-value pairs written by `-progress`,
+// a block ends with `progress=continue` or `progress=end`.
+type ffmpeg_progress struct {
+	kvs map[string]string
+}
+
+func new_ffmpeg_progress() *ffmpeg_progress {
+	return &ffmpeg_progress{kvs: make(map[string]string)}
+}
+
+// feed returns true when a progress block is completed.
+func (p *ffmpeg_progress) feed(line string) bool {
+	kv := strings.SplitN(line, "=", 2)
+	if len(kv) != 2 {
+		return false
+	}
+
+	key, val := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+	p.kvs[key] = val
+
+	return key == "progress"
+}
+
+func (p *ffmpeg_progress) get_int(key string) int64 {
+	n, _ := strconv.ParseInt(p.kvs[key], 10, 64)
+	return n
+}
+
+func (p *ffmpeg_progress) get_float(key string, suffix string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSuffix(p.kvs[key], suffix), 64)
+	return f
+}
+
+// apply updates stats by completed progress block,
+// unavailable values (`N/A`) are zero.
+func (p *ffmpeg_progress) apply(stats *RecordingStats) {
+	stats.Frames = p.get_int("frame")
+	stats.Fps = p.get_float("fps", "")
+	stats.Bitrate = p.get_float("bitrate", "kbits/s")
+	stats.TotalSize = p.get_int("total_size")
+	// out_time_ms is microseconds indeed.
+	stats.OutTime = time.Duration(p.get_int("out_time_us")) * time.Microsecond
+	if stats.OutTime == 0 {
+		stats.OutTime = time.Duration(p.get_int("out_time_ms")) * time.Microsecond
+	}
+	stats.DupFrames = p.get_int("dup_frames")
+	stats.DropFrames = p.get_int("drop_frames")
+	stats.Speed = p.get_float("speed", "x")
+	stats.UpdatedAt = time.Now()
+
+	p.kvs = make(map[string]string)
+}
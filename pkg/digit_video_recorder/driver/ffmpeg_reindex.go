@@ -0,0 +1,355 @@
+package digit_video_recorder_driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template/parse"
+	"time"
+
+	id_helper "github.com/nayotta/metathings/pkg/common/id"
+)
+
+/*
+ * Reindex:
+ *   rebuild record storage from output files, like after storage file lost,
+ *   base directories of output file templates are walked, paths are parsed
+ *   back by template, files not matching any template are reported as unmapped.
+ *   start time is parsed from `start_at`, `start` or date fields of path,
+ *   container `creation_time` is probed if path has no date,
+ *   duration is parsed from `end_at` or `duration` of path, probed from
+ *   container, or segment time of output profile.
+ *   only plain fields and `{{.start.Format "<layout>"}}` are supported in template.
+ */
+
+// reindex_template_field_patterns are patterns of template fields parsed back,
+// channel, profile and ext are matched literally.
+var reindex_template_field_patterns = map[string]string{
+	"id":       `[0-9a-f]{32}`,
+	"start_at": `\d+`,
+	"end_at":   `\d+`,
+	"duration": `\d+`,
+	"year":     `\d{4}`,
+	"month":    `\d{2}`,
+	"day":      `\d{2}`,
+	"hour":     `\d{2}`,
+	"minute":   `\d{2}`,
+	"second":   `\d{2}`,
+}
+
+// like `-1` appended by resolve_output_file_collision.
+var reindex_collision_suffix_regexp = regexp.MustCompile(`-\d+$`)
+
+type ReindexRecordsResult struct {
+	Recovered []*Record
+	// files already in record storage.
+	Indexed int
+	// files not mapped to records.
+	Unmapped []*ImportRecordsFailure
+}
+
+type reindex_template_matcher struct {
+	profile *ffmpeg_output_profile
+	re      *regexp.Regexp
+	// field names of capture groups, `start:<layout>` for start time.
+	fields []string
+}
+
+func new_reindex_template_matcher(profile *ffmpeg_output_profile) (*reindex_template_matcher, error) {
+	m := &reindex_template_matcher{profile: profile}
+	literals := map[string]string{
+		"channel": get_output_channel(profile.opt),
+		"profile": profile.name,
+		"ext":     profile.opt.GetString("format"),
+	}
+
+	var buf strings.Builder
+	buf.WriteString("^")
+	for _, node := range profile.tmpl.Tree.Root.Nodes {
+		switch n := node.(type) {
+		case *parse.TextNode:
+			buf.WriteString(regexp.QuoteMeta(string(n.Text)))
+		case *parse.ActionNode:
+			field, layout, err := parse_reindex_template_action(n)
+			if err != nil {
+				return nil, err
+			}
+
+			if val, ok := literals[field]; ok {
+				buf.WriteString(regexp.QuoteMeta(val))
+				continue
+			}
+
+			pattern, ok := reindex_template_field_patterns[field]
+			switch {
+			case field == "start" && layout != "":
+				pattern = `.+?`
+				field = "start:" + layout
+			case !ok:
+				return nil, fmt.Errorf("unsupported template field %v", field)
+			}
+
+			buf.WriteString("(" + pattern + ")")
+			m.fields = append(m.fields, field)
+		default:
+			return nil, fmt.Errorf("unsupported template action %v", n)
+		}
+	}
+	buf.WriteString("$")
+
+	re, err := regexp.Compile(buf.String())
+	if err != nil {
+		return nil, err
+	}
+	m.re = re
+
+	return m, nil
+}
+
+// parse_reindex_template_action returns field name of `{{.<field>}}`,
+// or `start` and layout of `{{.start.Format "<layout>"}}`.
+func parse_reindex_template_action(n *parse.ActionNode) (string, string, error) {
+	if len(n.Pipe.Decl) > 0 || len(n.Pipe.Cmds) != 1 {
+		return "", "", fmt.Errorf("unsupported template action %v", n)
+	}
+
+	args := n.Pipe.Cmds[0].Args
+	field, ok := args[0].(*parse.FieldNode)
+	if !ok {
+		return "", "", fmt.Errorf("unsupported template action %v", n)
+	}
+
+	switch {
+	case len(args) == 1 && len(field.Ident) == 1:
+		return field.Ident[0], "", nil
+	case len(args) == 2 && len(field.Ident) == 2 && field.Ident[0] == "start" && field.Ident[1] == "Format":
+		if layout, ok := args[1].(*parse.StringNode); ok {
+			return "start", layout.Text, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("unsupported template action %v", n)
+}
+
+// match returns template fields parsed from path, collision
+// suffix is ignored, returns nil if not matched.
+func (m *reindex_template_matcher) match(path string) map[string]string {
+	sub := m.re.FindStringSubmatch(path)
+	if sub == nil {
+		ext := filepath.Ext(path)
+		base := strings.TrimSuffix(path, ext)
+		if !reindex_collision_suffix_regexp.MatchString(base) {
+			return nil
+		}
+
+		if sub = m.re.FindStringSubmatch(reindex_collision_suffix_regexp.ReplaceAllString(base, "") + ext); sub == nil {
+			return nil
+		}
+	}
+
+	fields := make(map[string]string, len(m.fields))
+	for i, field := range m.fields {
+		fields[field] = sub[i+1]
+	}
+
+	return fields
+}
+
+// parse_reindex_start_time returns start time in fields, returns false if
+// fields has no start time.
+func parse_reindex_start_time(fields map[string]string, loc *time.Location) (time.Time, bool, error) {
+	if val, ok := fields["start_at"]; ok {
+		ts, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		return time.Unix(ts, 0), true, nil
+	}
+
+	for field, val := range fields {
+		if layout := strings.TrimPrefix(field, "start:"); layout != field {
+			t, err := time.ParseInLocation(layout, val, loc)
+			if err != nil {
+				return time.Time{}, false, err
+			}
+			return t, true, nil
+		}
+	}
+
+	if _, ok := fields["year"]; !ok {
+		return time.Time{}, false, nil
+	}
+
+	date := []string{"year", "month", "day", "hour", "minute", "second"}
+	parts := make([]int, len(date))
+	for i, key := range date {
+		// missing parts are the beginning of upper part.
+		if i < 3 {
+			parts[i] = 1
+		}
+
+		if val, ok := fields[key]; ok {
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return time.Time{}, false, err
+			}
+			parts[i] = n
+		}
+	}
+
+	t := time.Date(parts[0], time.Month(parts[1]), parts[2], parts[3], parts[4], parts[5], 0, loc)
+	return t, true, nil
+}
+
+// reindex_file returns record of file parsed by template matchers.
+func (drv *FFmpegDigitVideoRecorderDriver) reindex_file(opt *DigitVideoRecorderDriverOption, matchers []*reindex_template_matcher, path string) (*Record, error) {
+	var m *reindex_template_matcher
+	var fields map[string]string
+	for _, cur := range matchers {
+		if fields = cur.match(path); fields != nil {
+			m = cur
+			break
+		}
+	}
+	if m == nil {
+		return nil, fmt.Errorf("not matched by output file templates")
+	}
+
+	start_at, has_start, err := parse_reindex_start_time(fields, m.profile.loc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse start time: %v", err)
+	}
+
+	var duration time.Duration
+	if val, ok := fields["end_at"]; ok && has_start {
+		ts, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		duration = time.Unix(ts, 0).Sub(start_at)
+	} else if val, ok := fields["duration"]; ok {
+		sec, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		duration = time.Duration(sec) * time.Second
+	}
+
+	if !has_start || duration <= 0 {
+		media, err := probe_media_file_by_option(opt, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !has_start {
+			if media.CreationTime.IsZero() {
+				return nil, fmt.Errorf("start time not found in path or container")
+			}
+			start_at = media.CreationTime
+		}
+
+		if duration <= 0 {
+			duration = media.Duration
+		}
+	}
+
+	if duration <= 0 {
+		duration = time.Duration(m.profile.segment_time()) * time.Second
+	}
+
+	id, ok := fields["id"]
+	if !ok {
+		id = id_helper.NewNamedId("reindex:" + path)
+	}
+
+	return &Record{
+		Id:        id,
+		StartAt:   start_at,
+		EndAt:     start_at.Add(duration),
+		Path:      path,
+		Profile:   m.profile.name,
+		AudioOnly: m.profile.audio_only,
+	}, nil
+}
+
+func (drv *FFmpegDigitVideoRecorderDriver) ReindexRecords() (*ReindexRecordsResult, error) {
+	drv.op_mtx.Lock()
+	opt := drv.opt
+	drv.op_mtx.Unlock()
+
+	profiles, err := new_output_profiles(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	var matchers []*reindex_template_matcher
+	var dirs []string
+	seen := map[string]bool{}
+	for _, p := range profiles {
+		m, err := new_reindex_template_matcher(p)
+		if err != nil {
+			return nil, fmt.Errorf("output profile %v: %v", p.name, err)
+		}
+		matchers = append(matchers, m)
+
+		// walking relative base dir walks working directory of module.
+		dir := OutputFileBaseDir(p.opt.GetString("file"))
+		if !filepath.IsAbs(dir) {
+			return nil, ErrRelativeOutputFile
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	var files []string
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+
+		fs, err := walk_import_paths([]string{dir}, nil)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, fs...)
+	}
+
+	paths, err := drv.get_record_paths()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ReindexRecordsResult{}
+	for _, file := range files {
+		if paths[file] {
+			res.Indexed++
+			continue
+		}
+
+		r, err := drv.reindex_file(opt, matchers, file)
+		if err == nil {
+			err = drv.storage.SetRecord(r)
+		}
+		if err != nil {
+			drv.get_logger().WithError(err).WithField("file", file).Debugf("failed to reindex file")
+			res.Unmapped = append(res.Unmapped, &ImportRecordsFailure{Path: file, Reason: err.Error()})
+			continue
+		}
+
+		paths[file] = true
+		res.Recovered = append(res.Recovered, r)
+	}
+
+	drv.get_logger().WithFields(map[string]interface{}{
+		"recovered": len(res.Recovered),
+		"indexed":   res.Indexed,
+		"unmapped":  len(res.Unmapped),
+	}).Infof("reindex records")
+
+	return res, nil
+}
@@ -0,0 +1,143 @@
+package digit_video_recorder_driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+/*
+ * Output file template:
+ *   text/template, rendered when segment committed, missing directories are created,
+ *   `-<n>` is appended to file name if rendered path exists.
+ * Options:
+ *   driver:
+ *     [ channel: <name> ]  // channel name, like `cam1`, default `default`.
+ *     [ timezone: <tz> ]  // timezone of date fields, like `Asia/Shanghai`, default local timezone.
+ * Fields:
+ *   id: record id, 32 bytes.
+ *   start_at: timestamp, recording start at the time.
+ *   end_at: timestamp, recording end at the time.
+ *   start: recording start time in timezone, like `{{.start.Format "20060102"}}`.
+ *   year, month, day, hour, minute, second: zero padded date parts of start time in timezone.
+ *   channel: channel name.
+ *   profile: output profile name.
+ *   duration: segment duration, seconds.
+ *   ext: file extension, output format.
+ * Example:
+ *   /video/{{.year}}/{{.month}}/{{.day}}/{{.channel}}-{{.hour}}-{{.minute}}.{{.ext}}
+ *   => /video/2026/10/18/cam1-14-05.mp4
+ */
+
+const (
+	FFMPEG_DEFAULT_CHANNEL    = "default"
+	FFMPEG_MAX_PATH_COLLISION = 1000
+)
+
+func new_output_file_template(text string) (*template.Template, error) {
+	return template.New("driver").Option("missingkey=error").Parse(text)
+}
+
+// OutputFileBaseDir returns the static directory part of output file template,
+// like `/video` for `/video/{{.id}}.mp4`, relative templates are
+// refused by validation, so the base dir is absolute.
+func OutputFileBaseDir(text string) string {
+	if i := strings.Index(text, "{{"); i >= 0 {
+		text = text[:i]
+	}
+
+	if strings.HasSuffix(text, string(filepath.Separator)) {
+		return filepath.Clean(text)
+	}
+
+	return filepath.Dir(text)
+}
+
+func get_output_channel(opt *DigitVideoRecorderDriverOption) string {
+	if val := opt.GetString("channel"); val != "" {
+		return val
+	}
+	return FFMPEG_DEFAULT_CHANNEL
+}
+
+func get_output_timezone(opt *DigitVideoRecorderDriverOption) (*time.Location, error) {
+	if val := opt.GetString("timezone"); val != "" {
+		return time.LoadLocation(val)
+	}
+	return time.Local, nil
+}
+
+// output_file_template_data returns template fields of record,
+// profile is output profile options.
+func output_file_template_data(r *Record, profile *DigitVideoRecorderDriverOption, loc *time.Location) map[string]interface{} {
+	data := r.Data()
+
+	start := r.StartAt.In(loc)
+	data["start"] = start
+	data["year"] = start.Format("2006")
+	data["month"] = start.Format("01")
+	data["day"] = start.Format("02")
+	data["hour"] = start.Format("15")
+	data["minute"] = start.Format("04")
+	data["second"] = start.Format("05")
+	data["channel"] = get_output_channel(profile)
+	data["duration"] = int64(r.EndAt.Sub(r.StartAt) / time.Second)
+	data["ext"] = profile.GetString("format")
+
+	return data
+}
+
+// render_output_file renders output file path of record.
+func render_output_file(tmpl *template.Template, r *Record, profile *DigitVideoRecorderDriverOption, loc *time.Location) (string, error) {
+	var buf strings.Builder
+
+	if err := tmpl.Execute(&buf, output_file_template_data(r, profile, loc)); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// resolve_output_file_collision returns path not existing,
+// `-<n>` is appended to file name if path exists.
+func resolve_output_file_collision(path string) (string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	cur := path
+	for i := 1; i <= FFMPEG_MAX_PATH_COLLISION; i++ {
+		if _, err := os.Lstat(cur); os.IsNotExist(err) {
+			return cur, nil
+		} else if err != nil {
+			return "", err
+		}
+		cur = fmt.Sprintf("%v-%d%v", base, i, ext)
+	}
+
+	return "", fmt.Errorf("too many files collide with %v", path)
+}
+
+func validate_output_timezone(v *config_validator, opt *DigitVideoRecorderDriverOption) {
+	if _, err := get_output_timezone(opt); err != nil {
+		v.invalid("timezone", "unknown timezone: %v", err)
+	}
+}
+
+// existing_parent_dir returns the nearest existing ancestor directory of path.
+func existing_parent_dir(path string) string {
+	dir := filepath.Dir(path)
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
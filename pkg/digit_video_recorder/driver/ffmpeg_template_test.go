@@ -0,0 +1,55 @@
+package digit_video_recorder_driver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputFileBaseDir(t *testing.T) {
+	for _, tc := range []struct {
+		text   string
+		expect string
+	}{
+		{"/video/{{.id}}.mp4", "/video"},
+		{"/video/{{.year}}/{{.month}}/{{.id}}.mp4", "/video"},
+		{"/video/cam1-{{.id}}.mp4", "/video"},
+		{"/video/cam1/", "/video/cam1"},
+		{"{{.id}}.mp4", "."},
+	} {
+		if val := OutputFileBaseDir(tc.text); val != filepath.FromSlash(tc.expect) {
+			t.Errorf("%q: expect %v, got %v", tc.text, tc.expect, val)
+		}
+	}
+}
+
+func TestValidateOutputFileTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtdvr-template-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, tc := range []struct {
+		file string
+		ok   bool
+	}{
+		{filepath.Join(dir, "{{.year}}", "{{.id}}.{{.ext}}"), true},
+		{"{{.id}}.mp4", false},
+		{"records/{{.id}}.mp4", false},
+		{filepath.Join(dir, "{{.missing}}.mp4"), false},
+		{filepath.Join(dir, "{{.id"), false},
+	} {
+		v := new_config_validator()
+		v.validate_output_file_template("output.file", new_test_ffmpeg_option(map[string]interface{}{
+			"file":         tc.file,
+			"format":       "mp4",
+			"segment_time": 60,
+		}))
+
+		if err := v.error(); (err == nil) != tc.ok {
+			t.Errorf("%q: expect accepted %v, got %v", tc.file, tc.ok, err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package digit_video_recorder_driver
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+/*
+ * Watchdog:
+ *   restart ffmpeg when it is running but producing nothing,
+ *   like frozen rtsp session.
+ * Options:
+ *   driver:
+ *     watchdog:
+ *       [ enable: <bool> ]  // default `true`.
+ *       [ stall_timeout: <sec> ]  // no frames and no segment growth in the period is a stall, default 60.
+ *       [ check_interval: <sec> ]  // default 5.
+ */
+
+const (
+	WATCHDOG_DEFAULT_STALL_TIMEOUT  = 60 * time.Second
+	WATCHDOG_DEFAULT_CHECK_INTERVAL = 5 * time.Second
+)
+
+type ffmpeg_watchdog_sample struct {
+	frames       int64
+	total_size   int64
+	segment_file string
+	segment_size int64
+}
+
+func new_ffmpeg_watchdog_sample(stats *RecordingStats) ffmpeg_watchdog_sample {
+	return ffmpeg_watchdog_sample{
+		frames:       stats.Frames,
+		total_size:   stats.TotalSize,
+		segment_file: stats.SegmentFile,
+		segment_size: stats.SegmentSize,
+	}
+}
+
+// ffmpeg_watchdog_state tracks the last sample with progress.
+type ffmpeg_watchdog_state struct {
+	last        ffmpeg_watchdog_sample
+	last_active time.Time
+}
+
+// observe returns idle duration since the last sample with progress,
+// any change of frames, total size or segment is progress.
+func (w *ffmpeg_watchdog_state) observe(cur ffmpeg_watchdog_sample, now time.Time) time.Duration {
+	if cur != w.last {
+		w.last = cur
+		w.last_active = now
+		return 0
+	}
+
+	return now.Sub(w.last_active)
+}
+
+func (drv *FFmpegDigitVideoRecorderDriver) is_watchdog_enabled(opt *DigitVideoRecorderDriverOption) bool {
+	return !opt.IsSet("watchdog.enable") || opt.GetBool("watchdog.enable")
+}
+
+// watchdog_loop exits when ffmpeg stopped (ctx canceled) or stall handled.
+func (drv *FFmpegDigitVideoRecorderDriver) watchdog_loop(ctx context.Context, cmd *exec.Cmd, opt *DigitVideoRecorderDriverOption) {
+	stall_timeout := WATCHDOG_DEFAULT_STALL_TIMEOUT
+	if val := opt.GetInt("watchdog.stall_timeout"); val > 0 {
+		stall_timeout = time.Duration(val) * time.Second
+	}
+
+	check_interval := WATCHDOG_DEFAULT_CHECK_INTERVAL
+	if val := opt.GetInt("watchdog.check_interval"); val > 0 {
+		check_interval = time.Duration(val) * time.Second
+	}
+
+	ticker := time.NewTicker(check_interval)
+	defer ticker.Stop()
+
+	w := &ffmpeg_watchdog_state{last_active: time.Now()}
+
+	for {
+		select {
+		case <-ctx.Done():
+			drv.get_logger().Debugf("watchdog exit")
+			return
+		case <-ticker.C:
+		}
+
+		stats, err := drv.Stats()
+		if err != nil {
+			drv.get_logger().WithError(err).Debugf("failed to get stats for watchdog")
+			continue
+		}
+
+		idle := w.observe(new_ffmpeg_watchdog_sample(stats), time.Now())
+		if idle < stall_timeout {
+			continue
+		}
+
+		drv.handle_stall(cmd, idle)
+		return
+	}
+}
+
+// handle_stall records the stall and restarts ffmpeg,
+// state is `stalled` if ffmpeg failed to restart,
+// state changes are emitted as `state.changed` events.
+func (drv *FFmpegDigitVideoRecorderDriver) handle_stall(cmd *exec.Cmd, idle time.Duration) {
+	drv.op_mtx.Lock()
+	defer drv.op_mtx.Unlock()
+
+	// stopped or restarted already.
+	if drv.cmd != cmd {
+		return
+	}
+
+	drv.get_logger().WithField("idle", idle.String()).Warningf("ffmpeg stalled, restart it")
+
+	drv.update_stats(func(stats *RecordingStats) {
+		stats.Stalls++
+		stats.Restarts++
+		stats.LastStallAt = time.Now()
+	})
+
+	if err := drv.reset(); err != nil {
+		drv.get_logger().WithError(err).Errorf("failed to stop stalled ffmpeg")
+	}
+
+	if err := drv.start(); err != nil {
+		drv.get_logger().WithError(err).Errorf("failed to restart stalled ffmpeg")
+		drv.emit_error_event(EVENT_ERROR_SOURCE_WATCHDOG, err)
+		drv.set_state(DIGITI_VIDEO_RECORDER_STATE_STALLED)
+	}
+}
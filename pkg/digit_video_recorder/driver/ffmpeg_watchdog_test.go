@@ -0,0 +1,59 @@
+package digit_video_recorder_driver
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFFmpegWatchdogObserve(t *testing.T) {
+	base := &RecordingStats{
+		Frames:      100,
+		TotalSize:   4096,
+		SegmentFile: "mtdvr-1-default-00000001.mp4",
+		SegmentSize: 1024,
+	}
+
+	now := test_record_base_time
+	w := &ffmpeg_watchdog_state{last_active: now}
+
+	if idle := w.observe(new_ffmpeg_watchdog_sample(base), now.Add(5*time.Second)); idle != 0 {
+		t.Fatalf("first sample: expect progress, got idle %v", idle)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		change   func(stats *RecordingStats)
+		progress bool
+	}{
+		{"nothing", func(stats *RecordingStats) {}, false},
+		{"frames", func(stats *RecordingStats) { stats.Frames++ }, true},
+		{"total_size", func(stats *RecordingStats) { stats.TotalSize++ }, true},
+		{"segment_size", func(stats *RecordingStats) { stats.SegmentSize++ }, true},
+		// new segment of the same size is progress.
+		{"segment_file", func(stats *RecordingStats) { stats.SegmentFile = "mtdvr-1-default-00000002.mp4" }, true},
+		// ffmpeg reports speed and bitrate without new frames when frozen.
+		{"speed only", func(stats *RecordingStats) { stats.Speed = 1.5; stats.Bitrate = 100; stats.UpdatedAt = now }, false},
+	} {
+		w := &ffmpeg_watchdog_state{}
+		w.observe(new_ffmpeg_watchdog_sample(base), now)
+
+		stats := *base
+		tc.change(&stats)
+
+		idle := w.observe(new_ffmpeg_watchdog_sample(&stats), now.Add(time.Minute))
+		if tc.progress && idle != 0 {
+			t.Errorf("%v: expect progress, got idle %v", tc.name, idle)
+		}
+		if !tc.progress && idle != time.Minute {
+			t.Errorf("%v: expect idle 1m, got %v", tc.name, idle)
+		}
+	}
+
+	// idle grows from the last progress, not the last sample.
+	w = &ffmpeg_watchdog_state{}
+	w.observe(new_ffmpeg_watchdog_sample(base), now)
+	w.observe(new_ffmpeg_watchdog_sample(base), now.Add(30*time.Second))
+	if idle := w.observe(new_ffmpeg_watchdog_sample(base), now.Add(70*time.Second)); idle != 70*time.Second {
+		t.Errorf("expect idle 70s, got %v", idle)
+	}
+}
@@ -0,0 +1,318 @@
+package digit_video_recorder_driver
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+ * Index backup:
+ *   records in a consistent snapshot of record storage are exported to a
+ *   portable file, independent of storage driver, pending records are skipped.
+ *   file is JSON lines:
+ *     {"format":"mtdvr-index","version":1,"created_at":<unix nano>}
+ *     <record>  // one record per line, see record_codec.go.
+ *     {"records":<count>,"sha256":"<hex>"}  // checksum of lines above.
+ *   restore validates the whole file before replacing records,
+ *   recording should be stopped.
+ *   backup files are kept in `backup_dir`, paths of backup and restore are
+ *   relative to it, absolute paths and paths out of it are refused.
+ * Options:
+ *   driver:
+ *     [ backup_dir: <path> ]  // directory of index backups, like a directory on NAS,
+ *                             // backup and restore are disabled if not set.
+ */
+
+const (
+	INDEX_BACKUP_FORMAT  = "mtdvr-index"
+	INDEX_BACKUP_VERSION = 1
+	// max line size of backup file.
+	INDEX_BACKUP_MAX_LINE_SIZE = 1 << 20
+)
+
+type IndexBackup struct {
+	Path      string
+	Records   int
+	CreatedAt time.Time
+	Checksum  string
+}
+
+type index_backup_header struct {
+	Format    string `json:"format"`
+	Version   int    `json:"version"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type index_backup_trailer struct {
+	Records int    `json:"records"`
+	Sha256  string `json:"sha256"`
+}
+
+// write_index_backup writes records of storage snapshot to w.
+func write_index_backup(stor RecordStorage, w io.Writer, created_at time.Time) (*IndexBackup, error) {
+	bw := bufio.NewWriter(w)
+	h := sha256.New()
+	out := io.MultiWriter(bw, h)
+
+	write_line := func(buf []byte) error {
+		if _, err := out.Write(append(buf, '\n')); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	buf, err := json.Marshal(&index_backup_header{
+		Format:    INDEX_BACKUP_FORMAT,
+		Version:   INDEX_BACKUP_VERSION,
+		CreatedAt: created_at.UnixNano(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = write_line(buf); err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err = stor.WalkSnapshot(func(r *Record) error {
+		if r.Pending {
+			return nil
+		}
+
+		buf, err := encode_record(r)
+		if err != nil {
+			return err
+		}
+		count++
+
+		return write_line(buf)
+	}); err != nil {
+		return nil, err
+	}
+
+	checksum := hex.EncodeToString(h.Sum(nil))
+	if buf, err = json.Marshal(&index_backup_trailer{Records: count, Sha256: checksum}); err != nil {
+		return nil, err
+	}
+
+	if _, err = bw.Write(append(buf, '\n')); err != nil {
+		return nil, err
+	}
+
+	if err = bw.Flush(); err != nil {
+		return nil, err
+	}
+
+	return &IndexBackup{
+		Records:   count,
+		CreatedAt: created_at,
+		Checksum:  checksum,
+	}, nil
+}
+
+// read_index_backup reads and validates records in backup.
+func read_index_backup(r io.Reader) (*IndexBackup, []*Record, error) {
+	var lines [][]byte
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), INDEX_BACKUP_MAX_LINE_SIZE)
+	for sc.Scan() {
+		if line := bytes.TrimSpace(sc.Bytes()); len(line) > 0 {
+			lines = append(lines, append([]byte(nil), line...))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(lines) < 2 {
+		return nil, nil, fmt.Errorf("invalid index backup: truncated file")
+	}
+
+	var hdr index_backup_header
+	if err := json.Unmarshal(lines[0], &hdr); err != nil || hdr.Format != INDEX_BACKUP_FORMAT {
+		return nil, nil, fmt.Errorf("invalid index backup: unexpected header")
+	}
+
+	if hdr.Version != INDEX_BACKUP_VERSION {
+		return nil, nil, fmt.Errorf("%v: index backup version %v", ErrUnsupportedSchemaVersion, hdr.Version)
+	}
+
+	var trl index_backup_trailer
+	if err := json.Unmarshal(lines[len(lines)-1], &trl); err != nil || trl.Sha256 == "" {
+		return nil, nil, fmt.Errorf("invalid index backup: truncated file")
+	}
+
+	h := sha256.New()
+	for _, line := range lines[:len(lines)-1] {
+		h.Write(line)
+		h.Write([]byte{'\n'})
+	}
+
+	checksum := hex.EncodeToString(h.Sum(nil))
+	if checksum != trl.Sha256 {
+		return nil, nil, fmt.Errorf("invalid index backup: checksum mismatch")
+	}
+
+	body := lines[1 : len(lines)-1]
+	if len(body) != trl.Records {
+		return nil, nil, fmt.Errorf("invalid index backup: expect %v records, got %v", trl.Records, len(body))
+	}
+
+	ids := map[string]bool{}
+	rs := make([]*Record, 0, len(body))
+	for i, line := range body {
+		r, err := decode_record(line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid index backup: record %v: %v", i, err)
+		}
+
+		switch {
+		case r.Id == "":
+			err = fmt.Errorf("id required")
+		case r.Path == "":
+			err = fmt.Errorf("path required")
+		case r.EndAt.Before(r.StartAt):
+			err = fmt.Errorf("end before start")
+		case r.Pending:
+			err = fmt.Errorf("pending record")
+		case ids[r.Id]:
+			err = fmt.Errorf("duplicated id %v", r.Id)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid index backup: record %v: %v", i, err)
+		}
+
+		ids[r.Id] = true
+		rs = append(rs, r)
+	}
+
+	return &IndexBackup{
+		Records:   len(rs),
+		CreatedAt: time.Unix(0, hdr.CreatedAt),
+		Checksum:  checksum,
+	}, rs, nil
+}
+
+// resolve_index_backup_path returns path of backup file name in backup dir,
+// name should be relative and inside backup dir.
+func resolve_index_backup_path(backup_dir string, name string) (string, string, error) {
+	if backup_dir == "" {
+		return "", "", ErrIndexBackupDisabled
+	}
+
+	if name == "" || filepath.IsAbs(name) {
+		return "", "", ErrInvalidIndexBackupPath
+	}
+
+	name = filepath.Clean(name)
+	if name == "." || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return "", "", ErrInvalidIndexBackupPath
+	}
+
+	return filepath.Join(backup_dir, name), name, nil
+}
+
+func (drv *FFmpegDigitVideoRecorderDriver) get_backup_dir() string {
+	drv.op_mtx.Lock()
+	defer drv.op_mtx.Unlock()
+
+	return drv.opt.GetString("backup_dir")
+}
+
+// BackupIndex writes records to backup file at name in backup dir, file is
+// written to a temp file beside it and renamed, so it is complete or unchanged.
+func (drv *FFmpegDigitVideoRecorderDriver) BackupIndex(name string) (*IndexBackup, error) {
+	path, name, err := resolve_index_backup_path(drv.get_backup_dir(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	f, err := ioutil.TempFile(dir, FFMPEG_COMMIT_TEMP_PREFIX)
+	if err != nil {
+		return nil, err
+	}
+	tmp := f.Name()
+
+	bak, err := write_index_backup(drv.storage, f, time.Now())
+	if err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	sync_dir(dir)
+
+	bak.Path = name
+	drv.get_logger().WithFields(map[string]interface{}{
+		"path":    path,
+		"records": bak.Records,
+	}).Infof("backup index")
+
+	return bak, nil
+}
+
+// RestoreIndex replaces records by backup file at name in backup dir, records
+// are not changed if backup is invalid, recording should be stopped.
+func (drv *FFmpegDigitVideoRecorderDriver) RestoreIndex(name string) (*IndexBackup, error) {
+	path, name, err := resolve_index_backup_path(drv.get_backup_dir(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	bak, rs, err := read_index_backup(f)
+	if err != nil {
+		return nil, err
+	}
+	bak.Path = name
+
+	drv.op_mtx.Lock()
+	defer drv.op_mtx.Unlock()
+
+	if drv.cfn != nil {
+		return nil, ErrNotStopped
+	}
+
+	if err = drv.storage.ReplaceRecords(rs); err != nil {
+		return nil, err
+	}
+
+	drv.get_logger().WithFields(map[string]interface{}{
+		"path":    path,
+		"records": bak.Records,
+	}).Infof("restore index")
+
+	return bak, nil
+}
@@ -0,0 +1,167 @@
+package digit_video_recorder_driver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func new_test_memory_record_storage(t *testing.T, rs ...*Record) RecordStorage {
+	stor, err := NewRecordStorage("memory", &RecordStorageOption{viper.New()}, "logger", new_test_logger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range rs {
+		if err = stor.SetRecord(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return stor
+}
+
+// new_test_index_backup returns backup file of lines with valid trailer.
+func new_test_index_backup(lines ...string) []byte {
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.WriteString(line + "\n")
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	fmt.Fprintf(&buf, `{"records":%d,"sha256":"%v"}`+"\n", len(lines)-1, hex.EncodeToString(sum[:]))
+
+	return buf.Bytes()
+}
+
+func TestIndexBackupRoundTrip(t *testing.T) {
+	pending := new_test_record("c", 20, 30, "")
+	pending.Pending = true
+
+	stor := new_test_memory_record_storage(t,
+		new_test_record("a", 0, 10, ""),
+		new_test_record("b", 10, 20, "proxy"),
+		pending,
+	)
+	defer stor.Close()
+
+	var buf bytes.Buffer
+	bak, err := write_index_backup(stor, &buf, test_record_at(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bak.Records != 2 {
+		t.Errorf("expect 2 records written, pending skipped, got %v", bak.Records)
+	}
+
+	got, rs, err := read_index_backup(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Records != bak.Records || got.Checksum != bak.Checksum || !got.CreatedAt.Equal(bak.CreatedAt) {
+		t.Errorf("expect backup %+v, got %+v", bak, got)
+	}
+
+	byid := map[string]*Record{}
+	for _, r := range rs {
+		byid[r.Id] = r
+	}
+
+	for _, expect := range []*Record{new_test_record("a", 0, 10, ""), new_test_record("b", 10, 20, "proxy")} {
+		r, ok := byid[expect.Id]
+		if !ok {
+			t.Errorf("record %v not restored", expect.Id)
+			continue
+		}
+		if !r.StartAt.Equal(expect.StartAt) || !r.EndAt.Equal(expect.EndAt) || r.Path != expect.Path || r.Profile != expect.Profile {
+			t.Errorf("expect record %+v, got %+v", expect, r)
+		}
+	}
+}
+
+func TestReadIndexBackupInvalid(t *testing.T) {
+	stor := new_test_memory_record_storage(t,
+		new_test_record("a", 0, 10, ""),
+		new_test_record("b", 10, 20, ""),
+	)
+	defer stor.Close()
+
+	var buf bytes.Buffer
+	if _, err := write_index_backup(stor, &buf, test_record_at(100)); err != nil {
+		t.Fatal(err)
+	}
+	valid := buf.String()
+	lines := strings.SplitAfter(valid, "\n")
+
+	hdr := fmt.Sprintf(`{"format":"%v","version":%d,"created_at":0}`, INDEX_BACKUP_FORMAT, INDEX_BACKUP_VERSION)
+	rec := func(id string) string {
+		b, err := encode_record(new_test_record(id, 0, 10, ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		file   string
+		expect string
+	}{
+		{"checksum mismatch", strings.Replace(valid, `"a"`, `"z"`, 1), "checksum mismatch"},
+		{"truncated trailer", strings.Join(lines[:len(lines)-2], ""), "truncated file"},
+		{"record dropped", lines[0] + lines[2] + lines[3], "checksum mismatch"},
+		{"truncated line", valid[:len(valid)-10], "truncated file"},
+		{"header only", lines[0], "truncated file"},
+		{"empty", "", "truncated file"},
+		{"unknown format", strings.Replace(valid, INDEX_BACKUP_FORMAT, "other", 1), "unexpected header"},
+		{"duplicated id", string(new_test_index_backup(hdr, rec("a"), rec("a"))), "duplicated id a"},
+		{"record count", strings.Replace(string(new_test_index_backup(hdr, rec("a"))), `"records":1`, `"records":2`, 1), "expect 2 records"},
+	} {
+		_, _, err := read_index_backup(strings.NewReader(tc.file))
+		if err == nil || !strings.Contains(err.Error(), tc.expect) {
+			t.Errorf("%v: expect error %q, got %v", tc.name, tc.expect, err)
+		}
+	}
+}
+
+func TestResolveIndexBackupPath(t *testing.T) {
+	if _, _, err := resolve_index_backup_path("", "index.bak"); err != ErrIndexBackupDisabled {
+		t.Errorf("expect ErrIndexBackupDisabled, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		expect string
+	}{
+		{"index.bak", "/backup/index.bak"},
+		{"daily/index.bak", "/backup/daily/index.bak"},
+		{"daily/../index.bak", "/backup/index.bak"},
+		{"", ""},
+		{".", ""},
+		{"..", ""},
+		{"../index.bak", ""},
+		{"daily/../../index.bak", ""},
+		{"/etc/passwd", ""},
+		{"/backup/index.bak", ""},
+	} {
+		path, _, err := resolve_index_backup_path("/backup", tc.name)
+		if tc.expect == "" {
+			if err != ErrInvalidIndexBackupPath {
+				t.Errorf("%q: expect ErrInvalidIndexBackupPath, got %v, %v", tc.name, path, err)
+			}
+			continue
+		}
+
+		if err != nil || path != filepath.FromSlash(tc.expect) {
+			t.Errorf("%q: expect %v, got %v, %v", tc.name, tc.expect, path, err)
+		}
+	}
+}
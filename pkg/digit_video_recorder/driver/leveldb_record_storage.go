@@ -0,0 +1,444 @@
+package digit_video_recorder_driver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	opt_helper "github.com/nayotta/metathings/pkg/common/option"
+	log "github.com/sirupsen/logrus"
+	"github.com/syndtr/goleveldb/leveldb"
+	leveldb_opt "github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+/*
+ * Driver: leveldb
+ *   leveldb record storage, records are indexed by start time,
+ *   range queries only scan records around the range.
+ * Options:
+ *   storage:
+ *     name: leveldb
+ *     file: <path>  // leveldb file path, missing parent directories are created.
+ * Keys:
+ *   record.<id>: record, see record_codec.go.
+ *   start.<start><id>: start time index, see start_index_key, rebuilt on
+ *     open if index version changed.
+ *   meta.schema_version: storage schema version.
+ *   meta.index_version: start time index version.
+ *   meta.max_duration: max record duration.
+ */
+
+const LEVELDB_INDEX_VERSION = 1
+
+var (
+	leveldb_record_prefix      = []byte("record.")
+	leveldb_start_prefix       = []byte("start.")
+	leveldb_schema_version_key = []byte("meta.schema_version")
+	leveldb_index_version_key  = []byte("meta.index_version")
+	leveldb_max_duration_key   = []byte("meta.max_duration")
+)
+
+type leveldbRecordStorage struct {
+	// serializes writes, as records and index are updated by read-modify-write.
+	mtx    sync.Mutex
+	db     *leveldb.DB
+	opt    *RecordStorageOption
+	logger log.FieldLogger
+}
+
+func leveldb_record_key(id string) []byte {
+	return append(append([]byte(nil), leveldb_record_prefix...), id...)
+}
+
+func leveldb_start_key(r *Record) []byte {
+	return append(append([]byte(nil), leveldb_start_prefix...), start_index_key(r)...)
+}
+
+func leveldb_encode_duration(d time.Duration) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(d))
+	return buf
+}
+
+type leveldb_reader interface {
+	Get(key []byte, ro *leveldb_opt.ReadOptions) ([]byte, error)
+}
+
+func leveldb_get_max_duration(db leveldb_reader) (time.Duration, error) {
+	buf, err := db.Get(leveldb_max_duration_key, nil)
+	if err == leveldb.ErrNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	if len(buf) != 8 {
+		return 0, nil
+	}
+
+	return time.Duration(binary.BigEndian.Uint64(buf)), nil
+}
+
+func leveldb_get_record(db leveldb_reader, id string) (*Record, error) {
+	buf, err := db.Get(leveldb_record_key(id), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return decode_record(buf)
+}
+
+func (s *leveldbRecordStorage) get_logger() log.FieldLogger {
+	return s.logger
+}
+
+func (s *leveldbRecordStorage) ListRecords(flt ListRecordsFitler) ([]*Record, error) {
+	var rs []*Record
+
+	snap, err := s.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Release()
+
+	rng := util.BytesPrefix(leveldb_start_prefix)
+	if !flt.Range.StartAt.IsZero() {
+		// records started before range start may still overlap the range,
+		// but not the ones started before range start minus max duration.
+		max_dur, err := leveldb_get_max_duration(snap)
+		if err != nil {
+			return nil, err
+		}
+		rng.Start = append(append([]byte(nil), leveldb_start_prefix...), encode_index_time(flt.Range.StartAt.Add(-max_dur))...)
+	}
+
+	var upper []byte
+	if !flt.Range.EndAt.IsZero() {
+		upper = encode_index_time(flt.Range.EndAt)
+	}
+
+	iter := snap.NewIterator(rng, nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		k := iter.Key()[len(leveldb_start_prefix):]
+		if upper != nil && bytes.Compare(k[:8], upper) >= 0 {
+			break
+		}
+
+		r, err := leveldb_get_record(snap, string(k[8:]))
+		if err != nil {
+			return nil, err
+		}
+
+		if flt.match(r) {
+			rs = append(rs, r)
+		}
+	}
+
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	sort_records(rs)
+
+	return rs, nil
+}
+
+func (s *leveldbRecordStorage) GetRecord(id string) (*Record, error) {
+	return leveldb_get_record(s.db, id)
+}
+
+func (s *leveldbRecordStorage) SetRecord(r *Record) error {
+	buf, err := encode_record(r)
+	if err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	batch := new(leveldb.Batch)
+
+	old, err := leveldb_get_record(s.db, r.Id)
+	if err == nil {
+		batch.Delete(leveldb_start_key(old))
+	} else if err != ErrNotFound {
+		return err
+	}
+
+	batch.Put(leveldb_record_key(r.Id), buf)
+	batch.Put(leveldb_start_key(r), nil)
+
+	max_dur, err := leveldb_get_max_duration(s.db)
+	if err != nil {
+		return err
+	}
+	if dur := r.EndAt.Sub(r.StartAt); dur > max_dur {
+		batch.Put(leveldb_max_duration_key, leveldb_encode_duration(dur))
+	}
+
+	return s.db.Write(batch, nil)
+}
+
+func (s *leveldbRecordStorage) UnsetRecord(id string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	r, err := leveldb_get_record(s.db, id)
+	if err == ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	batch := new(leveldb.Batch)
+	batch.Delete(leveldb_start_key(r))
+	batch.Delete(leveldb_record_key(id))
+
+	return s.db.Write(batch, nil)
+}
+
+func (s *leveldbRecordStorage) WalkSnapshot(fn func(*Record) error) error {
+	snap, err := s.db.GetSnapshot()
+	if err != nil {
+		return err
+	}
+	defer snap.Release()
+
+	iter := snap.NewIterator(util.BytesPrefix(leveldb_record_prefix), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		r, err := decode_record(iter.Value())
+		if err != nil {
+			return err
+		}
+
+		if err = fn(r); err != nil {
+			return err
+		}
+	}
+
+	return iter.Error()
+}
+
+// ReplaceRecords deletes all records and index, puts new records in one batch.
+func (s *leveldbRecordStorage) ReplaceRecords(rs []*Record) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	batch := new(leveldb.Batch)
+
+	for _, prefix := range [][]byte{leveldb_record_prefix, leveldb_start_prefix} {
+		iter := s.db.NewIterator(util.BytesPrefix(prefix), nil)
+		for iter.Next() {
+			batch.Delete(append([]byte(nil), iter.Key()...))
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return err
+		}
+	}
+
+	var max_dur time.Duration
+	for _, r := range rs {
+		buf, err := encode_record(r)
+		if err != nil {
+			return err
+		}
+		batch.Put(leveldb_record_key(r.Id), buf)
+		batch.Put(leveldb_start_key(r), nil)
+
+		if dur := r.EndAt.Sub(r.StartAt); dur > max_dur {
+			max_dur = dur
+		}
+	}
+	batch.Put(leveldb_max_duration_key, leveldb_encode_duration(max_dur))
+
+	return s.db.Write(batch, &leveldb_opt.WriteOptions{Sync: true})
+}
+
+// migrate rewrites records in current schema version.
+func (s *leveldbRecordStorage) migrate() error {
+	buf, err := s.db.Get(leveldb_schema_version_key, nil)
+	if err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+
+	ver, err := parse_schema_version(buf)
+	if err != nil {
+		return err
+	}
+
+	if err = check_schema_version(ver); err != nil {
+		return err
+	}
+
+	if ver == RECORD_SCHEMA_VERSION {
+		return nil
+	}
+
+	s.get_logger().WithField("version", ver).Infof("migrate record storage")
+
+	var count int
+	batch := new(leveldb.Batch)
+	iter := s.db.NewIterator(util.BytesPrefix(leveldb_record_prefix), nil)
+	for iter.Next() {
+		val, err := migrate_record(iter.Value())
+		if err != nil {
+			iter.Release()
+			return fmt.Errorf("%s: %v", iter.Key(), err)
+		}
+
+		if val == nil {
+			continue
+		}
+
+		batch.Put(append([]byte(nil), iter.Key()...), val)
+		if batch.Len() >= RECORD_MIGRATION_BATCH_SIZE {
+			// not synced, leveldb journal is sequential, so batches lost
+			// in crash are after all persisted ones, and schema version is
+			// written in the last synced batch, lost batches are migrated
+			// again on next open, as migrated records are skipped.
+			if err = s.db.Write(batch, nil); err != nil {
+				iter.Release()
+				return err
+			}
+			count += batch.Len()
+			batch.Reset()
+		}
+	}
+	iter.Release()
+	if err = iter.Error(); err != nil {
+		return err
+	}
+
+	count += batch.Len()
+	batch.Put(leveldb_schema_version_key, format_schema_version(RECORD_SCHEMA_VERSION))
+	if err = s.db.Write(batch, &leveldb_opt.WriteOptions{Sync: true}); err != nil {
+		return err
+	}
+
+	s.get_logger().WithFields(log.Fields{"version": RECORD_SCHEMA_VERSION, "records": count}).Infof("record storage migrated")
+
+	return nil
+}
+
+// migrate_start_index rebuilds start time index and max duration
+// in one batch if index version changed.
+func (s *leveldbRecordStorage) migrate_start_index() error {
+	buf, err := s.db.Get(leveldb_index_version_key, nil)
+	if err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+
+	ver, err := parse_schema_version(buf)
+	if err != nil {
+		return err
+	}
+
+	if ver == LEVELDB_INDEX_VERSION {
+		return nil
+	}
+
+	batch := new(leveldb.Batch)
+
+	iter := s.db.NewIterator(util.BytesPrefix(leveldb_start_prefix), nil)
+	for iter.Next() {
+		batch.Delete(append([]byte(nil), iter.Key()...))
+	}
+	iter.Release()
+	if err = iter.Error(); err != nil {
+		return err
+	}
+
+	var count int
+	var max_dur time.Duration
+	iter = s.db.NewIterator(util.BytesPrefix(leveldb_record_prefix), nil)
+	for iter.Next() {
+		r, err := decode_record(iter.Value())
+		if err != nil {
+			iter.Release()
+			return fmt.Errorf("%s: %v", iter.Key(), err)
+		}
+
+		batch.Put(leveldb_start_key(r), nil)
+		if dur := r.EndAt.Sub(r.StartAt); dur > max_dur {
+			max_dur = dur
+		}
+		count++
+	}
+	iter.Release()
+	if err = iter.Error(); err != nil {
+		return err
+	}
+
+	batch.Put(leveldb_max_duration_key, leveldb_encode_duration(max_dur))
+	batch.Put(leveldb_index_version_key, format_schema_version(LEVELDB_INDEX_VERSION))
+	if err = s.db.Write(batch, &leveldb_opt.WriteOptions{Sync: true}); err != nil {
+		return err
+	}
+
+	if count > 0 {
+		s.get_logger().WithFields(log.Fields{"version": LEVELDB_INDEX_VERSION, "records": count}).Infof("record storage index rebuilt")
+	}
+
+	return nil
+}
+
+func (s *leveldbRecordStorage) Close() error {
+	return s.db.Close()
+}
+
+func NewLeveldbRecordStorage(opt *RecordStorageOption, args ...interface{}) (RecordStorage, error) {
+	var logger log.FieldLogger
+	var db *leveldb.DB
+	var err error
+
+	if err = opt_helper.Setopt(opt_helper.SetoptConds{
+		"logger": opt_helper.ToLogger(&logger),
+	})(args...); err != nil {
+		return nil, err
+	}
+
+	if val := opt.GetString("file"); val != "" {
+		if db, err = leveldb.OpenFile(val, nil); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, new_invalid_config_error("file")
+	}
+
+	stor := &leveldbRecordStorage{
+		db:     db,
+		opt:    opt,
+		logger: logger,
+	}
+
+	if err = stor.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err = stor.migrate_start_index(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return stor, nil
+}
+
+var register_leveldb_record_storage_once sync.Once
+
+func init() {
+	register_leveldb_record_storage_once.Do(func() {
+		register_record_storage_factory("leveldb", NewLeveldbRecordStorage)
+		register_record_storage_validator("leveldb", validate_record_storage_file)
+	})
+}
@@ -0,0 +1,152 @@
+package digit_video_recorder_driver
+
+import (
+	"sync"
+
+	opt_helper "github.com/nayotta/metathings/pkg/common/option"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+ * Driver: memory
+ *   in-memory record storage, records lost after process exit,
+ *   for testing and demo only.
+ * Options:
+ *   storage:
+ *     name: memory
+ */
+
+type memoryRecordStorage struct {
+	mtx     sync.RWMutex
+	records map[string]Record
+	opt     *RecordStorageOption
+	logger  log.FieldLogger
+}
+
+func (s *memoryRecordStorage) get_logger() log.FieldLogger {
+	return s.logger
+}
+
+// copy_record returns copy of record not sharing hook results with r.
+func copy_record(r *Record) Record {
+	x := *r
+	if r.Hooks != nil {
+		x.Hooks = make(map[string]*HookResult, len(r.Hooks))
+		for name, res := range r.Hooks {
+			res := *res
+			x.Hooks[name] = &res
+		}
+	}
+	return x
+}
+
+func (s *memoryRecordStorage) ListRecords(flt ListRecordsFitler) ([]*Record, error) {
+	var rs []*Record
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	for _, r := range s.records {
+		r := copy_record(&r)
+		if flt.match(&r) {
+			rs = append(rs, &r)
+		}
+	}
+
+	sort_records(rs)
+
+	return rs, nil
+}
+
+func (s *memoryRecordStorage) GetRecord(id string) (*Record, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	r, ok := s.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	x := copy_record(&r)
+	return &x, nil
+}
+
+func (s *memoryRecordStorage) SetRecord(r *Record) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.records[r.Id] = copy_record(r)
+
+	return nil
+}
+
+func (s *memoryRecordStorage) UnsetRecord(id string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	delete(s.records, id)
+
+	return nil
+}
+
+func (s *memoryRecordStorage) WalkSnapshot(fn func(*Record) error) error {
+	s.mtx.RLock()
+	rs := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		rs = append(rs, copy_record(&r))
+	}
+	s.mtx.RUnlock()
+
+	for i := range rs {
+		if err := fn(&rs[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryRecordStorage) ReplaceRecords(rs []*Record) error {
+	records := make(map[string]Record, len(rs))
+	for _, r := range rs {
+		records[r.Id] = copy_record(r)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.records = records
+
+	return nil
+}
+
+func (s *memoryRecordStorage) Close() error {
+	return nil
+}
+
+func NewMemoryRecordStorage(opt *RecordStorageOption, args ...interface{}) (RecordStorage, error) {
+	var logger log.FieldLogger
+
+	if err := opt_helper.Setopt(opt_helper.SetoptConds{
+		"logger": opt_helper.ToLogger(&logger),
+	})(args...); err != nil {
+		return nil, err
+	}
+
+	stor := &memoryRecordStorage{
+		records: make(map[string]Record),
+		opt:     opt,
+		logger:  logger,
+	}
+
+	return stor, nil
+}
+
+var register_memory_record_storage_once sync.Once
+
+func init() {
+	register_memory_record_storage_once.Do(func() {
+		register_record_storage_factory("memory", NewMemoryRecordStorage)
+		register_record_storage_validator("memory", func(*config_validator, *RecordStorageOption) {})
+	})
+}
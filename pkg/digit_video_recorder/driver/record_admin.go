@@ -0,0 +1,262 @@
+package digit_video_recorder_driver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+ * Admin:
+ *   offline maintenance of records on config file, used by admin commands,
+ *   module is not required to be running, but storage file is locked by
+ *   running module for leveldb and bbolt storage.
+ */
+
+// OpenRecordStorage opens record storage of driver options.
+func OpenRecordStorage(opt *DigitVideoRecorderDriverOption, logger log.FieldLogger) (RecordStorage, error) {
+	sub := opt.Sub("storage")
+	if sub == nil {
+		return nil, ErrInvalidRecordStorage
+	}
+
+	stor_opt := &RecordStorageOption{sub.Viper}
+	return NewRecordStorage(stor_opt.GetString("name"), stor_opt, "logger", logger)
+}
+
+// RenderCommand returns ffmpeg argv of driver options, segments are
+// written into tmp_dir, like the argv launched when recording started.
+func RenderCommand(name string, opt *DigitVideoRecorderDriverOption, tmp_dir string, ts int64) ([]string, error) {
+	switch name {
+	case "ffmpeg":
+	case "simulator":
+		opt = new_simulator_ffmpeg_option(opt)
+	default:
+		return nil, ErrInvalidDigitVideoRecorderDriver
+	}
+
+	return build_ffmpeg_command(opt, tmp_dir, ts)
+}
+
+// ExportRecordFile copies record file into dir, `-<n>` is appended to
+// file name if file exists in dir, returns path of copied file.
+func ExportRecordFile(r *Record, dir string) (string, error) {
+	return export_record_file(context.Background(), r, dir)
+}
+
+// export_record_file is ExportRecordFile canceled by ctx.
+func export_record_file(ctx context.Context, r *Record, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	dst, err := resolve_output_file_collision(filepath.Join(dir, filepath.Base(r.Path)))
+	if err != nil {
+		return "", err
+	}
+
+	if err = copy_file_context(ctx, r.Path, dst); err != nil {
+		return "", err
+	}
+	sync_dir(dir)
+
+	return dst, nil
+}
+
+// VerifyRecord checks record file is committed, existing and not empty,
+// and playable by ffprobe if probe is true.
+func VerifyRecord(opt *DigitVideoRecorderDriverOption, r *Record, probe bool) error {
+	if r.Pending {
+		return fmt.Errorf("record is pending")
+	}
+
+	info, err := os.Stat(r.Path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("file not found")
+	} else if err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file")
+	}
+
+	if info.Size() == 0 {
+		return fmt.Errorf("file is empty")
+	}
+
+	if !r.EndAt.After(r.StartAt) {
+		return fmt.Errorf("invalid time range")
+	}
+
+	if probe {
+		media, err := probe_media_file(get_ffprobe_binary(opt), r.Path)
+		if err != nil {
+			return fmt.Errorf("failed to probe media file: %v", err)
+		}
+
+		if media.Duration <= 0 {
+			return fmt.Errorf("no duration in container")
+		}
+	}
+
+	return nil
+}
+
+// GC_MAX_REMOVED_RATIO is the max ratio of records removed by gc
+// without force, more removed records are likely caused by unmounted
+// archive disk than by files deleted by hand.
+const GC_MAX_REMOVED_RATIO = 0.5
+
+type GcRecordsOption struct {
+	// print records and files to remove only.
+	DryRun bool
+	// remove records even if more than GC_MAX_REMOVED_RATIO of records
+	// would be removed.
+	Force bool
+}
+
+type GcRecordsResult struct {
+	// records removed as file not found.
+	Removed []*Record
+	// records with file not found kept as base dir of output file is
+	// missing or empty, like archive disk not mounted.
+	Skipped []*Record
+	// temp files left by interrupted copy.
+	TempFiles []string
+}
+
+// gc_base_dirs returns base dirs of absolute output file templates,
+// longer dirs first to match nested templates.
+func gc_base_dirs(opt *DigitVideoRecorderDriverOption) []string {
+	var dirs []string
+	seen := map[string]bool{}
+	for _, file := range OutputFiles(opt) {
+		dir := OutputFileBaseDir(file)
+		if seen[dir] || !filepath.IsAbs(dir) {
+			continue
+		}
+		seen[dir] = true
+		dirs = append(dirs, dir)
+	}
+
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+
+	return dirs
+}
+
+// is_base_dir_available returns false if dir is missing or empty,
+// mount point of unmounted disk is left as an empty directory.
+func is_base_dir_available(dir string) bool {
+	f, err := os.Open(dir)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	names, err := f.Readdirnames(1)
+	return err == nil && len(names) > 0
+}
+
+// is_sub_path reports whether path is in dir.
+func is_sub_path(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// GcRecords removes records with file not found and temp files of
+// interrupted commits, pending records are left to recovery on driver
+// created. Records under missing or empty base dir of output files are
+// skipped, and nothing is removed if more than GC_MAX_REMOVED_RATIO of
+// records would be removed without force, or dry run.
+func GcRecords(opt *DigitVideoRecorderDriverOption, stor RecordStorage, gc_opt *GcRecordsOption) (*GcRecordsResult, error) {
+	rs, err := stor.ListRecords(ListRecordsFitler{})
+	if err != nil {
+		return nil, err
+	}
+
+	base_dirs := gc_base_dirs(opt)
+	available := map[string]bool{}
+	for _, dir := range base_dirs {
+		available[dir] = is_base_dir_available(dir)
+	}
+
+	res := &GcRecordsResult{}
+	dirs := map[string]bool{}
+	for _, r := range rs {
+		dirs[filepath.Dir(r.Path)] = true
+
+		if r.Pending || is_file_exist(r.Path) {
+			continue
+		}
+
+		skip := false
+		for _, dir := range base_dirs {
+			if is_sub_path(dir, r.Path) {
+				skip = !available[dir]
+				break
+			}
+		}
+
+		if skip {
+			res.Skipped = append(res.Skipped, r)
+		} else {
+			res.Removed = append(res.Removed, r)
+		}
+	}
+
+	if !gc_opt.DryRun && !gc_opt.Force && float64(len(res.Removed)) > GC_MAX_REMOVED_RATIO*float64(len(rs)) {
+		return nil, fmt.Errorf("%d of %d records would be removed, check by dry run and gc with force", len(res.Removed), len(rs))
+	}
+
+	if !gc_opt.DryRun {
+		for _, r := range res.Removed {
+			if err = stor.UnsetRecord(r.Id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for dir := range dirs {
+		names, err := filepath.Glob(filepath.Join(dir, FFMPEG_COMMIT_TEMP_PREFIX+"*"))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range names {
+			if !gc_opt.DryRun {
+				if err = os.Remove(name); err != nil && !os.IsNotExist(err) {
+					return nil, err
+				}
+			}
+			res.TempFiles = append(res.TempFiles, name)
+		}
+	}
+
+	return res, nil
+}
+
+// DeleteRecord removes record and its file, file is kept if keep_file is true.
+func DeleteRecord(stor RecordStorage, id string, keep_file bool) (*Record, error) {
+	r, err := stor.GetRecord(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !keep_file {
+		if err = os.Remove(r.Path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	if err = stor.UnsetRecord(r.Id); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -0,0 +1,123 @@
+package digit_video_recorder_driver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// new_test_gc_records returns minute records of ids in dir from start,
+// files of existing ids are created.
+func new_test_gc_records(t *testing.T, dir string, start int, ids []string, existing ...string) []*Record {
+	var rs []*Record
+	for i, id := range ids {
+		r := new_test_record(id, start+i*60, start+i*60+60, "")
+		r.Path = filepath.Join(dir, id+".mp4")
+		rs = append(rs, r)
+	}
+
+	for _, id := range existing {
+		if err := ioutil.WriteFile(filepath.Join(dir, id+".mp4"), []byte("mp4"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return rs
+}
+
+func TestGcRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtdvr-gc-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	main_dir := filepath.Join(dir, "main")
+	// mount point of unmounted archive disk.
+	archive_dir := filepath.Join(dir, "archive")
+	for _, d := range []string{main_dir, archive_dir} {
+		if err = os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := new_test_ffmpeg_option_map()
+	delete(m, "output")
+	m["outputs"] = []interface{}{
+		map[string]interface{}{"name": "main", "format": "mp4", "segment_time": 60, "file": filepath.Join(main_dir, "{{.id}}.mp4")},
+		map[string]interface{}{"name": "archive", "format": "mp4", "segment_time": 60, "file": filepath.Join(archive_dir, "{{.id}}.mp4")},
+		map[string]interface{}{"name": "missing", "format": "mp4", "segment_time": 60, "file": filepath.Join(dir, "missing", "{{.id}}.mp4")},
+	}
+	opt := new_test_ffmpeg_option(m)
+
+	rs := new_test_gc_records(t, main_dir, 0, []string{"m1", "m2"}, "m1")
+	rs = append(rs, new_test_gc_records(t, archive_dir, 120, []string{"a1", "a2"})...)
+	rs = append(rs, new_test_gc_records(t, filepath.Join(dir, "missing"), 240, []string{"x1"})...)
+	temp := filepath.Join(main_dir, FFMPEG_COMMIT_TEMP_PREFIX+"m3.mp4")
+	if err = ioutil.WriteFile(temp, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stor := new_test_memory_record_storage(t, rs...)
+	res, err := GcRecords(opt, stor, &GcRecordsOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := record_ids(res.Removed); !equal_strings(ids, []string{"m2"}) {
+		t.Errorf("expect removed [m2], got %v", ids)
+	}
+	if ids := record_ids(res.Skipped); !equal_strings(ids, []string{"a1", "a2", "x1"}) {
+		t.Errorf("expect skipped [a1 a2 x1], got %v", ids)
+	}
+	if len(res.TempFiles) != 1 || res.TempFiles[0] != temp || is_file_exist(temp) {
+		t.Errorf("expect temp file %v removed, got %v", temp, res.TempFiles)
+	}
+
+	left, err := stor.ListRecords(ListRecordsFitler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := record_ids(left); !equal_strings(ids, []string{"m1", "a1", "a2", "x1"}) {
+		t.Errorf("expect records [m1 a1 a2 x1] left, got %v", ids)
+	}
+}
+
+func TestGcRecordsMaxRemovedRatio(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtdvr-gc-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := new_test_ffmpeg_option_map()
+	m["output"].(map[string]interface{})["file"] = filepath.Join(dir, "{{.id}}.mp4")
+	opt := new_test_ffmpeg_option(m)
+
+	rs := new_test_gc_records(t, dir, 0, []string{"r1", "r2", "r3"}, "r1")
+	stor := new_test_memory_record_storage(t, rs...)
+
+	if _, err = GcRecords(opt, stor, &GcRecordsOption{}); err == nil {
+		t.Errorf("expect error of too many records removed")
+	}
+
+	res, err := GcRecords(opt, stor, &GcRecordsOption{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := record_ids(res.Removed); !equal_strings(ids, []string{"r2", "r3"}) {
+		t.Errorf("dry run: expect removed [r2 r3], got %v", ids)
+	}
+
+	if left, _ := stor.ListRecords(ListRecordsFitler{}); len(left) != 3 {
+		t.Errorf("expect no records removed without force, got %v left", record_ids(left))
+	}
+
+	if _, err = GcRecords(opt, stor, &GcRecordsOption{Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	if left, _ := stor.ListRecords(ListRecordsFitler{}); !equal_strings(record_ids(left), []string{"r1"}) {
+		t.Errorf("force: expect records [r1] left, got %v", record_ids(left))
+	}
+}
@@ -0,0 +1,187 @@
+package digit_video_recorder_driver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+/*
+ * Record encoding:
+ *   records are stored as JSON object with schema version key `v`,
+ *   times are unix nanoseconds, empty fields are omitted.
+ *   records stored before versioned encoding are YAML of Record,
+ *   decoded as schema version 0.
+ *   storage schema version is stored in storage metadata, records are
+ *   migrated to current version when storage opened, migration is done
+ *   in batches and resumed on next open if interrupted, as records
+ *   of both versions are decodable.
+ *   storage of newer schema version is refused to open.
+ *   optional fields may be added without version change, readers not
+ *   knowing a field drop it when they rewrite a record, e.g. `hooks`
+ *   results are lost if an older module updates the record.
+ */
+
+const (
+	RECORD_SCHEMA_VERSION = 1
+	// records rewritten in one batch in migration.
+	RECORD_MIGRATION_BATCH_SIZE = 1000
+)
+
+type record_v1 struct {
+	Version   int    `json:"v"`
+	Id        string `json:"id"`
+	StartAt   int64  `json:"start_at"`
+	EndAt     int64  `json:"end_at"`
+	Path      string `json:"path"`
+	Profile   string `json:"profile,omitempty"`
+	AudioOnly bool   `json:"audio_only,omitempty"`
+	Pending   bool   `json:"pending,omitempty"`
+	Source    string `json:"source,omitempty"`
+	// fields below are added in version 1 without version change,
+	// as they are optional, older readers drop them on rewrite.
+	Hooks map[string]*hook_result_v1 `json:"hooks,omitempty"`
+}
+
+type hook_result_v1 struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	FinishedAt int64  `json:"finished_at"`
+}
+
+func encode_record(r *Record) ([]byte, error) {
+	var hooks map[string]*hook_result_v1
+	if len(r.Hooks) > 0 {
+		hooks = make(map[string]*hook_result_v1, len(r.Hooks))
+		for name, res := range r.Hooks {
+			hooks[name] = &hook_result_v1{
+				Status:     res.Status,
+				Error:      res.Error,
+				FinishedAt: res.FinishedAt.UnixNano(),
+			}
+		}
+	}
+
+	return json.Marshal(&record_v1{
+		Version:   RECORD_SCHEMA_VERSION,
+		Id:        r.Id,
+		StartAt:   r.StartAt.UnixNano(),
+		EndAt:     r.EndAt.UnixNano(),
+		Path:      r.Path,
+		Profile:   r.Profile,
+		AudioOnly: r.AudioOnly,
+		Pending:   r.Pending,
+		Source:    r.Source,
+		Hooks:     hooks,
+	})
+}
+
+// record_schema_version returns schema version of encoded record.
+func record_schema_version(buf []byte) (int, error) {
+	if !bytes.HasPrefix(buf, []byte("{")) {
+		return 0, nil
+	}
+
+	var v struct {
+		Version int `json:"v"`
+	}
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return 0, err
+	}
+
+	return v.Version, nil
+}
+
+func decode_record(buf []byte) (*Record, error) {
+	ver, err := record_schema_version(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ver {
+	case 0:
+		var r Record
+		if err = yaml.Unmarshal(buf, &r); err != nil {
+			return nil, err
+		}
+		return &r, nil
+	case 1:
+		var x record_v1
+		if err = json.Unmarshal(buf, &x); err != nil {
+			return nil, err
+		}
+		r := &Record{
+			Id:        x.Id,
+			StartAt:   time.Unix(0, x.StartAt),
+			EndAt:     time.Unix(0, x.EndAt),
+			Path:      x.Path,
+			Profile:   x.Profile,
+			AudioOnly: x.AudioOnly,
+			Pending:   x.Pending,
+			Source:    x.Source,
+		}
+		if len(x.Hooks) > 0 {
+			r.Hooks = make(map[string]*HookResult, len(x.Hooks))
+			for name, res := range x.Hooks {
+				r.Hooks[name] = &HookResult{
+					Status:     res.Status,
+					Error:      res.Error,
+					FinishedAt: time.Unix(0, res.FinishedAt),
+				}
+			}
+		}
+		return r, nil
+	default:
+		return nil, fmt.Errorf("%v: record schema version %v", ErrUnsupportedSchemaVersion, ver)
+	}
+}
+
+// migrate_record returns record encoded in current schema version,
+// nil if record is current.
+func migrate_record(buf []byte) ([]byte, error) {
+	ver, err := record_schema_version(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if ver == RECORD_SCHEMA_VERSION {
+		return nil, nil
+	}
+
+	r, err := decode_record(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return encode_record(r)
+}
+
+// parse_schema_version parses storage schema version in metadata,
+// storage without schema version is version 0.
+func parse_schema_version(buf []byte) (int, error) {
+	if buf == nil {
+		return 0, nil
+	}
+
+	ver, err := strconv.Atoi(string(buf))
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q", buf)
+	}
+
+	return ver, nil
+}
+
+func format_schema_version(ver int) []byte {
+	return []byte(strconv.Itoa(ver))
+}
+
+func check_schema_version(ver int) error {
+	if ver > RECORD_SCHEMA_VERSION {
+		return fmt.Errorf("%v: storage schema version %v, supported %v", ErrUnsupportedSchemaVersion, ver, RECORD_SCHEMA_VERSION)
+	}
+	return nil
+}
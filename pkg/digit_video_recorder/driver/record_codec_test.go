@@ -0,0 +1,237 @@
+package digit_video_recorder_driver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"gopkg.in/yaml.v2"
+)
+
+func new_test_codec_record() *Record {
+	r := new_test_record("a", 0, 60, "proxy")
+	r.AudioOnly = true
+	r.Source = "import"
+	r.Hooks = map[string]*HookResult{
+		"upload": {Status: "failed", Error: "timeout", FinishedAt: test_record_at(70)},
+	}
+	return r
+}
+
+func check_codec_record(t *testing.T, name string, expect, r *Record) {
+	if r.Id != expect.Id || !r.StartAt.Equal(expect.StartAt) || !r.EndAt.Equal(expect.EndAt) ||
+		r.Path != expect.Path || r.Profile != expect.Profile || r.AudioOnly != expect.AudioOnly ||
+		r.Pending != expect.Pending || r.Source != expect.Source {
+		t.Errorf("%v: expect record %+v, got %+v", name, expect, r)
+	}
+
+	if len(r.Hooks) != len(expect.Hooks) {
+		t.Errorf("%v: expect hooks %v, got %v", name, expect.Hooks, r.Hooks)
+		return
+	}
+	for hook, res := range expect.Hooks {
+		got, ok := r.Hooks[hook]
+		if !ok || got.Status != res.Status || got.Error != res.Error || !got.FinishedAt.Equal(res.FinishedAt) {
+			t.Errorf("%v: expect hook %v %+v, got %+v", name, hook, res, got)
+		}
+	}
+}
+
+func TestRecordCodec(t *testing.T) {
+	r := new_test_codec_record()
+
+	buf, err := encode_record(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ver, err := record_schema_version(buf); err != nil || ver != RECORD_SCHEMA_VERSION {
+		t.Errorf("expect schema version %v, got %v, %v", RECORD_SCHEMA_VERSION, ver, err)
+	}
+
+	got, err := decode_record(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check_codec_record(t, "v1", r, got)
+
+	// optional fields are omitted.
+	if buf, err = encode_record(new_test_record("b", 0, 60, "")); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"profile", "audio_only", "pending", "source", "hooks"} {
+		if bytes.Contains(buf, []byte(`"`+key+`"`)) {
+			t.Errorf("expect empty %v omitted, got %s", key, buf)
+		}
+	}
+}
+
+func TestDecodeLegacyRecord(t *testing.T) {
+	r := new_test_codec_record()
+
+	buf, err := yaml.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ver, err := record_schema_version(buf); err != nil || ver != 0 {
+		t.Errorf("expect legacy schema version 0, got %v, %v", ver, err)
+	}
+
+	got, err := decode_record(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check_codec_record(t, "legacy", r, got)
+
+	// records started with `{` are JSON, legacy records are YAML block mappings.
+	if _, err = decode_record([]byte(`{id: a, path: /records/a.mp4}`)); err == nil {
+		t.Errorf("expect error of YAML flow mapping")
+	}
+}
+
+func TestMigrateRecord(t *testing.T) {
+	r := new_test_codec_record()
+
+	legacy, err := yaml.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := migrate_record(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var x struct {
+		Version int `json:"v"`
+	}
+	if err = json.Unmarshal(buf, &x); err != nil || x.Version != RECORD_SCHEMA_VERSION {
+		t.Fatalf("expect migrated record of version %v, got %s, %v", RECORD_SCHEMA_VERSION, buf, err)
+	}
+
+	got, err := decode_record(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check_codec_record(t, "migrated", r, got)
+
+	// current records are not rewritten.
+	if buf, err = migrate_record(buf); err != nil || buf != nil {
+		t.Errorf("expect current record skipped, got %s, %v", buf, err)
+	}
+
+	for _, buf := range [][]byte{
+		[]byte(fmt.Sprintf(`{"v":%d,"id":"a"}`, RECORD_SCHEMA_VERSION+1)),
+		[]byte(`{"v":`),
+	} {
+		if _, err = migrate_record(buf); err == nil {
+			t.Errorf("expect error of %s", buf)
+		}
+	}
+
+	if _, err = decode_record([]byte(fmt.Sprintf(`{"v":%d,"id":"a"}`, RECORD_SCHEMA_VERSION+1))); err == nil || !strings.Contains(err.Error(), ErrUnsupportedSchemaVersion.Error()) {
+		t.Errorf("expect ErrUnsupportedSchemaVersion, got %v", err)
+	}
+}
+
+func TestLeveldbMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtdvr-codec-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "records.ldb")
+	c := record_storage_test_case{
+		name:   "leveldb",
+		option: func(dir string) map[string]interface{} { return map[string]interface{}{"file": file} },
+	}
+
+	// legacy records over a batch, and a record migrated by an interrupted migration.
+	n := RECORD_MIGRATION_BATCH_SIZE + 10
+	db, err := leveldb.OpenFile(file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		r := new_test_record(fmt.Sprintf("r%05d", i), i, i+1, "")
+		var buf []byte
+		if i == 0 {
+			buf, err = encode_record(r)
+		} else {
+			buf, err = yaml.Marshal(r)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = db.Put([]byte("record."+r.Id), buf, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	stor := open_test_record_storage(t, c, dir)
+	rs, err := stor.ListRecords(ListRecordsFitler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != n || rs[n-1].Id != fmt.Sprintf("r%05d", n-1) || !rs[n-1].EndAt.Equal(test_record_at(n)) {
+		t.Errorf("expect %v migrated records, got %v", n, len(rs))
+	}
+	stor.Close()
+
+	if db, err = leveldb.OpenFile(file, nil); err != nil {
+		t.Fatal(err)
+	}
+	iter := db.NewIterator(nil, nil)
+	for iter.Next() {
+		key := string(iter.Key())
+		if strings.HasPrefix(key, "record.") {
+			if ver, err := record_schema_version(iter.Value()); err != nil || ver != RECORD_SCHEMA_VERSION {
+				t.Errorf("%v: expect version %v, got %v, %v", key, RECORD_SCHEMA_VERSION, ver, err)
+			}
+		} else if key == string(leveldb_schema_version_key) && string(iter.Value()) != fmt.Sprint(RECORD_SCHEMA_VERSION) {
+			t.Errorf("expect storage schema version %v, got %s", RECORD_SCHEMA_VERSION, iter.Value())
+		}
+	}
+	iter.Release()
+
+	// storage of newer version is refused.
+	if err = db.Put(leveldb_schema_version_key, format_schema_version(RECORD_SCHEMA_VERSION+1), nil); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if stor, err = NewRecordStorage("leveldb", new_test_record_storage_option(c, dir), "logger", new_test_logger()); err == nil {
+		stor.Close()
+		t.Errorf("expect newer storage refused")
+	}
+}
+
+func TestParseSchemaVersion(t *testing.T) {
+	for _, tc := range []struct {
+		buf    []byte
+		expect int
+		ok     bool
+	}{
+		{nil, 0, true},
+		{[]byte("1"), 1, true},
+		{[]byte("x"), 0, false},
+	} {
+		ver, err := parse_schema_version(tc.buf)
+		if (err == nil) != tc.ok || ver != tc.expect {
+			t.Errorf("%q: expect %v, %v, got %v, %v", tc.buf, tc.expect, tc.ok, ver, err)
+		}
+	}
+
+	if err := check_schema_version(RECORD_SCHEMA_VERSION + 1); err == nil {
+		t.Errorf("expect newer schema version refused")
+	}
+}
@@ -0,0 +1,193 @@
+package digit_video_recorder_driver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cast"
+)
+
+/*
+ * Record processors:
+ *   post-processing of committed records, run by hooks, see ffmpeg_hook.go,
+ *   processors of other packages are registered by RegisterRecordProcessor.
+ * Processor: command
+ *   run external command, record fields of `Record.Data()` are passed as
+ *   environment variables `MTDVR_RECORD_<FIELD>`, like `MTDVR_RECORD_PATH`,
+ *   and as json on stdin, hook failed if command exits with error.
+ *   Options:
+ *     command: [ <binary>, <arg>, ... ]  // argv, no shell is involved.
+ *     [ env: { <name>: <value> } ]  // extra environment variables.
+ * Processor: copy
+ *   copy record file into directory, like a NAS mount, copy is canceled
+ *   between chunks on hook timeout.
+ *   Options:
+ *     dir: <dir>  // destination directory, created if not existing.
+ */
+
+const (
+	RECORD_PROCESSOR_ENV_PREFIX = "MTDVR_RECORD_"
+	// output of failed command kept in hook error.
+	RECORD_PROCESSOR_MAX_OUTPUT = 1024
+)
+
+// RecordProcessor processes committed record, ctx is done on hook timeout,
+// hook is reported as timeout on ctx done without waiting for Process.
+type RecordProcessor interface {
+	Process(ctx context.Context, r *Record) error
+}
+
+type RecordProcessorFactory func(opt *DigitVideoRecorderDriverOption) (RecordProcessor, error)
+
+// RecordProcessorValidator validates hook options of record processor,
+// returns ConfigErrors or InvalidConfigError with keys in hook options,
+// like `dir`, other errors are reported on hook `type`.
+type RecordProcessorValidator func(opt *DigitVideoRecorderDriverOption) error
+
+type record_processor_validator func(v *config_validator, opt *DigitVideoRecorderDriverOption)
+
+var record_processor_factories map[string]RecordProcessorFactory
+var record_processor_validators map[string]record_processor_validator
+var record_processor_factories_once sync.Once
+
+func register_record_processor_factory(name string, fty RecordProcessorFactory, vld record_processor_validator) {
+	record_processor_factories_once.Do(func() {
+		record_processor_factories = make(map[string]RecordProcessorFactory)
+		record_processor_validators = make(map[string]record_processor_validator)
+	})
+	record_processor_factories[name] = fty
+	record_processor_validators[name] = vld
+}
+
+// RegisterRecordProcessor registers record processor of hook type name,
+// called in init of other packages, vld is optional.
+func RegisterRecordProcessor(name string, fty RecordProcessorFactory, vld RecordProcessorValidator) {
+	register_record_processor_factory(name, fty, func(v *config_validator, opt *DigitVideoRecorderDriverOption) {
+		if vld == nil {
+			return
+		}
+
+		switch err := vld(opt).(type) {
+		case nil:
+		case ConfigErrors:
+			for _, e := range err {
+				v.invalid(e.Key, "%v", e.Reason)
+			}
+		case *InvalidConfigError:
+			v.invalid(err.Key, "%v", err.Reason)
+		default:
+			v.invalid("type", "%v", err)
+		}
+	})
+}
+
+func NewRecordProcessor(name string, opt *DigitVideoRecorderDriverOption) (RecordProcessor, error) {
+	fty, ok := record_processor_factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown record processor %v", name)
+	}
+	return fty(opt)
+}
+
+type command_record_processor struct {
+	argv []string
+	env  map[string]string
+}
+
+// tail_output returns the last RECORD_PROCESSOR_MAX_OUTPUT bytes of output.
+func tail_output(buf []byte) string {
+	if len(buf) > RECORD_PROCESSOR_MAX_OUTPUT {
+		buf = buf[len(buf)-RECORD_PROCESSOR_MAX_OUTPUT:]
+	}
+	return strings.TrimSpace(string(buf))
+}
+
+func (p *command_record_processor) Process(ctx context.Context, r *Record) error {
+	data := r.Data()
+
+	stdin, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, p.argv[0], p.argv[1:]...)
+	cmd.Env = os.Environ()
+	for key, val := range data {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%v%v=%v", RECORD_PROCESSOR_ENV_PREFIX, strings.ToUpper(key), val))
+	}
+	for key, val := range p.env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%v=%v", key, val))
+	}
+	cmd.Stdin = bytes.NewReader(stdin)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if s := tail_output(out); s != "" {
+			return fmt.Errorf("%v: %v", err, s)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func NewCommandRecordProcessor(opt *DigitVideoRecorderDriverOption) (RecordProcessor, error) {
+	argv := cast.ToStringSlice(opt.Get("command"))
+	if len(argv) == 0 {
+		return nil, new_invalid_config_error("command")
+	}
+
+	return &command_record_processor{
+		argv: argv,
+		env:  cast.ToStringMapString(opt.Get("env")),
+	}, nil
+}
+
+func validate_command_record_processor_option(v *config_validator, opt *DigitVideoRecorderDriverOption) {
+	if len(cast.ToStringSlice(opt.Get("command"))) == 0 {
+		v.invalid("command", "expect list of command and arguments")
+	}
+}
+
+type copy_record_processor struct {
+	dir string
+}
+
+func (p *copy_record_processor) Process(ctx context.Context, r *Record) error {
+	_, err := export_record_file(ctx, r, p.dir)
+	return err
+}
+
+func NewCopyRecordProcessor(opt *DigitVideoRecorderDriverOption) (RecordProcessor, error) {
+	dir := opt.GetString("dir")
+	if dir == "" {
+		return nil, new_invalid_config_error("dir")
+	}
+
+	return &copy_record_processor{dir: dir}, nil
+}
+
+func validate_copy_record_processor_option(v *config_validator, opt *DigitVideoRecorderDriverOption) {
+	if dir := v.require_string(opt, "dir"); dir != "" {
+		v.writable_dir("dir", existing_parent_dir(dir+string(os.PathSeparator)))
+	}
+}
+
+var register_record_processors_once sync.Once
+
+func init() {
+	register_record_processors_once.Do(func() {
+		register_record_processor_factory("command", NewCommandRecordProcessor, validate_command_record_processor_option)
+		register_record_processor_factory("copy", NewCopyRecordProcessor, validate_copy_record_processor_option)
+	})
+}
@@ -1,14 +1,12 @@
 package digit_video_recorder_driver
 
 import (
+	"encoding/binary"
+	"sort"
+	"sync"
 	"time"
 
-	opt_helper "github.com/nayotta/metathings/pkg/common/option"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/util"
-	"gopkg.in/yaml.v2"
 )
 
 type RecordStorageOption struct {
@@ -20,114 +18,76 @@ type ListRecordsFitler struct {
 		StartAt time.Time
 		EndAt   time.Time
 	}
+	// output profile, all profiles if empty.
+	Profile string
 }
 
-type RecordStorage interface {
-	ListRecords(ListRecordsFitler) ([]*Record, error)
-	GetRecord(id string) (*Record, error)
-	SetRecord(*Record) error
-	UnsetRecord(id string) error
-}
-
-/*
- * Driver: leveldb
- *   leveldb record storage
- * Options:
- *   storage:
- *     name: leveldb
- *     file: <path>  // leveldb file path
- */
-
-type leveldbRecordStorage struct {
-	db     *leveldb.DB
-	opt    *RecordStorageOption
-	logger log.FieldLogger
+// encode_index_time encodes time in bytes sorted by time,
+// sign bit is flipped to sort times before 1970 first.
+func encode_index_time(t time.Time) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(t.UnixNano())^1<<63)
+	return buf
 }
 
-func (s *leveldbRecordStorage) get_logger() log.FieldLogger {
-	return s.logger
+// start_index_key returns key of start time index, encoded start time then id.
+func start_index_key(r *Record) []byte {
+	return append(encode_index_time(r.StartAt), []byte(r.Id)...)
 }
 
-func (s *leveldbRecordStorage) ListRecords(flt ListRecordsFitler) ([]*Record, error) {
-	var rs []*Record
-
-	iter := s.db.NewIterator(util.BytesPrefix([]byte("record.")), nil)
-	for iter.Next() {
-		buf := iter.Value()
-		var r Record
-		if err := yaml.Unmarshal(buf, &r); err != nil {
-			return nil, err
-		}
-		rs = append(rs, &r)
+// match reports whether record overlaps the filter range,
+// zero value of range boundary means unbounded.
+func (flt ListRecordsFitler) match(r *Record) bool {
+	if flt.Profile != "" && flt.Profile != r.GetProfile() {
+		return false
 	}
 
-	return rs, nil
-}
-
-func (s *leveldbRecordStorage) GetRecord(id string) (*Record, error) {
-	buf, err := s.db.Get([]byte("record."+id), nil)
-	if err == leveldb.ErrNotFound {
-		return nil, ErrNotFound
-	} else {
-		return nil, err
+	if !flt.Range.StartAt.IsZero() && !r.EndAt.After(flt.Range.StartAt) {
+		return false
 	}
 
-	var r Record
-	if err = yaml.Unmarshal(buf, &r); err != nil {
-		return nil, err
+	if !flt.Range.EndAt.IsZero() && !r.StartAt.Before(flt.Range.EndAt) {
+		return false
 	}
 
-	return &r, nil
+	return true
 }
 
-func (s *leveldbRecordStorage) SetRecord(r *Record) error {
-	buf, err := yaml.Marshal(r)
-	if err != nil {
-		return err
-	}
-
-	if err = s.db.Put([]byte("record."+r.Id), buf, nil); err != nil {
-		return err
-	}
-
-	return nil
+type RecordStorage interface {
+	ListRecords(ListRecordsFitler) ([]*Record, error)
+	GetRecord(id string) (*Record, error)
+	SetRecord(*Record) error
+	UnsetRecord(id string) error
+	// WalkSnapshot calls fn with every record in a consistent snapshot,
+	// writes during walking are not visible.
+	WalkSnapshot(fn func(*Record) error) error
+	// ReplaceRecords replaces all records atomically.
+	ReplaceRecords([]*Record) error
+	Close() error
 }
 
-func (s *leveldbRecordStorage) UnsetRecord(id string) error {
-	if err := s.db.Delete([]byte("record."+id), nil); err != nil {
-		return err
-	}
-	return nil
+func sort_records(rs []*Record) {
+	sort.SliceStable(rs, func(i, j int) bool {
+		return rs[i].StartAt.Before(rs[j].StartAt)
+	})
 }
 
-func NewRecordStorage(name string, opt *RecordStorageOption, args ...interface{}) (RecordStorage, error) {
-	if name != "leveldb" {
-		return nil, ErrInvalidRecordStorage
-	}
-
-	var logger log.FieldLogger
-	var db *leveldb.DB
-	var err error
+type RecordStorageFactory func(opt *RecordStorageOption, args ...interface{}) (RecordStorage, error)
 
-	if err = opt_helper.Setopt(opt_helper.SetoptConds{
-		"logger": opt_helper.ToLogger(&logger),
-	})(args...); err != nil {
-		return nil, err
-	}
+var record_storage_factories map[string]RecordStorageFactory
+var record_storage_factories_once sync.Once
 
-	if val := opt.GetString("file"); val != "" {
-		if db, err = leveldb.OpenFile(val, nil); err != nil {
-			return nil, err
-		}
-	} else {
-		return nil, new_invalid_config_error("file")
-	}
+func register_record_storage_factory(name string, fty RecordStorageFactory) {
+	record_storage_factories_once.Do(func() {
+		record_storage_factories = make(map[string]RecordStorageFactory)
+	})
+	record_storage_factories[name] = fty
+}
 
-	stor := &leveldbRecordStorage{
-		db:     db,
-		opt:    opt,
-		logger: logger,
+func NewRecordStorage(name string, opt *RecordStorageOption, args ...interface{}) (RecordStorage, error) {
+	fty, ok := record_storage_factories[name]
+	if !ok {
+		return nil, ErrInvalidRecordStorage
 	}
-
-	return stor, nil
+	return fty(opt, args...)
 }
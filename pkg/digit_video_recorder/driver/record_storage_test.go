@@ -0,0 +1,463 @@
+package digit_video_recorder_driver
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	bolt "go.etcd.io/bbolt"
+)
+
+// records of tests start from base time, aligned to timeline buckets.
+var test_record_base_time = time.Date(2020, 1, 2, 3, 0, 0, 0, time.UTC)
+
+func new_test_logger() log.FieldLogger {
+	logger := log.New()
+	logger.SetOutput(ioutil.Discard)
+	return logger
+}
+
+func new_test_record(id string, start, end int, profile string) *Record {
+	return &Record{
+		Id:      id,
+		StartAt: test_record_base_time.Add(time.Duration(start) * time.Second),
+		EndAt:   test_record_base_time.Add(time.Duration(end) * time.Second),
+		Path:    "/records/" + id + ".mp4",
+		Profile: profile,
+	}
+}
+
+func test_record_at(sec int) time.Time {
+	return test_record_base_time.Add(time.Duration(sec) * time.Second)
+}
+
+func record_ids(rs []*Record) []string {
+	ids := []string{}
+	for _, r := range rs {
+		ids = append(ids, r.Id)
+	}
+	return ids
+}
+
+func equal_strings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type record_storage_test_case struct {
+	name string
+	// file backed storages keep records after reopen.
+	persistent bool
+	option     func(dir string) map[string]interface{}
+}
+
+var record_storage_test_cases = []record_storage_test_case{
+	{
+		name:   "memory",
+		option: func(dir string) map[string]interface{} { return map[string]interface{}{} },
+	},
+	{
+		name:       "bbolt",
+		persistent: true,
+		option: func(dir string) map[string]interface{} {
+			return map[string]interface{}{"file": filepath.Join(dir, "data", "records.db")}
+		},
+	},
+	{
+		name:       "leveldb",
+		persistent: true,
+		option: func(dir string) map[string]interface{} {
+			return map[string]interface{}{"file": filepath.Join(dir, "data", "records.ldb")}
+		},
+	},
+}
+
+func new_test_record_storage_option(c record_storage_test_case, dir string) *RecordStorageOption {
+	v := viper.New()
+	for key, val := range c.option(dir) {
+		v.Set(key, val)
+	}
+	return &RecordStorageOption{v}
+}
+
+func open_test_record_storage(t *testing.T, c record_storage_test_case, dir string) RecordStorage {
+	stor, err := NewRecordStorage(c.name, new_test_record_storage_option(c, dir), "logger", new_test_logger())
+	if err != nil {
+		t.Fatalf("failed to open %v storage: %v", c.name, err)
+	}
+
+	return stor
+}
+
+func TestRecordStorageFactoriesCovered(t *testing.T) {
+	names := map[string]bool{}
+	for _, c := range record_storage_test_cases {
+		names[c.name] = true
+	}
+
+	for name := range record_storage_factories {
+		if !names[name] {
+			t.Errorf("record storage %v not covered by conformance suite", name)
+		}
+	}
+}
+
+func TestRecordStorageConformance(t *testing.T) {
+	for _, c := range record_storage_test_cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "mtdvr-storage-test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			// parent directories of storage file are missing before open.
+			if vld, ok := record_storage_validators[c.name]; ok {
+				v := new_config_validator()
+				vld(v, new_test_record_storage_option(c, dir))
+				if err = v.error(); err != nil {
+					t.Fatalf("validate storage option: %v", err)
+				}
+			}
+
+			stor := open_test_record_storage(t, c, dir)
+
+			t.Run("GetSetUnset", func(t *testing.T) { test_record_storage_get_set_unset(t, stor) })
+			t.Run("ListRecords", func(t *testing.T) { test_record_storage_list_records(t, stor) })
+			t.Run("ResetRecord", func(t *testing.T) { test_record_storage_reset_record(t, stor) })
+			t.Run("WalkSnapshot", func(t *testing.T) { test_record_storage_walk_snapshot(t, stor) })
+			t.Run("ReplaceRecords", func(t *testing.T) { test_record_storage_replace_records(t, stor) })
+			t.Run("PreEpochRecord", func(t *testing.T) { test_record_storage_pre_epoch_record(t, stor) })
+			t.Run("HookResults", func(t *testing.T) { test_record_storage_hook_results(t, stor) })
+
+			if err = stor.Close(); err != nil {
+				t.Fatalf("failed to close storage: %v", err)
+			}
+
+			if c.persistent {
+				stor = open_test_record_storage(t, c, dir)
+				defer stor.Close()
+
+				rs, err := stor.ListRecords(ListRecordsFitler{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ids := record_ids(rs); !equal_strings(ids, []string{"x", "y"}) {
+					t.Errorf("records after reopen: expect [x y], got %v", ids)
+				}
+			}
+		})
+	}
+}
+
+func test_record_storage_get_set_unset(t *testing.T, stor RecordStorage) {
+	if _, err := stor.GetRecord("missing"); err != ErrNotFound {
+		t.Fatalf("get missing record: expect ErrNotFound, got %v", err)
+	}
+
+	r := new_test_record("a", 0, 10, "")
+	r.Source = "import"
+	if err := stor.SetRecord(r); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := stor.GetRecord("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Id != r.Id || !got.StartAt.Equal(r.StartAt) || !got.EndAt.Equal(r.EndAt) ||
+		got.Path != r.Path || got.GetProfile() != DEFAULT_OUTPUT_PROFILE || got.Source != r.Source {
+		t.Errorf("get record: expect %+v, got %+v", r, got)
+	}
+
+	if err = stor.UnsetRecord("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stor.GetRecord("a"); err != ErrNotFound {
+		t.Errorf("get unset record: expect ErrNotFound, got %v", err)
+	}
+
+	if err = stor.UnsetRecord("a"); err != nil {
+		t.Errorf("unset missing record: %v", err)
+	}
+}
+
+func test_record_storage_list_records(t *testing.T, stor RecordStorage) {
+	// set out of order, listed by start time.
+	for _, r := range []*Record{
+		new_test_record("c", 20, 30, ""),
+		new_test_record("a", 0, 10, ""),
+		new_test_record("p", 5, 15, "proxy"),
+		new_test_record("b", 10, 20, ""),
+	} {
+		if err := stor.SetRecord(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		start   int
+		end     int
+		profile string
+		expect  []string
+	}{
+		// -1 is zero time, unbounded.
+		{"unbounded", -1, -1, "", []string{"a", "p", "b", "c"}},
+		{"start only", 15, -1, "", []string{"b", "c"}},
+		{"end only", -1, 10, "", []string{"a", "p"}},
+		// records touching range boundaries do not overlap.
+		{"exact range", 10, 20, "", []string{"p", "b"}},
+		{"inside record", 21, 22, "", []string{"c"}},
+		{"after all", 30, 40, "", []string{}},
+		{"profile", -1, -1, "proxy", []string{"p"}},
+		{"default profile", 12, -1, DEFAULT_OUTPUT_PROFILE, []string{"b", "c"}},
+	} {
+		var flt ListRecordsFitler
+		if tc.start >= 0 {
+			flt.Range.StartAt = test_record_at(tc.start)
+		}
+		if tc.end >= 0 {
+			flt.Range.EndAt = test_record_at(tc.end)
+		}
+		flt.Profile = tc.profile
+
+		rs, err := stor.ListRecords(flt)
+		if err != nil {
+			t.Fatalf("%v: %v", tc.name, err)
+		}
+		if ids := record_ids(rs); !equal_strings(ids, tc.expect) {
+			t.Errorf("%v: expect %v, got %v", tc.name, tc.expect, ids)
+		}
+	}
+}
+
+// test_record_storage_reset_record sets an existing record with new times,
+// record should move in start time index, and not be listed twice.
+func test_record_storage_reset_record(t *testing.T, stor RecordStorage) {
+	if err := stor.SetRecord(new_test_record("b", 40, 50, "")); err != nil {
+		t.Fatal(err)
+	}
+
+	rs, err := stor.ListRecords(ListRecordsFitler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := record_ids(rs); !equal_strings(ids, []string{"a", "p", "c", "b"}) {
+		t.Errorf("unbounded: expect [a p c b], got %v", ids)
+	}
+
+	var flt ListRecordsFitler
+	flt.Range.StartAt = test_record_at(35)
+	if rs, err = stor.ListRecords(flt); err != nil {
+		t.Fatal(err)
+	}
+	if ids := record_ids(rs); !equal_strings(ids, []string{"b"}) {
+		t.Errorf("after re-set: expect [b], got %v", ids)
+	}
+
+	flt.Range.StartAt, flt.Range.EndAt = test_record_at(10), test_record_at(20)
+	if rs, err = stor.ListRecords(flt); err != nil {
+		t.Fatal(err)
+	}
+	if ids := record_ids(rs); !equal_strings(ids, []string{"p"}) {
+		t.Errorf("old range of re-set record: expect [p], got %v", ids)
+	}
+}
+
+func test_record_storage_walk_snapshot(t *testing.T, stor RecordStorage) {
+	var ids []string
+	if err := stor.WalkSnapshot(func(r *Record) error {
+		ids = append(ids, r.Id)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(ids)
+	if !equal_strings(ids, []string{"a", "b", "c", "p"}) {
+		t.Errorf("walk snapshot: expect [a b c p], got %v", ids)
+	}
+
+	stop := ErrNotFound
+	n := 0
+	if err := stor.WalkSnapshot(func(r *Record) error {
+		n++
+		return stop
+	}); err != stop || n != 1 {
+		t.Errorf("walk snapshot: expect stopped by callback error, got %v after %v records", err, n)
+	}
+}
+
+func test_record_storage_replace_records(t *testing.T, stor RecordStorage) {
+	if err := stor.ReplaceRecords([]*Record{
+		new_test_record("y", 60, 65, ""),
+		new_test_record("x", 50, 55, ""),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stor.GetRecord("a"); err != ErrNotFound {
+		t.Errorf("get replaced record: expect ErrNotFound, got %v", err)
+	}
+
+	rs, err := stor.ListRecords(ListRecordsFitler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := record_ids(rs); !equal_strings(ids, []string{"x", "y"}) {
+		t.Errorf("list after replace: expect [x y], got %v", ids)
+	}
+
+	var flt ListRecordsFitler
+	flt.Range.StartAt = test_record_at(54)
+	if rs, err = stor.ListRecords(flt); err != nil {
+		t.Fatal(err)
+	}
+	if ids := record_ids(rs); !equal_strings(ids, []string{"x", "y"}) {
+		t.Errorf("list range after replace: expect [x y], got %v", ids)
+	}
+
+	var n int
+	if err = stor.WalkSnapshot(func(r *Record) error {
+		n++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("walk after replace: expect 2 records, got %v", n)
+	}
+}
+
+// test_record_storage_pre_epoch_record sets a record started before 1970,
+// which is listed before others, record is unset after test.
+func test_record_storage_pre_epoch_record(t *testing.T, stor RecordStorage) {
+	epoch := time.Unix(0, 0)
+	r := &Record{Id: "old", StartAt: epoch.Add(-time.Minute), EndAt: epoch.Add(-30 * time.Second), Path: "/records/old.mp4"}
+	if err := stor.SetRecord(r); err != nil {
+		t.Fatal(err)
+	}
+	defer stor.UnsetRecord(r.Id)
+
+	rs, err := stor.ListRecords(ListRecordsFitler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := record_ids(rs); !equal_strings(ids, []string{"old", "x", "y"}) {
+		t.Errorf("unbounded: expect [old x y], got %v", ids)
+	}
+
+	var flt ListRecordsFitler
+	flt.Range.StartAt, flt.Range.EndAt = epoch.Add(-50*time.Second), test_record_at(52)
+	if rs, err = stor.ListRecords(flt); err != nil {
+		t.Fatal(err)
+	}
+	if ids := record_ids(rs); !equal_strings(ids, []string{"old", "x"}) {
+		t.Errorf("range across 1970: expect [old x], got %v", ids)
+	}
+}
+
+// test_record_storage_hook_results checks hook results are stored by
+// SetRecord only, record is unset after test.
+func test_record_storage_hook_results(t *testing.T, stor RecordStorage) {
+	r := new_test_record("h", 70, 75, "")
+	r.Hooks = map[string]*HookResult{"upload": {Status: HOOK_STATUS_OK, FinishedAt: test_record_at(80)}}
+	if err := stor.SetRecord(r); err != nil {
+		t.Fatal(err)
+	}
+	defer stor.UnsetRecord(r.Id)
+
+	// records set or got are not shared with storage.
+	r.Hooks["upload"].Status = HOOK_STATUS_FAILED
+	got, err := stor.GetRecord("h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.Hooks["notify"] = &HookResult{Status: HOOK_STATUS_OK}
+
+	if got, err = stor.GetRecord("h"); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Hooks) != 1 || got.Hooks["upload"].Status != HOOK_STATUS_OK || !got.Hooks["upload"].FinishedAt.Equal(test_record_at(80)) {
+		t.Errorf("expect stored hook results unchanged, got %+v", got.Hooks)
+	}
+}
+
+// TestBboltMigrateStartIndex opens storage of start index version 0,
+// which sorts times before 1970 after others.
+func TestBboltMigrateStartIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtdvr-storage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var c record_storage_test_case
+	for _, c = range record_storage_test_cases {
+		if c.name == "bbolt" {
+			break
+		}
+	}
+	epoch := time.Unix(0, 0)
+	rs := []*Record{
+		{Id: "old", StartAt: epoch.Add(-time.Minute), EndAt: epoch.Add(-30 * time.Second), Path: "/records/old.mp4"},
+		new_test_record("a", 0, 10, ""),
+	}
+
+	stor := open_test_record_storage(t, c, dir)
+	for _, r := range rs {
+		if err = stor.SetRecord(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db := stor.(*bboltRecordStorage).db
+	if err = db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(bbolt_meta_bucket).Delete(bbolt_index_version_key); err != nil {
+			return err
+		}
+		idx := tx.Bucket(bbolt_records_start_bucket)
+		for _, r := range rs {
+			if err := idx.Delete(start_index_key(r)); err != nil {
+				return err
+			}
+			buf := make([]byte, 8)
+			binary.BigEndian.PutUint64(buf, uint64(r.StartAt.UnixNano()))
+			if err := idx.Put(append(buf, r.Id...), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	stor.Close()
+
+	stor = open_test_record_storage(t, c, dir)
+	defer stor.Close()
+
+	var flt ListRecordsFitler
+	flt.Range.StartAt, flt.Range.EndAt = epoch.Add(-50*time.Second), test_record_at(5)
+	got, err := stor.ListRecords(flt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := record_ids(got); !equal_strings(ids, []string{"old", "a"}) {
+		t.Errorf("expect [old a] after index rebuilt, got %v", ids)
+	}
+}
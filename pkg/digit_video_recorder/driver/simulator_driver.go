@@ -0,0 +1,137 @@
+package digit_video_recorder_driver
+
+import (
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+
+	opt_helper "github.com/nayotta/metathings/pkg/common/option"
+)
+
+/*
+ * Driver: simulator
+ *   record synthetic video generated by ffmpeg lavfi source,
+ *   no camera required, segments go through the same path as ffmpeg driver.
+ * Options:
+ *   driver:
+ *     name: simulator
+ *     simulator:
+ *       [ source: <source> ]  // lavfi video source, like `testsrc`, `smptebars`, default `testsrc`.
+ *       [ frame_size: <width>x<height> ]  // frame size, default `640x480`.
+ *       [ frame_rate: <rate> ]  // frame rate, default `25`.
+ *       [ clock: <bool> ]  // burn wall clock into frames, requires ffmpeg with libfreetype, default `true`.
+ *     output:  // same as ffmpeg driver.
+ *     [ outputs: [ ... ] ]  // same as ffmpeg driver.
+ *     video:  // same as ffmpeg driver, `copy` codec is not available.
+ *     storage:  // same as ffmpeg driver.
+ */
+
+const (
+	SIMULATOR_DEFAULT_SOURCE     = "testsrc"
+	SIMULATOR_DEFAULT_FRAME_SIZE = "640x480"
+	SIMULATOR_DEFAULT_FRAME_RATE = "25"
+)
+
+type SimulatorDigitVideoRecorderDriver struct {
+	*FFmpegDigitVideoRecorderDriver
+}
+
+// new_simulator_ffmpeg_option converts simulator options to ffmpeg driver options,
+// input section is replaced by lavfi source.
+func new_simulator_ffmpeg_option(opt *DigitVideoRecorderDriverOption) *DigitVideoRecorderDriverOption {
+	v := viper.New()
+	for key, val := range opt.AllSettings() {
+		v.Set(key, val)
+	}
+
+	v.SetDefault("simulator.source", SIMULATOR_DEFAULT_SOURCE)
+	v.SetDefault("simulator.frame_size", SIMULATOR_DEFAULT_FRAME_SIZE)
+	v.SetDefault("simulator.frame_rate", SIMULATOR_DEFAULT_FRAME_RATE)
+	v.SetDefault("simulator.clock", true)
+
+	src := fmt.Sprintf("%v=size=%v:rate=%v",
+		v.GetString("simulator.source"),
+		v.GetString("simulator.frame_size"),
+		v.GetString("simulator.frame_rate"))
+
+	if v.GetBool("simulator.clock") {
+		src += ",drawtext=text='%{localtime}':x=16:y=16:fontsize=32:fontcolor=white:box=1:boxcolor=black@0.5"
+	}
+
+	v.Set("input", map[string]interface{}{
+		"format":     "lavfi",
+		"file":       src,
+		"frame_size": v.GetString("simulator.frame_size"),
+		"extra":      []string{"-re"},
+	})
+
+	return &DigitVideoRecorderDriverOption{v}
+}
+
+func (drv *SimulatorDigitVideoRecorderDriver) Reconfigure(opt *DigitVideoRecorderDriverOption) error {
+	if err := drv.check_reconfigure_option(opt); err != nil {
+		return err
+	}
+
+	drv.op_mtx.Lock()
+	defer drv.op_mtx.Unlock()
+
+	return drv.reconfigure(new_simulator_ffmpeg_option(opt))
+}
+
+func NewSimulatorDigitVideoRecorderDriver(opt *DigitVideoRecorderDriverOption, args ...interface{}) (DigitVideoRecorderDriver, error) {
+	var logger log.FieldLogger
+
+	opt_helper.Setopt(opt_helper.SetoptConds{
+		"logger": opt_helper.ToLogger(&logger),
+	})(args...)
+
+	ffmpeg_drv, err := NewFFmpegDigitVideoRecorderDriver(new_simulator_ffmpeg_option(opt), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	drv := &SimulatorDigitVideoRecorderDriver{
+		FFmpegDigitVideoRecorderDriver: ffmpeg_drv.(*FFmpegDigitVideoRecorderDriver),
+	}
+
+	logger.Debugf("new simulator digit video recorder")
+
+	return drv, nil
+}
+
+func validate_simulator_digit_video_recorder_driver_option(v *config_validator, opt *DigitVideoRecorderDriverOption) {
+	ffmpeg_opt := new_simulator_ffmpeg_option(opt)
+
+	v.match_string(ffmpeg_opt, "simulator.frame_size", config_frame_size_regexp, "expect <width>x<height>, like `640x480`")
+	v.match_string(ffmpeg_opt, "simulator.frame_rate", config_frame_rate_regexp, "expect number or fraction, like `30` or `30000/1001`")
+
+	if is_audio_mode(ffmpeg_opt) {
+		v.invalid("mode", "audio mode is not available for simulator")
+	}
+
+	if ffmpeg_opt.IsSet("outputs") {
+		if opts, err := get_output_profile_options(ffmpeg_opt); err == nil {
+			for i, p_opt := range opts {
+				if p_opt.GetString("video.codec.name") == "copy" {
+					v.invalid(fmt.Sprintf("outputs.%d.video.codec.name", i), "`copy` codec is not available for simulator")
+				}
+			}
+		}
+	} else if ffmpeg_opt.GetString("video.codec.name") == "copy" {
+		v.invalid("video.codec.name", "`copy` codec is not available for simulator")
+	}
+
+	validate_ffmpeg_digit_video_recorder_driver_option(v, ffmpeg_opt)
+}
+
+var register_simulator_digit_video_recorder_driver_once sync.Once
+
+func init() {
+	register_simulator_digit_video_recorder_driver_once.Do(func() {
+		register_digit_video_recorder_driver_factory("simulator", NewSimulatorDigitVideoRecorderDriver)
+		register_digit_video_recorder_driver_validator("simulator", validate_simulator_digit_video_recorder_driver_option)
+	})
+}
@@ -0,0 +1,245 @@
+package digit_video_recorder_driver
+
+import (
+	"time"
+)
+
+const (
+	TIMELINE_MAX_BUCKETS = 10000
+
+	// previous and next records are looked up in windows around timestamp,
+	// from the first window, doubled until found, unbounded after the max window.
+	FIND_RECORD_FIRST_WINDOW = time.Hour
+	FIND_RECORD_MAX_WINDOW   = 366 * 24 * time.Hour
+)
+
+type TimelineSpan struct {
+	StartAt time.Time
+	EndAt   time.Time
+}
+
+// TimelineBucket is a fixed size slot of timeline, aligned to bucket size,
+// Recorded is recorded duration inside the slot.
+type TimelineBucket struct {
+	TimelineSpan
+	Recorded time.Duration
+}
+
+type Timeline struct {
+	TimelineSpan
+	Spans   []TimelineSpan
+	Gaps    []TimelineSpan
+	Buckets []TimelineBucket
+}
+
+type TimelineOption struct {
+	StartAt time.Time
+	EndAt   time.Time
+	// records closer than tolerance are merged into one span.
+	Tolerance time.Duration
+	// bucket size, no buckets if zero.
+	Bucket time.Duration
+}
+
+// merge_timeline_spans merges records sorted by start time into spans,
+// clipped by [start_at, end_at).
+func merge_timeline_spans(rs []*Record, start_at, end_at time.Time, tolerance time.Duration) []TimelineSpan {
+	var spans []TimelineSpan
+
+	for _, r := range rs {
+		s, e := r.StartAt, r.EndAt
+		if s.Before(start_at) {
+			s = start_at
+		}
+		if e.After(end_at) {
+			e = end_at
+		}
+		if !e.After(s) {
+			continue
+		}
+
+		if n := len(spans); n > 0 && !s.After(spans[n-1].EndAt.Add(tolerance)) {
+			if e.After(spans[n-1].EndAt) {
+				spans[n-1].EndAt = e
+			}
+			continue
+		}
+
+		spans = append(spans, TimelineSpan{StartAt: s, EndAt: e})
+	}
+
+	return spans
+}
+
+func timeline_gaps(spans []TimelineSpan, start_at, end_at time.Time) []TimelineSpan {
+	var gaps []TimelineSpan
+
+	cur := start_at
+	for _, sp := range spans {
+		if sp.StartAt.After(cur) {
+			gaps = append(gaps, TimelineSpan{StartAt: cur, EndAt: sp.StartAt})
+		}
+		cur = sp.EndAt
+	}
+
+	if end_at.After(cur) {
+		gaps = append(gaps, TimelineSpan{StartAt: cur, EndAt: end_at})
+	}
+
+	return gaps
+}
+
+func timeline_buckets(spans []TimelineSpan, start_at, end_at time.Time, size time.Duration) []TimelineBucket {
+	var buckets []TimelineBucket
+
+	i := 0
+	for s := start_at.Truncate(size); s.Before(end_at); s = s.Add(size) {
+		b := TimelineBucket{TimelineSpan: TimelineSpan{StartAt: s, EndAt: s.Add(size)}}
+
+		// skip spans ended before bucket, spans are sorted and disjoint.
+		for i < len(spans) && !spans[i].EndAt.After(b.StartAt) {
+			i++
+		}
+
+		for j := i; j < len(spans) && spans[j].StartAt.Before(b.EndAt); j++ {
+			ss, se := spans[j].StartAt, spans[j].EndAt
+			if ss.Before(b.StartAt) {
+				ss = b.StartAt
+			}
+			if se.After(b.EndAt) {
+				se = b.EndAt
+			}
+			b.Recorded += se.Sub(ss)
+		}
+
+		buckets = append(buckets, b)
+	}
+
+	return buckets
+}
+
+// BuildTimeline merges records into recorded spans and gaps in range,
+// records should be sorted by start time, like results of ListRecords.
+func BuildTimeline(rs []*Record, opt TimelineOption) (*Timeline, error) {
+	if opt.StartAt.IsZero() || opt.EndAt.IsZero() || !opt.EndAt.After(opt.StartAt) || opt.Tolerance < 0 || opt.Bucket < 0 {
+		return nil, ErrInvalidTimelineRange
+	}
+
+	// buckets are aligned to bucket size, the last one may be partial.
+	if opt.Bucket > 0 && (opt.EndAt.Sub(opt.StartAt.Truncate(opt.Bucket))+opt.Bucket-1)/opt.Bucket > TIMELINE_MAX_BUCKETS {
+		return nil, ErrTooManyTimelineBuckets
+	}
+
+	tl := &Timeline{TimelineSpan: TimelineSpan{StartAt: opt.StartAt, EndAt: opt.EndAt}}
+	tl.Spans = merge_timeline_spans(rs, opt.StartAt, opt.EndAt, opt.Tolerance)
+	tl.Gaps = timeline_gaps(tl.Spans, opt.StartAt, opt.EndAt)
+
+	if opt.Bucket > 0 {
+		tl.Buckets = timeline_buckets(tl.Spans, opt.StartAt, opt.EndAt, opt.Bucket)
+	}
+
+	return tl, nil
+}
+
+type RecordLister interface {
+	ListRecords(ListRecordsFitler) ([]*Record, error)
+}
+
+// RecordLocation is result of FindRecordAt, Record and Offset are set if
+// timestamp is covered by a record, else Previous and Next are nearest
+// records around the gap, nil if none.
+type RecordLocation struct {
+	Record   *Record
+	Offset   time.Duration
+	Previous *Record
+	Next     *Record
+}
+
+// FindRecordAt finds record of output profile covering timestamp,
+// all profiles if profile is empty, returns ErrNotFound if no records
+// at all around timestamp.
+func FindRecordAt(lister RecordLister, ts time.Time, profile string) (*RecordLocation, error) {
+	flt := ListRecordsFitler{Profile: profile}
+	flt.Range.StartAt = ts
+	flt.Range.EndAt = ts.Add(time.Nanosecond)
+	rs, err := lister.ListRecords(flt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rs) > 0 {
+		// latest started record wins if records overlapped.
+		r := rs[len(rs)-1]
+		return &RecordLocation{Record: r, Offset: ts.Sub(r.StartAt)}, nil
+	}
+
+	loc := &RecordLocation{}
+
+	if loc.Previous, err = find_previous_record(lister, ts, profile); err != nil {
+		return nil, err
+	}
+
+	if loc.Next, err = find_next_record(lister, ts, profile); err != nil {
+		return nil, err
+	}
+
+	if loc.Previous == nil && loc.Next == nil {
+		return nil, ErrNotFound
+	}
+
+	return loc, nil
+}
+
+// find_previous_record returns record ended last before timestamp, nil if none,
+// records ended in a window are always later than records out of the window,
+// so only records around timestamp are listed.
+func find_previous_record(lister RecordLister, ts time.Time, profile string) (*Record, error) {
+	for w := FIND_RECORD_FIRST_WINDOW; ; w *= 2 {
+		flt := ListRecordsFitler{Profile: profile}
+		flt.Range.EndAt = ts
+		if w <= FIND_RECORD_MAX_WINDOW {
+			flt.Range.StartAt = ts.Add(-w)
+		}
+
+		rs, err := lister.ListRecords(flt)
+		if err != nil {
+			return nil, err
+		}
+
+		var prev *Record
+		for _, r := range rs {
+			if prev == nil || r.EndAt.After(prev.EndAt) {
+				prev = r
+			}
+		}
+
+		if prev != nil || flt.Range.StartAt.IsZero() {
+			return prev, nil
+		}
+	}
+}
+
+// find_next_record returns record started first after timestamp, nil if none,
+// like find_previous_record.
+func find_next_record(lister RecordLister, ts time.Time, profile string) (*Record, error) {
+	for w := FIND_RECORD_FIRST_WINDOW; ; w *= 2 {
+		flt := ListRecordsFitler{Profile: profile}
+		flt.Range.StartAt = ts
+		if w <= FIND_RECORD_MAX_WINDOW {
+			flt.Range.EndAt = ts.Add(w)
+		}
+
+		rs, err := lister.ListRecords(flt)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(rs) > 0 {
+			return rs[0], nil
+		}
+
+		if flt.Range.EndAt.IsZero() {
+			return nil, nil
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package digit_video_recorder_driver
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// format_timeline_spans formats spans as `<start>-<end>` in seconds from base time.
+func format_timeline_spans(spans []TimelineSpan) string {
+	s := ""
+	for _, sp := range spans {
+		s += fmt.Sprintf("%d-%d ", int(sp.StartAt.Sub(test_record_base_time)/time.Second), int(sp.EndAt.Sub(test_record_base_time)/time.Second))
+	}
+	return s
+}
+
+func TestBuildTimeline(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		records   [][2]int
+		start     int
+		end       int
+		tolerance time.Duration
+		spans     string
+		gaps      string
+	}{
+		{"no records", nil, 0, 60, 0, "", "0-60 "},
+		{"adjacent", [][2]int{{0, 10}, {10, 20}}, 0, 20, 0, "0-20 ", ""},
+		{"overlapping", [][2]int{{0, 15}, {10, 20}}, 0, 20, 0, "0-20 ", ""},
+		{"contained", [][2]int{{0, 20}, {5, 10}, {15, 30}}, 0, 30, 0, "0-30 ", ""},
+		{"gapped", [][2]int{{0, 10}, {15, 20}}, 0, 20, 0, "0-10 15-20 ", "10-15 "},
+		{"gap in tolerance", [][2]int{{0, 10}, {15, 20}}, 0, 20, 5 * time.Second, "0-20 ", ""},
+		{"gap over tolerance", [][2]int{{0, 10}, {15, 20}}, 0, 20, 4 * time.Second, "0-10 15-20 ", "10-15 "},
+		{"leading and trailing gaps", [][2]int{{10, 20}}, 0, 30, 0, "10-20 ", "0-10 20-30 "},
+		{"clipped by range", [][2]int{{0, 10}, {15, 25}}, 5, 20, 0, "5-10 15-20 ", "10-15 "},
+		{"out of range", [][2]int{{0, 10}, {40, 50}}, 10, 40, 0, "", "10-40 "},
+		{"empty record", [][2]int{{5, 5}, {10, 20}}, 0, 20, 0, "10-20 ", "0-10 "},
+	} {
+		var rs []*Record
+		for i, r := range tc.records {
+			rs = append(rs, new_test_record(fmt.Sprint(i), r[0], r[1], ""))
+		}
+
+		tl, err := BuildTimeline(rs, TimelineOption{
+			StartAt:   test_record_at(tc.start),
+			EndAt:     test_record_at(tc.end),
+			Tolerance: tc.tolerance,
+		})
+		if err != nil {
+			t.Errorf("%v: %v", tc.name, err)
+			continue
+		}
+
+		if val := format_timeline_spans(tl.Spans); val != tc.spans {
+			t.Errorf("%v: expect spans %q, got %q", tc.name, tc.spans, val)
+		}
+
+		if val := format_timeline_spans(tl.Gaps); val != tc.gaps {
+			t.Errorf("%v: expect gaps %q, got %q", tc.name, tc.gaps, val)
+		}
+
+		if tl.Buckets != nil {
+			t.Errorf("%v: expect no buckets without bucket size, got %v", tc.name, len(tl.Buckets))
+		}
+	}
+}
+
+func TestBuildTimelineBuckets(t *testing.T) {
+	rs := []*Record{
+		new_test_record("a", 5, 15, ""),
+		new_test_record("b", 25, 27, ""),
+		new_test_record("c", 30, 40, ""),
+	}
+
+	for _, tc := range []struct {
+		name     string
+		start    int
+		end      int
+		buckets  string
+		recorded []int
+	}{
+		{"aligned", 0, 40, "0-10 10-20 20-30 30-40 ", []int{5, 5, 2, 10}},
+		// buckets are aligned to bucket size, records are clipped by range.
+		{"unaligned start", 7, 40, "0-10 10-20 20-30 30-40 ", []int{3, 5, 2, 10}},
+		{"unaligned end", 0, 26, "0-10 10-20 20-30 ", []int{5, 5, 1}},
+		// span ended at bucket start is not in bucket.
+		{"span ends at boundary", 15, 20, "10-20 ", []int{0}},
+	} {
+		tl, err := BuildTimeline(rs, TimelineOption{
+			StartAt: test_record_at(tc.start),
+			EndAt:   test_record_at(tc.end),
+			Bucket:  10 * time.Second,
+		})
+		if err != nil {
+			t.Errorf("%v: %v", tc.name, err)
+			continue
+		}
+
+		var spans []TimelineSpan
+		var recorded []int
+		for _, b := range tl.Buckets {
+			spans = append(spans, b.TimelineSpan)
+			recorded = append(recorded, int(b.Recorded/time.Second))
+		}
+
+		if val := format_timeline_spans(spans); val != tc.buckets {
+			t.Errorf("%v: expect buckets %q, got %q", tc.name, tc.buckets, val)
+		}
+
+		if fmt.Sprint(recorded) != fmt.Sprint(tc.recorded) {
+			t.Errorf("%v: expect recorded %v, got %v", tc.name, tc.recorded, recorded)
+		}
+	}
+}
+
+func TestBuildTimelineMaxBuckets(t *testing.T) {
+	start := test_record_at(0)
+
+	tl, err := BuildTimeline(nil, TimelineOption{
+		StartAt: start,
+		EndAt:   start.Add(TIMELINE_MAX_BUCKETS * time.Second),
+		Bucket:  time.Second,
+	})
+	if err != nil {
+		t.Fatalf("expect %v buckets accepted, got %v", TIMELINE_MAX_BUCKETS, err)
+	}
+	if len(tl.Buckets) != TIMELINE_MAX_BUCKETS {
+		t.Errorf("expect %v buckets, got %v", TIMELINE_MAX_BUCKETS, len(tl.Buckets))
+	}
+
+	for _, opt := range []TimelineOption{
+		{StartAt: start, EndAt: start.Add(TIMELINE_MAX_BUCKETS*time.Second + time.Nanosecond), Bucket: time.Second},
+		// unaligned start adds a bucket.
+		{StartAt: start.Add(time.Millisecond), EndAt: start.Add(TIMELINE_MAX_BUCKETS*time.Second + time.Millisecond), Bucket: time.Second},
+	} {
+		if _, err = BuildTimeline(nil, opt); err != ErrTooManyTimelineBuckets {
+			t.Errorf("%v-%v: expect ErrTooManyTimelineBuckets, got %v", opt.StartAt, opt.EndAt, err)
+		}
+	}
+}
+
+func TestBuildTimelineInvalidRange(t *testing.T) {
+	start := test_record_at(0)
+
+	for _, opt := range []TimelineOption{
+		{EndAt: start},
+		{StartAt: start},
+		{StartAt: start, EndAt: start},
+		{StartAt: start.Add(time.Second), EndAt: start},
+		{StartAt: start, EndAt: start.Add(time.Second), Tolerance: -time.Second},
+		{StartAt: start, EndAt: start.Add(time.Second), Bucket: -time.Second},
+	} {
+		if _, err := BuildTimeline(nil, opt); err != ErrInvalidTimelineRange {
+			t.Errorf("%+v: expect ErrInvalidTimelineRange, got %v", opt, err)
+		}
+	}
+}
+
+// counting_record_lister records filters of ListRecords.
+type counting_record_lister struct {
+	RecordLister
+	flts []ListRecordsFitler
+}
+
+func (l *counting_record_lister) ListRecords(flt ListRecordsFitler) ([]*Record, error) {
+	l.flts = append(l.flts, flt)
+	return l.RecordLister.ListRecords(flt)
+}
+
+func record_id(r *Record) string {
+	if r == nil {
+		return ""
+	}
+	return r.Id
+}
+
+func TestFindRecordAt(t *testing.T) {
+	const day = 24 * 60 * 60
+
+	lister := &counting_record_lister{RecordLister: new_test_memory_record_storage(t,
+		new_test_record("old", -800*day, -800*day+60, ""),
+		new_test_record("a", 0, 60, ""),
+		// long record overlapped by b.
+		new_test_record("long", 30, 3*60*60, ""),
+		new_test_record("b", 120, 180, ""),
+		new_test_record("c", 4*60*60, 4*60*60+60, ""),
+	)}
+
+	for _, tc := range []struct {
+		name     string
+		ts       int
+		record   string
+		offset   int
+		previous string
+		next     string
+	}{
+		{"start of record", 0, "a", 0, "", ""},
+		{"inside record", 10, "a", 10, "", ""},
+		// latest started record wins.
+		{"overlapped", 150, "b", 30, "", ""},
+		{"end of record", 3 * 60 * 60, "", 0, "long", "c"},
+		{"gap", 3*60*60 + 10, "", 0, "long", "c"},
+		{"after all", 5 * 60 * 60, "", 0, "c", ""},
+		{"before all but old", -1, "", 0, "old", "a"},
+		{"before all", -900 * day, "", 0, "", "old"},
+		{"after old", -800*day + 120, "", 0, "old", "a"},
+	} {
+		loc, err := FindRecordAt(lister, test_record_at(tc.ts), "")
+		if err != nil {
+			t.Errorf("%v: %v", tc.name, err)
+			continue
+		}
+
+		if id := record_id(loc.Record); id != tc.record {
+			t.Errorf("%v: expect record %q, got %q", tc.name, tc.record, id)
+		}
+		if loc.Record != nil && loc.Offset != time.Duration(tc.offset)*time.Second {
+			t.Errorf("%v: expect offset %vs, got %v", tc.name, tc.offset, loc.Offset)
+		}
+		if id := record_id(loc.Previous); id != tc.previous {
+			t.Errorf("%v: expect previous %q, got %q", tc.name, tc.previous, id)
+		}
+		if id := record_id(loc.Next); id != tc.next {
+			t.Errorf("%v: expect next %q, got %q", tc.name, tc.next, id)
+		}
+	}
+
+	if _, err := FindRecordAt(lister, test_record_at(10), "proxy"); err != ErrNotFound {
+		t.Errorf("other profile: expect ErrNotFound, got %v", err)
+	}
+
+	// records in the first windows are found without listing other records.
+	lister.flts = nil
+	if _, err := FindRecordAt(lister, test_record_at(3*60*60+10), ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, flt := range lister.flts {
+		if flt.Range.StartAt.IsZero() || flt.Range.EndAt.IsZero() ||
+			flt.Range.StartAt.Before(test_record_at(2*60*60)) || flt.Range.EndAt.After(test_record_at(5*60*60)) {
+			t.Errorf("expect lookup bounded near timestamp, got range %v-%v", flt.Range.StartAt, flt.Range.EndAt)
+		}
+	}
+}
@@ -2,12 +2,16 @@ package digit_video_recorder_service
 
 import (
 	"context"
+	"errors"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/golang/protobuf/ptypes/empty"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -21,12 +25,37 @@ import (
 type DigitVideoRecorderService struct {
 	module *component.Module
 	drv    driver.DigitVideoRecorderDriver
+	// serializes Reconfigure and SetPrivacyMasks, which read, change
+	// and write driver options.
+	reconfigure_mtx sync.Mutex
+	drv_opt_mtx     sync.RWMutex
+	drv_opt         *driver.DigitVideoRecorderDriverOption
+	metrics         *digitVideoRecorderMetrics
+	playback        *playbackServer
+	// signals state changed by driver itself, like watchdog.
+	state_ch chan struct{}
 }
 
 func (s *DigitVideoRecorderService) logger() log.FieldLogger {
 	return s.module.Logger()
 }
 
+// get_drv_opt returns current driver options, read by http servers
+// and metrics concurrently with Reconfigure.
+func (s *DigitVideoRecorderService) get_drv_opt() *driver.DigitVideoRecorderDriverOption {
+	s.drv_opt_mtx.RLock()
+	defer s.drv_opt_mtx.RUnlock()
+
+	return s.drv_opt
+}
+
+func (s *DigitVideoRecorderService) set_drv_opt(opt *driver.DigitVideoRecorderDriverOption) {
+	s.drv_opt_mtx.Lock()
+	defer s.drv_opt_mtx.Unlock()
+
+	s.drv_opt = opt
+}
+
 func (s *DigitVideoRecorderService) update_state() error {
 	if err := s.module.PutObject("state", strings.NewReader(s.drv.State().String())); err != nil {
 		s.logger().WithError(err).Errorf("failed to set digit video recorder state")
@@ -36,12 +65,40 @@ func (s *DigitVideoRecorderService) update_state() error {
 	return nil
 }
 
+// handle_state_changed refreshes module state when driver state changed
+// without service, like restarted by watchdog, state is put in state loop,
+// event handlers are called with driver locked.
+func (s *DigitVideoRecorderService) handle_state_changed(evt *driver.Event) {
+	if evt.Type != driver.EVENT_STATE_CHANGED {
+		return
+	}
+
+	select {
+	case s.state_ch <- struct{}{}:
+	default:
+	}
+}
+
+func (s *DigitVideoRecorderService) state_loop() {
+	for range s.state_ch {
+		s.update_state()
+	}
+}
+
+func (s *DigitVideoRecorderService) init_state_loop() {
+	s.state_ch = make(chan struct{}, 1)
+	s.drv.OnEvent(s.handle_state_changed)
+	go s.state_loop()
+}
+
 func (s *DigitVideoRecorderService) reset() {
 	s.drv.Stop()
 	s.update_state()
 }
 
 func (s *DigitVideoRecorderService) HANDLE_GRPC_Start(ctx context.Context, in *any.Any) (*any.Any, error) {
+	defer s.metrics.observe_rpc("Start", time.Now())
+
 	var err error
 	req := &empty.Empty{}
 
@@ -80,6 +137,8 @@ func (s *DigitVideoRecorderService) Start(ctx context.Context, _ *empty.Empty) (
 }
 
 func (s *DigitVideoRecorderService) HANDLE_GRPC_Stop(ctx context.Context, in *any.Any) (*any.Any, error) {
+	defer s.metrics.observe_rpc("Stop", time.Now())
+
 	var err error
 	req := &empty.Empty{}
 
@@ -118,6 +177,8 @@ func (s *DigitVideoRecorderService) Stop(ctx context.Context, _ *empty.Empty) (*
 }
 
 func (s *DigitVideoRecorderService) HANDLE_GRPC_GetRecord(ctx context.Context, in *any.Any) (*any.Any, error) {
+	defer s.metrics.observe_rpc("GetRecord", time.Now())
+
 	var err error
 	req := &pb.GetRecordRequest{}
 
@@ -154,6 +215,8 @@ func (s *DigitVideoRecorderService) GetRecord(ctx context.Context, req *pb.GetRe
 }
 
 func (s *DigitVideoRecorderService) HANDLE_GRPC_ListRecords(ctx context.Context, in *any.Any) (*any.Any, error) {
+	defer s.metrics.observe_rpc("ListRecords", time.Now())
+
 	var err error
 	req := &pb.ListRecordsRequest{}
 
@@ -194,6 +257,8 @@ func (s *DigitVideoRecorderService) ListRecords(ctx context.Context, req *pb.Lis
 		}
 	}
 
+	flt.Profile = req.GetProfile().GetValue()
+
 	rs, err := s.drv.ListRecords(flt)
 	if err != nil {
 		s.module.Logger().WithError(err).Debugf("failed to list records")
@@ -209,16 +274,689 @@ func (s *DigitVideoRecorderService) ListRecords(ctx context.Context, req *pb.Lis
 	return res, nil
 }
 
+func (s *DigitVideoRecorderService) HANDLE_GRPC_GetTimeline(ctx context.Context, in *any.Any) (*any.Any, error) {
+	defer s.metrics.observe_rpc("GetTimeline", time.Now())
+
+	var err error
+	req := &pb.GetTimelineRequest{}
+
+	if err = ptypes.UnmarshalAny(in, req); err != nil {
+		return nil, err
+	}
+
+	res, err := s.GetTimeline(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ptypes.MarshalAny(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+var timeline_bucket_sizes = map[pb.GetTimelineRequestBucket_]time.Duration{
+	pb.GetTimelineRequest_NONE:   0,
+	pb.GetTimelineRequest_MINUTE: time.Minute,
+	pb.GetTimelineRequest_HOUR:   time.Hour,
+}
+
+func (s *DigitVideoRecorderService) GetTimeline(ctx context.Context, req *pb.GetTimelineRequest) (*pb.GetTimelineResponse, error) {
+	var opt driver.TimelineOption
+	var ok bool
+	var err error
+
+	if opt.StartAt, err = ptypes.Timestamp(req.GetStartAt()); err != nil {
+		s.module.Logger().WithError(err).Debugf("failed to get start_at field")
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if opt.EndAt, err = ptypes.Timestamp(req.GetEndAt()); err != nil {
+		s.module.Logger().WithError(err).Debugf("failed to get end_at field")
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if req_tolerance := req.GetTolerance(); req_tolerance != nil {
+		if opt.Tolerance, err = ptypes.Duration(req_tolerance); err != nil {
+			s.module.Logger().WithError(err).Debugf("failed to get tolerance field")
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	if opt.Bucket, ok = timeline_bucket_sizes[req.GetBucket()]; !ok {
+		err = errors.New("unknown bucket")
+		s.module.Logger().WithError(err).Debugf("failed to get bucket field")
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	flt := driver.ListRecordsFitler{Profile: req.GetProfile().GetValue()}
+	flt.Range.StartAt = opt.StartAt
+	flt.Range.EndAt = opt.EndAt
+	rs, err := s.drv.ListRecords(flt)
+	if err != nil {
+		s.module.Logger().WithError(err).Debugf("failed to list records")
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	tl, err := driver.BuildTimeline(rs, opt)
+	if err != nil {
+		s.module.Logger().WithError(err).Debugf("failed to build timeline")
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	res := &pb.GetTimelineResponse{
+		Spans:   copy_timeline_spans(tl.Spans),
+		Gaps:    copy_timeline_spans(tl.Gaps),
+		Buckets: copy_timeline_buckets(tl.Buckets),
+	}
+
+	s.module.Logger().Debugf("get timeline")
+
+	return res, nil
+}
+
+func (s *DigitVideoRecorderService) HANDLE_GRPC_FindRecordAt(ctx context.Context, in *any.Any) (*any.Any, error) {
+	defer s.metrics.observe_rpc("FindRecordAt", time.Now())
+
+	var err error
+	req := &pb.FindRecordAtRequest{}
+
+	if err = ptypes.UnmarshalAny(in, req); err != nil {
+		return nil, err
+	}
+
+	res, err := s.FindRecordAt(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ptypes.MarshalAny(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (s *DigitVideoRecorderService) FindRecordAt(ctx context.Context, req *pb.FindRecordAtRequest) (*pb.FindRecordAtResponse, error) {
+	ts, err := ptypes.Timestamp(req.GetTimestamp())
+	if err != nil {
+		s.module.Logger().WithError(err).Debugf("failed to get timestamp field")
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	loc, err := driver.FindRecordAt(s.drv, ts, req.GetProfile().GetValue())
+	if err != nil {
+		s.module.Logger().WithError(err).Debugf("failed to find record")
+		if err == driver.ErrNotFound {
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	res := &pb.FindRecordAtResponse{}
+	if loc.Record != nil {
+		res.Record = copy_record(loc.Record)
+		res.Offset = ptypes.DurationProto(loc.Offset)
+	}
+	if loc.Previous != nil {
+		res.Previous = copy_record(loc.Previous)
+	}
+	if loc.Next != nil {
+		res.Next = copy_record(loc.Next)
+	}
+
+	s.module.Logger().Debugf("find record at")
+
+	return res, nil
+}
+
+func (s *DigitVideoRecorderService) HANDLE_GRPC_ImportRecords(ctx context.Context, in *any.Any) (*any.Any, error) {
+	defer s.metrics.observe_rpc("ImportRecords", time.Now())
+
+	var err error
+	req := &pb.ImportRecordsRequest{}
+
+	if err = ptypes.UnmarshalAny(in, req); err != nil {
+		return nil, err
+	}
+
+	res, err := s.ImportRecords(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ptypes.MarshalAny(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+var import_records_modes = map[pb.ImportRecordsRequestMode_]string{
+	pb.ImportRecordsRequest_KEEP: driver.IMPORT_MODE_KEEP,
+	pb.ImportRecordsRequest_COPY: driver.IMPORT_MODE_COPY,
+	pb.ImportRecordsRequest_MOVE: driver.IMPORT_MODE_MOVE,
+}
+
+func (s *DigitVideoRecorderService) ImportRecords(ctx context.Context, req *pb.ImportRecordsRequest) (*pb.ImportRecordsResponse, error) {
+	var err error
+
+	if len(req.GetPaths()) == 0 {
+		err = errors.New("paths required")
+		s.module.Logger().WithError(err).Debugf("failed to import records")
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	mode, ok := import_records_modes[req.GetMode()]
+	if !ok {
+		err = errors.New("unknown mode")
+		s.module.Logger().WithError(err).Debugf("failed to get mode field")
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	ret, err := s.drv.ImportRecords(&driver.ImportRecordsOption{
+		Paths:      req.GetPaths(),
+		Mode:       mode,
+		Profile:    req.GetProfile().GetValue(),
+		TimeLayout: req.GetTimeLayout().GetValue(),
+	})
+	if err != nil {
+		s.module.Logger().WithError(err).Debugf("failed to import records")
+		if _, ok := err.(*driver.InvalidImportPathError); ok {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	s.module.Logger().Debugf("import records")
+
+	return copy_import_records_result(ret), nil
+}
+
+func (s *DigitVideoRecorderService) HANDLE_GRPC_ReindexRecords(ctx context.Context, in *any.Any) (*any.Any, error) {
+	defer s.metrics.observe_rpc("ReindexRecords", time.Now())
+
+	var err error
+	req := &empty.Empty{}
+
+	if err = ptypes.UnmarshalAny(in, req); err != nil {
+		return nil, err
+	}
+
+	res, err := s.ReindexRecords(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ptypes.MarshalAny(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (s *DigitVideoRecorderService) ReindexRecords(ctx context.Context, req *empty.Empty) (*pb.ReindexRecordsResponse, error) {
+	ret, err := s.drv.ReindexRecords()
+	if err != nil {
+		s.module.Logger().WithError(err).Debugf("failed to reindex records")
+		if err == driver.ErrRelativeOutputFile {
+			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	s.module.Logger().Debugf("reindex records")
+
+	return copy_reindex_records_result(ret), nil
+}
+
+func (s *DigitVideoRecorderService) HANDLE_GRPC_BackupIndex(ctx context.Context, in *any.Any) (*any.Any, error) {
+	defer s.metrics.observe_rpc("BackupIndex", time.Now())
+
+	var err error
+	req := &pb.BackupIndexRequest{}
+
+	if err = ptypes.UnmarshalAny(in, req); err != nil {
+		return nil, err
+	}
+
+	res, err := s.BackupIndex(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ptypes.MarshalAny(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (s *DigitVideoRecorderService) BackupIndex(ctx context.Context, req *pb.BackupIndexRequest) (*pb.BackupIndexResponse, error) {
+	var err error
+
+	path := req.GetPath().GetValue()
+	if path == "" {
+		err = errors.New("path required")
+		s.module.Logger().WithError(err).Debugf("failed to backup index")
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	bak, err := s.drv.BackupIndex(path)
+	if err != nil {
+		s.module.Logger().WithError(err).Debugf("failed to backup index")
+		return nil, status.Errorf(index_backup_error_code(err), err.Error())
+	}
+
+	s.module.Logger().Debugf("backup index")
+
+	return &pb.BackupIndexResponse{Backup: copy_index_backup(bak)}, nil
+}
+
+func (s *DigitVideoRecorderService) HANDLE_GRPC_RestoreIndex(ctx context.Context, in *any.Any) (*any.Any, error) {
+	defer s.metrics.observe_rpc("RestoreIndex", time.Now())
+
+	var err error
+	req := &pb.RestoreIndexRequest{}
+
+	if err = ptypes.UnmarshalAny(in, req); err != nil {
+		return nil, err
+	}
+
+	res, err := s.RestoreIndex(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ptypes.MarshalAny(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (s *DigitVideoRecorderService) RestoreIndex(ctx context.Context, req *pb.RestoreIndexRequest) (*pb.RestoreIndexResponse, error) {
+	var err error
+
+	path := req.GetPath().GetValue()
+	if path == "" {
+		err = errors.New("path required")
+		s.module.Logger().WithError(err).Debugf("failed to restore index")
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	bak, err := s.drv.RestoreIndex(path)
+	if err != nil {
+		s.module.Logger().WithError(err).Debugf("failed to restore index")
+		return nil, status.Errorf(index_backup_error_code(err), err.Error())
+	}
+
+	s.module.Logger().Debugf("restore index")
+
+	return &pb.RestoreIndexResponse{Backup: copy_index_backup(bak)}, nil
+}
+
+func (s *DigitVideoRecorderService) HANDLE_GRPC_SetPrivacyMasks(ctx context.Context, in *any.Any) (*any.Any, error) {
+	defer s.metrics.observe_rpc("SetPrivacyMasks", time.Now())
+
+	var err error
+	req := &pb.SetPrivacyMasksRequest{}
+
+	if err = ptypes.UnmarshalAny(in, req); err != nil {
+		return nil, err
+	}
+
+	res, err := s.SetPrivacyMasks(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ptypes.MarshalAny(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// SetPrivacyMasks replaces privacy masks in driver config, mask images
+// are loaded by ffmpeg at start, so ffmpeg is restarted if recording and
+// current segment is finished early, nothing is changed if masks are same.
+func (s *DigitVideoRecorderService) SetPrivacyMasks(ctx context.Context, req *pb.SetPrivacyMasksRequest) (*empty.Empty, error) {
+	masks, err := parse_privacy_masks(req.GetMasks())
+	if err != nil {
+		s.module.Logger().WithError(err).Debugf("failed to parse privacy masks")
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	s.reconfigure_mtx.Lock()
+	defer s.reconfigure_mtx.Unlock()
+
+	cur_opt := s.get_drv_opt()
+	drv_opt := driver.WithPrivacyMasks(cur_opt, masks)
+	if driver.EqualPrivacyMasks(cur_opt, drv_opt) {
+		s.module.Logger().Debugf("privacy masks not changed")
+		return &empty.Empty{}, nil
+	}
+
+	if err = s.drv.Reconfigure(drv_opt); err != nil {
+		s.module.Logger().WithError(err).Errorf("failed to set privacy masks")
+		if _, ok := err.(driver.ConfigErrors); ok {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		s.update_state()
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	s.set_drv_opt(drv_opt)
+
+	if err = s.update_state(); err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	s.module.Logger().WithField("masks", len(masks)).Debugf("privacy masks set")
+
+	return &empty.Empty{}, nil
+}
+
+func (s *DigitVideoRecorderService) HANDLE_GRPC_GetStats(ctx context.Context, in *any.Any) (*any.Any, error) {
+	defer s.metrics.observe_rpc("GetStats", time.Now())
+
+	var err error
+	req := &empty.Empty{}
+
+	if err = ptypes.UnmarshalAny(in, req); err != nil {
+		return nil, err
+	}
+
+	res, err := s.GetStats(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ptypes.MarshalAny(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (s *DigitVideoRecorderService) GetStats(ctx context.Context, _ *empty.Empty) (*pb.GetStatsResponse, error) {
+	stats, err := s.drv.Stats()
+	if err != nil {
+		s.module.Logger().WithError(err).Debugf("failed to get stats")
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	res := &pb.GetStatsResponse{
+		Stats: copy_recording_stats(stats),
+	}
+
+	return res, nil
+}
+
+func (s *DigitVideoRecorderService) HANDLE_GRPC_GetRecordURL(ctx context.Context, in *any.Any) (*any.Any, error) {
+	defer s.metrics.observe_rpc("GetRecordURL", time.Now())
+
+	var err error
+	req := &pb.GetRecordURLRequest{}
+
+	if err = ptypes.UnmarshalAny(in, req); err != nil {
+		return nil, err
+	}
+
+	res, err := s.GetRecordURL(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ptypes.MarshalAny(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (s *DigitVideoRecorderService) GetRecordURL(ctx context.Context, req *pb.GetRecordURLRequest) (*pb.GetRecordURLResponse, error) {
+	var ttl time.Duration
+	var err error
+
+	if s.playback == nil {
+		err = errors.New("playback disabled")
+		s.module.Logger().WithError(err).Debugf("failed to get record url")
+		return nil, status.Errorf(codes.FailedPrecondition, err.Error())
+	}
+
+	if req_ttl := req.GetTtl(); req_ttl != nil {
+		if ttl, err = ptypes.Duration(req_ttl); err != nil {
+			s.module.Logger().WithError(err).Debugf("failed to get ttl field")
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+
+		if err = s.playback.check_ttl(ttl); err != nil {
+			s.module.Logger().WithError(err).Debugf("failed to check ttl")
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	r, err := s.drv.GetRecord(req.GetRecord().GetId().GetValue())
+	if err != nil {
+		s.module.Logger().WithError(err).Debugf("failed to get record")
+		if err == driver.ErrNotFound {
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	url, expires_at := s.playback.record_url(r.Id, ttl)
+	expires_at_pb, err := ptypes.TimestampProto(expires_at)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	res := &pb.GetRecordURLResponse{
+		Url:       url,
+		ExpiresAt: expires_at_pb,
+	}
+
+	s.module.Logger().Debugf("get record url")
+
+	return res, nil
+}
+
+func (s *DigitVideoRecorderService) HANDLE_GRPC_ValidateConfig(ctx context.Context, in *any.Any) (*any.Any, error) {
+	defer s.metrics.observe_rpc("ValidateConfig", time.Now())
+
+	var err error
+	req := &pb.ValidateConfigRequest{}
+
+	if err = ptypes.UnmarshalAny(in, req); err != nil {
+		return nil, err
+	}
+
+	res, err := s.ValidateConfig(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ptypes.MarshalAny(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (s *DigitVideoRecorderService) ValidateConfig(ctx context.Context, req *pb.ValidateConfigRequest) (*pb.ValidateConfigResponse, error) {
+	var drv_opt *driver.DigitVideoRecorderDriverOption
+	var err error
+
+	if cfg := req.GetConfig(); cfg != nil {
+		if drv_opt, err = driver.NewDigitVideoRecorderDriverOptionFromText(cfg.GetValue()); err != nil {
+			s.module.Logger().WithError(err).Debugf("failed to parse config")
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+	} else {
+		drv_opt = s.get_drv_opt()
+	}
+
+	errs, err := copy_config_errors(driver.ValidateDigitVideoRecorderDriverOption(drv_opt))
+	if err != nil {
+		s.module.Logger().WithError(err).Debugf("failed to validate config")
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	// playback, metrics and webhook options of module are checked with current config.
+	if req.GetConfig() == nil {
+		for _, m := range []struct {
+			key      string
+			validate func(*viper.Viper) error
+		}{
+			{"playback", validate_playback_option},
+			{"metrics", validate_metrics_option},
+			{"webhook", validate_webhook_option},
+		} {
+			opt := s.module.Kernel().Config().Sub(m.key).Raw()
+			if opt == nil {
+				continue
+			}
+
+			module_errs, err := copy_config_errors(m.validate(opt))
+			if err != nil {
+				s.module.Logger().WithError(err).Debugf("failed to validate config")
+				return nil, status.Errorf(codes.Internal, err.Error())
+			}
+			errs = append(errs, module_errs...)
+		}
+	}
+
+	res := &pb.ValidateConfigResponse{
+		Valid:  len(errs) == 0,
+		Errors: errs,
+	}
+
+	s.module.Logger().Debugf("validate config")
+
+	return res, nil
+}
+
+func (s *DigitVideoRecorderService) HANDLE_GRPC_Reconfigure(ctx context.Context, in *any.Any) (*any.Any, error) {
+	defer s.metrics.observe_rpc("Reconfigure", time.Now())
+
+	var err error
+	req := &pb.ReconfigureRequest{}
+
+	if err = ptypes.UnmarshalAny(in, req); err != nil {
+		return nil, err
+	}
+
+	res, err := s.Reconfigure(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ptypes.MarshalAny(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (s *DigitVideoRecorderService) Reconfigure(ctx context.Context, req *pb.ReconfigureRequest) (*empty.Empty, error) {
+	cfg := req.GetConfig()
+	if cfg == nil {
+		err := errors.New("config required")
+		s.module.Logger().WithError(err).Debugf("failed to reconfigure recorder")
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	drv_opt, err := driver.NewDigitVideoRecorderDriverOptionFromText(cfg.GetValue())
+	if err != nil {
+		s.module.Logger().WithError(err).Debugf("failed to parse config")
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	s.reconfigure_mtx.Lock()
+	defer s.reconfigure_mtx.Unlock()
+
+	if err = s.drv.Reconfigure(drv_opt); err != nil {
+		s.module.Logger().WithError(err).Errorf("failed to reconfigure recorder")
+		if _, ok := err.(driver.ConfigErrors); ok {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		s.update_state()
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	s.set_drv_opt(drv_opt)
+
+	if err = s.update_state(); err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	s.module.Logger().Debugf("recorder reconfigured")
+
+	return &empty.Empty{}, nil
+}
+
+func (s *DigitVideoRecorderService) log_config_errors(err error) {
+	if errs, ok := err.(driver.ConfigErrors); ok {
+		for _, e := range errs {
+			s.logger().WithField("key", e.Key).Errorf("invalid config: %v", e.Reason)
+		}
+	}
+}
+
 func (s *DigitVideoRecorderService) InitModuleService(m *component.Module) error {
 	var err error
 
 	s.module = m
 
-	drv_opt := &driver.DigitVideoRecorderDriverOption{s.module.Kernel().Config().Sub("driver").Raw()}
+	drv_opt := &driver.DigitVideoRecorderDriverOption{Viper: s.module.Kernel().Config().Sub("driver").Raw()}
+	if err = driver.ValidateDigitVideoRecorderDriverOption(drv_opt); err != nil {
+		s.log_config_errors(err)
+		return err
+	}
+
 	s.drv, err = driver.NewDigitVideoRecorderDriver(drv_opt.GetString("name"), drv_opt, "logger", s.logger(), "module", s.module)
 	if err != nil {
 		return err
 	}
+	s.set_drv_opt(drv_opt)
+	s.init_state_loop()
+
+	if metrics_opt := s.module.Kernel().Config().Sub("metrics").Raw(); metrics_opt != nil {
+		if err = s.init_metrics(metrics_opt); err != nil {
+			s.log_config_errors(err)
+			return err
+		}
+	}
+
+	if playback_opt := s.module.Kernel().Config().Sub("playback").Raw(); playback_opt != nil {
+		if err = s.init_playback(playback_opt); err != nil {
+			s.log_config_errors(err)
+			return err
+		}
+	}
+
+	if webhook_opt := s.module.Kernel().Config().Sub("webhook").Raw(); webhook_opt != nil {
+		if err = s.init_webhook(webhook_opt); err != nil {
+			s.log_config_errors(err)
+			return err
+		}
+	}
+
+	if driver.IsLiveEnabled(drv_opt) {
+		s.init_live(drv_opt.GetString("live.listen"))
+	}
 
 	s.logger().WithField("driver", drv_opt.GetString("name")).Debugf("init digit video recorder driver")
 	s.reset()
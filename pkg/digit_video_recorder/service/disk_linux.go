@@ -0,0 +1,16 @@
+//go:build linux
+// +build linux
+
+package digit_video_recorder_service
+
+import "syscall"
+
+func disk_free(path string) (uint64, error) {
+	var st syscall.Statfs_t
+
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+
+	return st.Bavail * uint64(st.Bsize), nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+// +build !linux
+
+package digit_video_recorder_service
+
+func disk_free(path string) (uint64, error) {
+	return 0, ErrUnsupported
+}
@@ -5,4 +5,5 @@ import "errors"
 var (
 	ErrNotStartable = errors.New("not startable")
 	ErrNotStopable  = errors.New("not stopable")
+	ErrUnsupported  = errors.New("unsupported")
 )
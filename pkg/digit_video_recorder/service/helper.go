@@ -1,7 +1,10 @@
 package digit_video_recorder_service
 
 import (
+	"fmt"
+
 	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
 
 	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
 	pb "github.com/nayotta/metathings-component-digit-video-recorder/proto"
@@ -11,9 +14,23 @@ func copy_record(x *driver.Record) *pb.Record {
 	start_at, _ := ptypes.TimestampProto(x.StartAt)
 	end_at, _ := ptypes.TimestampProto(x.EndAt)
 	y := &pb.Record{
-		Id:      x.Id,
-		StartAt: start_at,
-		EndAt:   end_at,
+		Id:        x.Id,
+		StartAt:   start_at,
+		EndAt:     end_at,
+		Profile:   x.GetProfile(),
+		AudioOnly: x.AudioOnly,
+	}
+
+	if len(x.Hooks) > 0 {
+		y.Hooks = make(map[string]*pb.HookResult, len(x.Hooks))
+		for name, res := range x.Hooks {
+			finished_at, _ := ptypes.TimestampProto(res.FinishedAt)
+			y.Hooks[name] = &pb.HookResult{
+				Status:     res.Status,
+				Error:      res.Error,
+				FinishedAt: finished_at,
+			}
+		}
 	}
 
 	return y
@@ -26,3 +43,182 @@ func copy_records(xs []*driver.Record) []*pb.Record {
 	}
 	return ys
 }
+
+func copy_timeline_span(x driver.TimelineSpan) *pb.TimelineSpan {
+	start_at, _ := ptypes.TimestampProto(x.StartAt)
+	end_at, _ := ptypes.TimestampProto(x.EndAt)
+	return &pb.TimelineSpan{
+		StartAt: start_at,
+		EndAt:   end_at,
+	}
+}
+
+func copy_timeline_spans(xs []driver.TimelineSpan) []*pb.TimelineSpan {
+	var ys []*pb.TimelineSpan
+	for _, x := range xs {
+		ys = append(ys, copy_timeline_span(x))
+	}
+	return ys
+}
+
+func copy_timeline_buckets(xs []driver.TimelineBucket) []*pb.TimelineBucket {
+	var ys []*pb.TimelineBucket
+	for _, x := range xs {
+		start_at, _ := ptypes.TimestampProto(x.StartAt)
+		end_at, _ := ptypes.TimestampProto(x.EndAt)
+		ys = append(ys, &pb.TimelineBucket{
+			StartAt:  start_at,
+			EndAt:    end_at,
+			Recorded: ptypes.DurationProto(x.Recorded),
+		})
+	}
+	return ys
+}
+
+func copy_recording_stats(x *driver.RecordingStats) *pb.RecordingStats {
+	y := &pb.RecordingStats{
+		State:       x.State,
+		Frames:      uint64(x.Frames),
+		Fps:         x.Fps,
+		Bitrate:     x.Bitrate,
+		TotalSize:   uint64(x.TotalSize),
+		OutTime:     ptypes.DurationProto(x.OutTime),
+		DupFrames:   uint64(x.DupFrames),
+		DropFrames:  uint64(x.DropFrames),
+		Speed:       x.Speed,
+		SegmentFile: x.SegmentFile,
+		SegmentSize: uint64(x.SegmentSize),
+
+		Restarts:          uint64(x.Restarts),
+		SegmentsCommitted: uint64(x.SegmentsCommitted),
+		SegmentFailures:   uint64(x.SegmentFailures),
+		BytesWritten:      uint64(x.BytesWritten),
+		Stalls:            uint64(x.Stalls),
+	}
+
+	if !x.StartedAt.IsZero() {
+		y.StartedAt, _ = ptypes.TimestampProto(x.StartedAt)
+	}
+
+	if !x.UpdatedAt.IsZero() {
+		y.UpdatedAt, _ = ptypes.TimestampProto(x.UpdatedAt)
+	}
+
+	if !x.LastStallAt.IsZero() {
+		y.LastStallAt, _ = ptypes.TimestampProto(x.LastStallAt)
+	}
+
+	return y
+}
+
+func copy_import_failures(xs []*driver.ImportRecordsFailure) []*pb.ImportFailure {
+	var ys []*pb.ImportFailure
+
+	for _, x := range xs {
+		ys = append(ys, &pb.ImportFailure{
+			Path:   x.Path,
+			Reason: x.Reason,
+		})
+	}
+
+	return ys
+}
+
+func copy_import_records_result(x *driver.ImportRecordsResult) *pb.ImportRecordsResponse {
+	return &pb.ImportRecordsResponse{
+		Imported: copy_records(x.Imported),
+		Skipped:  x.Skipped,
+		Failed:   copy_import_failures(x.Failed),
+	}
+}
+
+func copy_reindex_records_result(x *driver.ReindexRecordsResult) *pb.ReindexRecordsResponse {
+	return &pb.ReindexRecordsResponse{
+		Recovered: copy_records(x.Recovered),
+		Indexed:   int32(x.Indexed),
+		Unmapped:  copy_import_failures(x.Unmapped),
+	}
+}
+
+func copy_index_backup(x *driver.IndexBackup) *pb.IndexBackup {
+	created_at, _ := ptypes.TimestampProto(x.CreatedAt)
+
+	return &pb.IndexBackup{
+		Path:      x.Path,
+		Records:   int32(x.Records),
+		CreatedAt: created_at,
+		Checksum:  x.Checksum,
+	}
+}
+
+// index_backup_error_code returns grpc code of index backup error.
+func index_backup_error_code(err error) codes.Code {
+	switch err {
+	case driver.ErrInvalidIndexBackupPath:
+		return codes.InvalidArgument
+	case driver.ErrIndexBackupDisabled, driver.ErrNotStopped:
+		return codes.FailedPrecondition
+	default:
+		return codes.Internal
+	}
+}
+
+func copy_config_errors(err error) ([]*pb.ConfigError, error) {
+	if err == nil {
+		return nil, nil
+	}
+
+	errs, ok := err.(driver.ConfigErrors)
+	if !ok {
+		return nil, err
+	}
+
+	var ys []*pb.ConfigError
+	for _, e := range errs {
+		ys = append(ys, &pb.ConfigError{
+			Key:    e.Key,
+			Reason: e.Reason,
+		})
+	}
+
+	return ys, nil
+}
+
+var privacy_mask_modes = map[pb.PrivacyMaskMode_]string{
+	pb.PrivacyMask_FILL:     driver.PRIVACY_MASK_MODE_FILL,
+	pb.PrivacyMask_PIXELATE: driver.PRIVACY_MASK_MODE_PIXELATE,
+}
+
+func parse_privacy_masks(xs []*pb.PrivacyMask) ([]*driver.PrivacyMask, error) {
+	var ys []*driver.PrivacyMask
+	for i, x := range xs {
+		mode, ok := privacy_mask_modes[x.GetMode()]
+		if !ok {
+			return nil, fmt.Errorf("masks.%d: unknown mode", i)
+		}
+
+		y := &driver.PrivacyMask{
+			Name:      x.GetName().GetValue(),
+			Mode:      mode,
+			Color:     x.GetColor().GetValue(),
+			PixelSize: int(x.GetPixelSize().GetValue()),
+		}
+
+		if r := x.GetRect(); r != nil {
+			y.Rect = &driver.PrivacyMaskRect{
+				X:      int(r.GetX()),
+				Y:      int(r.GetY()),
+				Width:  int(r.GetWidth()),
+				Height: int(r.GetHeight()),
+			}
+		}
+
+		for _, p := range x.GetPolygon() {
+			y.Polygon = append(y.Polygon, driver.PrivacyMaskPoint{X: int(p.GetX()), Y: int(p.GetY())})
+		}
+
+		ys = append(ys, y)
+	}
+
+	return ys, nil
+}
@@ -0,0 +1,23 @@
+package digit_video_recorder_service
+
+import (
+	"net/http"
+)
+
+// serve_http starts a http server in background,
+// module keeps running if the server failed.
+func (s *DigitVideoRecorderService) serve_http(name string, addr string, handler http.Handler) *http.Server {
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: handler,
+	}
+
+	go func() {
+		s.logger().WithField("server", name).WithField("listen", addr).Debugf("http server listening")
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			s.logger().WithError(err).WithField("server", name).Errorf("http server exited")
+		}
+	}()
+
+	return srv
+}
@@ -0,0 +1,81 @@
+package digit_video_recorder_service
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
+)
+
+const (
+	LIVE_HTTP_PREFIX  = "/live/"
+	LIVE_TOKEN_COOKIE = "mtdvr_live_token"
+)
+
+func get_request_token(r *http.Request) (string, bool) {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer "), false
+	}
+
+	if tkn := r.URL.Query().Get("token"); tkn != "" {
+		return tkn, true
+	}
+
+	if c, err := r.Cookie(LIVE_TOKEN_COOKIE); err == nil {
+		return c.Value, false
+	}
+
+	return "", false
+}
+
+// handle_live serves live playlist and segments,
+// token passed by query is saved in cookie, so players fetch
+// segments referred by playlist without token.
+func (s *DigitVideoRecorderService) handle_live(w http.ResponseWriter, r *http.Request) {
+	drv_opt := s.get_drv_opt()
+	if !driver.IsLiveEnabled(drv_opt) {
+		http.NotFound(w, r)
+		return
+	}
+
+	expect := drv_opt.GetString("live.token")
+	tkn, from_query := get_request_token(r)
+	if expect == "" || subtle.ConstantTimeCompare([]byte(tkn), []byte(expect)) != 1 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	if from_query {
+		http.SetCookie(w, &http.Cookie{
+			Name:     LIVE_TOKEN_COOKIE,
+			Value:    tkn,
+			Path:     LIVE_HTTP_PREFIX,
+			HttpOnly: true,
+		})
+	}
+
+	name := path.Base(strings.TrimPrefix(r.URL.Path, LIVE_HTTP_PREFIX))
+	switch {
+	case name == driver.LIVE_PLAYLIST:
+		w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+		w.Header().Set("Cache-Control", "no-cache")
+	case strings.HasPrefix(name, "live-") && strings.HasSuffix(name, ".ts"):
+		w.Header().Set("Content-Type", "video/mp2t")
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	http.ServeFile(w, r, filepath.Join(driver.GetLiveDir(drv_opt), name))
+}
+
+// init_live starts live http server, listen address takes effect
+// only at module start, reconfigure changes others.
+func (s *DigitVideoRecorderService) init_live(listen string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(LIVE_HTTP_PREFIX, s.handle_live)
+	s.serve_http("live", listen, mux)
+}
@@ -0,0 +1,265 @@
+package digit_video_recorder_service
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/viper"
+
+	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
+)
+
+/*
+ * Metrics:
+ *   export recorder and storage metrics for prometheus.
+ * Options:
+ *   metrics:  // same level as `driver`, metrics disabled if not set.
+ *     listen: <host>:<port>  // metrics http listen address, like `0.0.0.0:9100`.
+ *     [ path: <path> ]  // metrics http path, default `/metrics`.
+ *     [ storage_interval: <sec> ]  // record storage metrics refreshed at most once in interval, default 60.
+ */
+
+const (
+	METRICS_NAMESPACE                = "mtdvr"
+	METRICS_DEFAULT_PATH             = "/metrics"
+	METRICS_DEFAULT_STORAGE_INTERVAL = 60 * time.Second
+)
+
+var (
+	metrics_recorder_state_desc = prometheus.NewDesc(
+		METRICS_NAMESPACE+"_recorder_state", "Recorder state, 1 for current state.", []string{"state"}, nil)
+	metrics_ffmpeg_restarts_desc = prometheus.NewDesc(
+		METRICS_NAMESPACE+"_ffmpeg_restarts_total", "Ffmpeg restarted by recorder.", nil, nil)
+	metrics_ffmpeg_stalls_desc = prometheus.NewDesc(
+		METRICS_NAMESPACE+"_ffmpeg_stalls_total", "Ffmpeg stalls detected by watchdog.", nil, nil)
+	metrics_segments_committed_desc = prometheus.NewDesc(
+		METRICS_NAMESPACE+"_segments_committed_total", "Segments committed to record storage.", nil, nil)
+	metrics_segment_failures_desc = prometheus.NewDesc(
+		METRICS_NAMESPACE+"_segment_failures_total", "Segments failed to process.", nil, nil)
+	metrics_bytes_written_desc = prometheus.NewDesc(
+		METRICS_NAMESPACE+"_bytes_written_total", "Bytes of committed segments.", nil, nil)
+	metrics_recording_fps_desc = prometheus.NewDesc(
+		METRICS_NAMESPACE+"_recording_fps", "Current recording frames per second.", nil, nil)
+	metrics_recording_speed_desc = prometheus.NewDesc(
+		METRICS_NAMESPACE+"_recording_speed", "Current recording speed, 1 for realtime.", nil, nil)
+	metrics_storage_records_desc = prometheus.NewDesc(
+		METRICS_NAMESPACE+"_storage_records", "Records in record storage.", nil, nil)
+	metrics_storage_oldest_record_age_desc = prometheus.NewDesc(
+		METRICS_NAMESPACE+"_storage_oldest_record_age_seconds", "Age of oldest record end time.", nil, nil)
+	metrics_storage_newest_record_age_desc = prometheus.NewDesc(
+		METRICS_NAMESPACE+"_storage_newest_record_age_seconds", "Age of newest record end time.", nil, nil)
+	metrics_disk_free_desc = prometheus.NewDesc(
+		METRICS_NAMESPACE+"_disk_free_bytes", "Free disk space of output directory.", []string{"path"}, nil)
+)
+
+var metrics_recorder_states = []*driver.DigitVideoRecorderState{
+	driver.DIGITI_VIDEO_RECORDER_STATE_ON,
+	driver.DIGITI_VIDEO_RECORDER_STATE_OFF,
+	driver.DIGITI_VIDEO_RECORDER_STATE_STALLED,
+}
+
+// metrics_storage_stats is summary of record storage, listing records
+// decodes whole index on some storages, so it is cached between scrapes.
+type metrics_storage_stats struct {
+	records   int
+	oldest_at time.Time
+	newest_at time.Time
+}
+
+// digitVideoRecorderMetrics collects recorder and storage metrics on scrape,
+// rpc latencies are observed by grpc handlers.
+type digitVideoRecorderMetrics struct {
+	srv         *DigitVideoRecorderService
+	registry    *prometheus.Registry
+	rpc_latency *prometheus.HistogramVec
+
+	storage_mtx      sync.Mutex
+	storage_interval time.Duration
+	storage_at       time.Time
+	storage          *metrics_storage_stats
+}
+
+// get_storage_stats returns cached storage summary, refreshed
+// if older than storage interval.
+func (m *digitVideoRecorderMetrics) get_storage_stats(now time.Time) (*metrics_storage_stats, error) {
+	m.storage_mtx.Lock()
+	defer m.storage_mtx.Unlock()
+
+	if m.storage != nil && now.Sub(m.storage_at) < m.storage_interval {
+		return m.storage, nil
+	}
+
+	rs, err := m.srv.drv.ListRecords(driver.ListRecordsFitler{})
+	if err != nil {
+		return nil, err
+	}
+
+	st := &metrics_storage_stats{records: len(rs)}
+	// records are sorted by start time.
+	if len(rs) > 0 {
+		st.oldest_at = rs[0].EndAt
+		st.newest_at = rs[len(rs)-1].EndAt
+	}
+
+	m.storage, m.storage_at = st, now
+
+	return st, nil
+}
+
+func (m *digitVideoRecorderMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- metrics_recorder_state_desc
+	ch <- metrics_ffmpeg_restarts_desc
+	ch <- metrics_ffmpeg_stalls_desc
+	ch <- metrics_segments_committed_desc
+	ch <- metrics_segment_failures_desc
+	ch <- metrics_bytes_written_desc
+	ch <- metrics_recording_fps_desc
+	ch <- metrics_recording_speed_desc
+	ch <- metrics_storage_records_desc
+	ch <- metrics_storage_oldest_record_age_desc
+	ch <- metrics_storage_newest_record_age_desc
+	ch <- metrics_disk_free_desc
+}
+
+func (m *digitVideoRecorderMetrics) Collect(ch chan<- prometheus.Metric) {
+	logger := m.srv.logger()
+
+	if stats, err := m.srv.drv.Stats(); err != nil {
+		logger.WithError(err).Debugf("failed to get stats for metrics")
+	} else {
+		for _, st := range metrics_recorder_states {
+			val := 0.0
+			if st.String() == stats.State {
+				val = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(metrics_recorder_state_desc, prometheus.GaugeValue, val, st.String())
+		}
+		ch <- prometheus.MustNewConstMetric(metrics_ffmpeg_restarts_desc, prometheus.CounterValue, float64(stats.Restarts))
+		ch <- prometheus.MustNewConstMetric(metrics_ffmpeg_stalls_desc, prometheus.CounterValue, float64(stats.Stalls))
+		ch <- prometheus.MustNewConstMetric(metrics_segments_committed_desc, prometheus.CounterValue, float64(stats.SegmentsCommitted))
+		ch <- prometheus.MustNewConstMetric(metrics_segment_failures_desc, prometheus.CounterValue, float64(stats.SegmentFailures))
+		ch <- prometheus.MustNewConstMetric(metrics_bytes_written_desc, prometheus.CounterValue, float64(stats.BytesWritten))
+		ch <- prometheus.MustNewConstMetric(metrics_recording_fps_desc, prometheus.GaugeValue, stats.Fps)
+		ch <- prometheus.MustNewConstMetric(metrics_recording_speed_desc, prometheus.GaugeValue, stats.Speed)
+	}
+
+	now := time.Now()
+	if st, err := m.get_storage_stats(now); err != nil {
+		logger.WithError(err).Debugf("failed to list records for metrics")
+	} else {
+		ch <- prometheus.MustNewConstMetric(metrics_storage_records_desc, prometheus.GaugeValue, float64(st.records))
+		if st.records > 0 {
+			ch <- prometheus.MustNewConstMetric(metrics_storage_oldest_record_age_desc, prometheus.GaugeValue, now.Sub(st.oldest_at).Seconds())
+			ch <- prometheus.MustNewConstMetric(metrics_storage_newest_record_age_desc, prometheus.GaugeValue, now.Sub(st.newest_at).Seconds())
+		}
+	}
+
+	dirs := map[string]bool{}
+	for _, file := range driver.OutputFiles(m.srv.get_drv_opt()) {
+		dir := driver.OutputFileBaseDir(file)
+		if dirs[dir] || !filepath.IsAbs(dir) {
+			continue
+		}
+		dirs[dir] = true
+
+		if free, err := disk_free(dir); err != nil {
+			logger.WithError(err).Debugf("failed to get disk free for metrics")
+		} else {
+			ch <- prometheus.MustNewConstMetric(metrics_disk_free_desc, prometheus.GaugeValue, float64(free), dir)
+		}
+	}
+}
+
+// observe_rpc records rpc latency, usage: `defer s.metrics.observe_rpc("Start", time.Now())`.
+func (m *digitVideoRecorderMetrics) observe_rpc(method string, start time.Time) {
+	if m == nil {
+		return
+	}
+
+	m.rpc_latency.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// validate_metrics_option returns driver.ConfigErrors of metrics options,
+// keys are prefixed by `metrics.`.
+func validate_metrics_option(opt *viper.Viper) error {
+	var errs driver.ConfigErrors
+	invalid := func(key string, reason string) {
+		errs = append(errs, &driver.InvalidConfigError{Key: "metrics." + key, Reason: reason})
+	}
+
+	listen := opt.GetString("listen")
+	if listen == "" {
+		invalid("listen", "required")
+	} else if _, _, err := net.SplitHostPort(listen); err != nil {
+		invalid("listen", fmt.Sprintf("expect <host>:<port>, got `%v`", listen))
+	}
+
+	if path := opt.GetString("path"); path != "" && !strings.HasPrefix(path, "/") {
+		invalid("path", fmt.Sprintf("expect absolute path, got `%v`", path))
+	}
+
+	if opt.GetInt("storage_interval") < 0 {
+		invalid("storage_interval", "expect positive integer")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (s *DigitVideoRecorderService) init_metrics(opt *viper.Viper) error {
+	if err := validate_metrics_option(opt); err != nil {
+		return err
+	}
+
+	storage_interval := METRICS_DEFAULT_STORAGE_INTERVAL
+	if val := opt.GetInt("storage_interval"); val > 0 {
+		storage_interval = time.Duration(val) * time.Second
+	}
+
+	m := &digitVideoRecorderMetrics{
+		srv:              s,
+		registry:         prometheus.NewRegistry(),
+		storage_interval: storage_interval,
+		rpc_latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: METRICS_NAMESPACE,
+			Name:      "rpc_duration_seconds",
+			Help:      "DigitVideoRecorderService rpc latencies.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+
+	for _, c := range []prometheus.Collector{
+		m,
+		m.rpc_latency,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{PidFn: func() (int, error) { return os.Getpid(), nil }}),
+	} {
+		if err := m.registry.Register(c); err != nil {
+			return err
+		}
+	}
+
+	path := opt.GetString("path")
+	if path == "" {
+		path = METRICS_DEFAULT_PATH
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	s.serve_http("metrics", opt.GetString("listen"), mux)
+
+	s.metrics = m
+
+	return nil
+}
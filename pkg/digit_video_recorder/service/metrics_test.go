@@ -0,0 +1,80 @@
+package digit_video_recorder_service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+
+	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
+)
+
+// counting_driver lists records, counts ListRecords calls.
+type counting_driver struct {
+	driver.DigitVideoRecorderDriver
+	records []*driver.Record
+	lists   int
+}
+
+func (d *counting_driver) ListRecords(driver.ListRecordsFitler) ([]*driver.Record, error) {
+	d.lists++
+	return d.records, nil
+}
+
+func TestMetricsStorageStats(t *testing.T) {
+	base := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	drv := &counting_driver{records: []*driver.Record{
+		{Id: "a", StartAt: base, EndAt: base.Add(time.Minute)},
+		{Id: "b", StartAt: base.Add(time.Minute), EndAt: base.Add(2 * time.Minute)},
+	}}
+	m := &digitVideoRecorderMetrics{srv: &DigitVideoRecorderService{drv: drv}, storage_interval: time.Minute}
+
+	now := base.Add(time.Hour)
+	st, err := m.get_storage_stats(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.records != 2 || !st.oldest_at.Equal(base.Add(time.Minute)) || !st.newest_at.Equal(base.Add(2*time.Minute)) {
+		t.Errorf("unexpected storage stats %+v", st)
+	}
+
+	// records are listed once in interval.
+	drv.records = drv.records[:1]
+	if st, _ = m.get_storage_stats(now.Add(59 * time.Second)); st.records != 2 || drv.lists != 1 {
+		t.Errorf("expect cached stats in interval, got %v records after %v lists", st.records, drv.lists)
+	}
+
+	if st, _ = m.get_storage_stats(now.Add(time.Minute)); st.records != 1 || drv.lists != 2 {
+		t.Errorf("expect stats refreshed after interval, got %v records after %v lists", st.records, drv.lists)
+	}
+}
+
+func TestValidateMetricsOption(t *testing.T) {
+	for _, tc := range []struct {
+		opt    map[string]interface{}
+		expect []string
+	}{
+		{map[string]interface{}{"listen": "0.0.0.0:9100"}, nil},
+		{map[string]interface{}{"listen": ":9100", "path": "/metrics", "storage_interval": 10}, nil},
+		{map[string]interface{}{}, []string{"metrics.listen"}},
+		{map[string]interface{}{"listen": "9100"}, []string{"metrics.listen"}},
+		{map[string]interface{}{"listen": ":9100", "path": "metrics"}, []string{"metrics.path"}},
+		{map[string]interface{}{"listen": ":9100", "storage_interval": -1}, []string{"metrics.storage_interval"}},
+	} {
+		v := viper.New()
+		if err := v.MergeConfigMap(tc.opt); err != nil {
+			t.Fatal(err)
+		}
+
+		var keys []string
+		if err := validate_metrics_option(v); err != nil {
+			for _, e := range err.(driver.ConfigErrors) {
+				keys = append(keys, e.Key)
+			}
+		}
+
+		if len(keys) != len(tc.expect) || (len(keys) > 0 && keys[0] != tc.expect[0]) {
+			t.Errorf("%v: expect errors of %v, got %v", tc.opt, tc.expect, keys)
+		}
+	}
+}
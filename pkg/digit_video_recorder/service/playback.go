@@ -0,0 +1,244 @@
+package digit_video_recorder_service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
+)
+
+/*
+ * Playback:
+ *   serve record files by http for browser playback,
+ *   urls are signed and short-lived, issued by `GetRecordURL`.
+ * Options:
+ *   playback:  // same level as `driver`, playback disabled if not set.
+ *     listen: <host>:<port>  // playback http listen address.
+ *     secret: <secret>  // hmac secret for signing urls.
+ *     [ base_url: <url> ]  // url prefix returned by `GetRecordURL`, like `https://dvr.example.com`, default `http://<listen>`,
+ *                          // required if listen host is empty or unspecified, like `:8080` or `0.0.0.0:8080`.
+ *     [ ttl: <sec> ]  // default url ttl, default 300.
+ *     [ max_ttl: <sec> ]  // max url ttl requested by `GetRecordURL`, default 3600.
+ *   record url: <base_url>/records/<id>?expires=<unix>&signature=<hmac-sha256>
+ */
+
+const (
+	PLAYBACK_HTTP_PREFIX     = "/records/"
+	PLAYBACK_DEFAULT_TTL     = 300 * time.Second
+	PLAYBACK_DEFAULT_MAX_TTL = 3600 * time.Second
+)
+
+var playback_content_types = map[string]string{
+	".mp4": "video/mp4",
+	".mkv": "video/x-matroska",
+	".ts":  "video/mp2t",
+	".flv": "video/x-flv",
+	".avi": "video/x-msvideo",
+	".m4a": "audio/mp4",
+	".ogg": "audio/ogg",
+	".wav": "audio/wav",
+	".mp3": "audio/mpeg",
+}
+
+type playbackServer struct {
+	srv      *DigitVideoRecorderService
+	secret   []byte
+	base_url string
+	ttl      time.Duration
+	max_ttl  time.Duration
+}
+
+// check_ttl returns error if requested ttl over max ttl.
+func (p *playbackServer) check_ttl(ttl time.Duration) error {
+	if ttl > p.max_ttl {
+		return fmt.Errorf("ttl %v exceeds max ttl %v", ttl, p.max_ttl)
+	}
+	return nil
+}
+
+func (p *playbackServer) sign(id string, expires int64) string {
+	mac := hmac.New(sha256.New, p.secret)
+	fmt.Fprintf(mac, "%s\n%d", id, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// record_url returns signed record url, ttl defaults to playback ttl if zero.
+func (p *playbackServer) record_url(id string, ttl time.Duration) (string, time.Time) {
+	if ttl <= 0 {
+		ttl = p.ttl
+	}
+
+	expires_at := time.Now().Add(ttl)
+	expires := expires_at.Unix()
+
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	q.Set("signature", p.sign(id, expires))
+
+	return p.base_url + PLAYBACK_HTTP_PREFIX + url.PathEscape(id) + "?" + q.Encode(), time.Unix(expires, 0)
+}
+
+func (p *playbackServer) verify(id string, r *http.Request) bool {
+	q := r.URL.Query()
+
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+
+	return hmac.Equal([]byte(q.Get("signature")), []byte(p.sign(id, expires)))
+}
+
+func get_record_content_type(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if typ, ok := playback_content_types[ext]; ok {
+		return typ
+	}
+
+	if typ := mime.TypeByExtension(ext); typ != "" {
+		return typ
+	}
+
+	return "application/octet-stream"
+}
+
+// handle_record serves record file, range requests are handled by http.ServeContent.
+func (p *playbackServer) handle_record(w http.ResponseWriter, r *http.Request) {
+	logger := p.srv.logger()
+
+	id := path.Base(strings.TrimPrefix(r.URL.Path, PLAYBACK_HTTP_PREFIX))
+	if !p.verify(id, r) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	rec, err := p.srv.drv.GetRecord(id)
+	if err == driver.ErrNotFound {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		logger.WithError(err).Debugf("failed to get record for playback")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	reader, err := rec.Reader()
+	if err != nil {
+		logger.WithError(err).Debugf("failed to open record for playback")
+		http.NotFound(w, r)
+		return
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	content, ok := reader.(io.ReadSeeker)
+	if !ok {
+		logger.Debugf("record reader not seekable")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	name := filepath.Base(rec.Path)
+	w.Header().Set("Content-Type", get_record_content_type(name))
+	http.ServeContent(w, r, name, rec.EndAt, content)
+}
+
+func get_playback_ttls(opt *viper.Viper) (time.Duration, time.Duration) {
+	ttl := PLAYBACK_DEFAULT_TTL
+	if val := opt.GetInt("ttl"); val > 0 {
+		ttl = time.Duration(val) * time.Second
+	}
+
+	max_ttl := PLAYBACK_DEFAULT_MAX_TTL
+	if val := opt.GetInt("max_ttl"); val > 0 {
+		max_ttl = time.Duration(val) * time.Second
+	}
+
+	return ttl, max_ttl
+}
+
+// validate_playback_option returns driver.ConfigErrors of playback options,
+// or nil if options are valid.
+func validate_playback_option(opt *viper.Viper) error {
+	var errs driver.ConfigErrors
+	invalid := func(key string, reason string) {
+		errs = append(errs, &driver.InvalidConfigError{Key: "playback." + key, Reason: reason})
+	}
+
+	if opt.GetString("secret") == "" {
+		invalid("secret", "required")
+	}
+
+	base_url := opt.GetString("base_url")
+	if base_url != "" {
+		if u, err := url.Parse(base_url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("base_url", "expect http or https url")
+		}
+	}
+
+	listen := opt.GetString("listen")
+	if listen == "" {
+		invalid("listen", "required")
+	} else if host, _, err := net.SplitHostPort(listen); err != nil {
+		invalid("listen", fmt.Sprintf("expect <host>:<port>, got `%v`", listen))
+	} else if ip := net.ParseIP(host); base_url == "" && (host == "" || (ip != nil && ip.IsUnspecified())) {
+		invalid("base_url", fmt.Sprintf("required, listen host of `%v` is not reachable by clients", listen))
+	}
+
+	if ttl, max_ttl := get_playback_ttls(opt); ttl > max_ttl {
+		invalid("ttl", fmt.Sprintf("expect ttl not over max_ttl %v", max_ttl))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (s *DigitVideoRecorderService) init_playback(opt *viper.Viper) error {
+	if err := validate_playback_option(opt); err != nil {
+		return err
+	}
+
+	listen := opt.GetString("listen")
+	secret := opt.GetString("secret")
+
+	base_url := strings.TrimSuffix(opt.GetString("base_url"), "/")
+	if base_url == "" {
+		base_url = "http://" + listen
+	}
+
+	ttl, max_ttl := get_playback_ttls(opt)
+
+	p := &playbackServer{
+		srv:      s,
+		secret:   []byte(secret),
+		base_url: base_url,
+		ttl:      ttl,
+		max_ttl:  max_ttl,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(PLAYBACK_HTTP_PREFIX, p.handle_record)
+	s.serve_http("playback", listen, mux)
+
+	s.playback = p
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package digit_video_recorder_service
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+
+	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
+	component "github.com/nayotta/metathings/pkg/component"
+)
+
+func TestValidatePlaybackOption(t *testing.T) {
+	for _, tc := range []struct {
+		listen   string
+		base_url string
+		expect   []string
+	}{
+		{"192.168.1.10:8080", "", nil},
+		{"dvr.local:8080", "", nil},
+		{":8080", "", []string{"playback.base_url"}},
+		{"0.0.0.0:8080", "", []string{"playback.base_url"}},
+		{"[::]:8080", "", []string{"playback.base_url"}},
+		{":8080", "https://dvr.example.com", nil},
+		{"0.0.0.0:8080", "https://dvr.example.com/", nil},
+		{"0.0.0.0:8080", "dvr.example.com", []string{"playback.base_url"}},
+		{"8080", "https://dvr.example.com", []string{"playback.listen"}},
+		{"", "https://dvr.example.com", []string{"playback.listen"}},
+	} {
+		v := viper.New()
+		v.Set("listen", tc.listen)
+		v.Set("base_url", tc.base_url)
+		v.Set("secret", "secret")
+
+		var keys []string
+		if err := validate_playback_option(v); err != nil {
+			for _, e := range err.(driver.ConfigErrors) {
+				keys = append(keys, e.Key)
+			}
+		}
+
+		if len(keys) != len(tc.expect) || (len(keys) > 0 && keys[0] != tc.expect[0]) {
+			t.Errorf("listen %q, base_url %q: expect errors of %v, got %v", tc.listen, tc.base_url, tc.expect, keys)
+		}
+	}
+}
+
+// test_record_driver gets records by id.
+type test_record_driver struct {
+	driver.DigitVideoRecorderDriver
+	records map[string]*driver.Record
+}
+
+func (d *test_record_driver) GetRecord(id string) (*driver.Record, error) {
+	r, ok := d.records[id]
+	if !ok {
+		return nil, driver.ErrNotFound
+	}
+	return r, nil
+}
+
+func new_test_playback_server(records ...*driver.Record) *playbackServer {
+	drv := &test_record_driver{records: map[string]*driver.Record{}}
+	for _, r := range records {
+		drv.records[r.Id] = r
+	}
+
+	return &playbackServer{
+		srv:      &DigitVideoRecorderService{module: &component.Module{}, drv: drv},
+		secret:   []byte("secret"),
+		base_url: "https://dvr.example.com",
+		ttl:      PLAYBACK_DEFAULT_TTL,
+		max_ttl:  PLAYBACK_DEFAULT_MAX_TTL,
+	}
+}
+
+func TestPlaybackVerify(t *testing.T) {
+	p := new_test_playback_server()
+
+	raw, _ := p.record_url("a", time.Minute)
+	valid, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valid.Path != PLAYBACK_HTTP_PREFIX+"a" {
+		t.Errorf("expect record path, got %v", valid.Path)
+	}
+
+	expires, _ := strconv.ParseInt(valid.Query().Get("expires"), 10, 64)
+	expired := p.sign("a", time.Now().Add(-time.Second).Unix())
+	other := &playbackServer{secret: []byte("other")}
+
+	for _, tc := range []struct {
+		name  string
+		id    string
+		query string
+		ok    bool
+	}{
+		{"valid", "a", valid.RawQuery, true},
+		{"other record", "b", valid.RawQuery, false},
+		{"expired", "a", url.Values{"expires": {strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10)}, "signature": {expired}}.Encode(), false},
+		{"extended expires", "a", url.Values{"expires": {strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)}, "signature": {valid.Query().Get("signature")}}.Encode(), false},
+		{"other secret", "a", url.Values{"expires": {valid.Query().Get("expires")}, "signature": {other.sign("a", expires)}}.Encode(), false},
+		{"no signature", "a", url.Values{"expires": {valid.Query().Get("expires")}}.Encode(), false},
+		{"no expires", "a", url.Values{"signature": {valid.Query().Get("signature")}}.Encode(), false},
+	} {
+		r := httptest.NewRequest("GET", PLAYBACK_HTTP_PREFIX+tc.id+"?"+tc.query, nil)
+		if ok := p.verify(tc.id, r); ok != tc.ok {
+			t.Errorf("%v: expect verified %v, got %v", tc.name, tc.ok, ok)
+		}
+	}
+}
+
+func TestPlaybackHandleRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtdvr-playback-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "a.mp4")
+	if err = ioutil.WriteFile(file, []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	end := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := new_test_playback_server(
+		&driver.Record{Id: "a", EndAt: end, Path: file},
+	)
+	srv := httptest.NewServer(http.HandlerFunc(p.handle_record))
+	defer srv.Close()
+
+	get := func(id string, rng string) *http.Response {
+		raw, _ := p.record_url(id, 0)
+		req, err := http.NewRequest("GET", srv.URL+raw[len(p.base_url):], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rng != "" {
+			req.Header.Set("Range", rng)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	res := get("a", "bytes=2-5")
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusPartialContent {
+		t.Errorf("range: expect status 206, got %v", res.StatusCode)
+	}
+	if val := res.Header.Get("Content-Range"); val != "bytes 2-5/10" {
+		t.Errorf("range: expect content range bytes 2-5/10, got %v", val)
+	}
+	if string(body) != "2345" {
+		t.Errorf("range: expect body 2345, got %q", body)
+	}
+	if val := res.Header.Get("Content-Type"); val != "video/mp4" {
+		t.Errorf("expect content type video/mp4, got %v", val)
+	}
+
+	res = get("a", "")
+	body, _ = ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || string(body) != "0123456789" || res.Header.Get("Accept-Ranges") != "bytes" {
+		t.Errorf("full: expect whole file with accept ranges, got %v %q %v", res.StatusCode, body, res.Header)
+	}
+
+	if res, err = http.Get(srv.URL + PLAYBACK_HTTP_PREFIX + "a"); err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("unsigned: expect status 403, got %v", res.StatusCode)
+	}
+
+	res = get("b", "")
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown record: expect status 404, got %v", res.StatusCode)
+	}
+}
+
+func TestGetRecordContentType(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		expect string
+	}{
+		{"a.mp4", "video/mp4"},
+		{"a.MP4", "video/mp4"},
+		{"a.ts", "video/mp2t"},
+		{"a.mkv", "video/x-matroska"},
+		{"a.m4a", "audio/mp4"},
+		{"a", "application/octet-stream"},
+		{"a.unknown-ext", "application/octet-stream"},
+	} {
+		if val := get_record_content_type(tc.name); val != tc.expect {
+			t.Errorf("%v: expect %v, got %v", tc.name, tc.expect, val)
+		}
+	}
+}
+
+func TestPlaybackCheckTTL(t *testing.T) {
+	p := new_test_playback_server()
+
+	for _, tc := range []struct {
+		ttl time.Duration
+		ok  bool
+	}{
+		{0, true},
+		{time.Minute, true},
+		{PLAYBACK_DEFAULT_MAX_TTL, true},
+		{PLAYBACK_DEFAULT_MAX_TTL + time.Second, false},
+		{365 * 24 * time.Hour, false},
+	} {
+		if err := p.check_ttl(tc.ttl); (err == nil) != tc.ok {
+			t.Errorf("ttl %v: expect accepted %v, got %v", tc.ttl, tc.ok, err)
+		}
+	}
+}
@@ -0,0 +1,491 @@
+package digit_video_recorder_service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+
+	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
+	id_helper "github.com/nayotta/metathings/pkg/common/id"
+)
+
+/*
+ * Webhook:
+ *   post driver events to http endpoint, see driver/event.go for events,
+ *   events are delivered one by one in order, failed delivery is retried
+ *   with exponential backoff, and blocks later events.
+ * Options:
+ *   webhook:  // same level as `driver`, webhook disabled if not set.
+ *     url: <url>  // endpoint, events are posted as json.
+ *     [ headers: { <name>: <value> } ]  // extra request headers, like `Authorization`.
+ *     [ secret: <secret> ]  // hmac secret, body is signed in `X-Mtdvr-Signature: sha256=<hex>` if set.
+ *     [ events: [ <type>, ... ] ]  // event types, default all events.
+ *     [ timeout: <sec> ]  // request timeout, default 10.
+ *     [ retry:
+ *         [ initial_interval: <sec> ]  // default 1.
+ *         [ max_interval: <sec> ]  // default 300.
+ *         [ max_attempts: <n> ]  // event dropped after attempts, default 0, retry forever. ]
+ *     [ outbox: <dir> ]  // persistent outbox, pending events are delivered after restart,
+ *                        // events are written by delivery goroutine, and kept in memory if not set.
+ *     [ max_pending: <n> ]  // oldest events dropped if more pending, default 10000.
+ * Payload:
+ *   {"id": "<delivery id>", "type": "<event type>", "time": <unix>, "module": "<module name>", "data": { ... }}
+ *   data of segment.finalized is `Record.Data()` and `size`.
+ * Headers:
+ *   X-Mtdvr-Event: <event type>
+ *   X-Mtdvr-Delivery: <delivery id>
+ */
+
+const (
+	WEBHOOK_DEFAULT_TIMEOUT          = 10 * time.Second
+	WEBHOOK_DEFAULT_INITIAL_INTERVAL = 1 * time.Second
+	WEBHOOK_DEFAULT_MAX_INTERVAL     = 300 * time.Second
+	WEBHOOK_DEFAULT_MAX_PENDING      = 10000
+
+	WEBHOOK_HEADER_EVENT     = "X-Mtdvr-Event"
+	WEBHOOK_HEADER_DELIVERY  = "X-Mtdvr-Delivery"
+	WEBHOOK_HEADER_SIGNATURE = "X-Mtdvr-Signature"
+
+	// prefix of outbox temp files, renamed to `.json` when complete.
+	WEBHOOK_OUTBOX_TEMP_PREFIX = ".webhook-"
+)
+
+var webhook_event_types = []string{
+	driver.EVENT_SEGMENT_FINALIZED,
+	driver.EVENT_STATE_CHANGED,
+	driver.EVENT_ERROR,
+}
+
+type webhook_payload struct {
+	Id     string                 `json:"id"`
+	Type   string                 `json:"type"`
+	Time   int64                  `json:"time"`
+	Module string                 `json:"module"`
+	Data   map[string]interface{} `json:"data"`
+}
+
+type webhook_delivery struct {
+	id   string
+	typ  string
+	body []byte
+	// name of outbox file to write, empty if outbox disabled.
+	name string
+	// outbox file, empty if not written.
+	file     string
+	attempts int
+	// dropped from queue as too many pending.
+	dropped bool
+}
+
+type webhookNotifier struct {
+	logger           log.FieldLogger
+	module           string
+	url              string
+	headers          map[string]string
+	secret           []byte
+	events           map[string]bool
+	client           *http.Client
+	initial_interval time.Duration
+	max_interval     time.Duration
+	max_attempts     int
+	outbox           string
+	max_pending      int
+
+	mtx   sync.Mutex
+	queue []*webhook_delivery
+	wake  chan struct{}
+}
+
+func (n *webhookNotifier) sign(body []byte) string {
+	mac := hmac.New(sha256.New, n.secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// notify enqueues event in memory, called by driver event handlers,
+// which should not block, outbox file is written by deliver_loop.
+func (n *webhookNotifier) notify(evt *driver.Event) {
+	if !n.events[evt.Type] {
+		return
+	}
+
+	id := id_helper.NewId()
+	body, err := json.Marshal(&webhook_payload{
+		Id:     id,
+		Type:   evt.Type,
+		Time:   evt.Time.Unix(),
+		Module: n.module,
+		Data:   evt.Data,
+	})
+	if err != nil {
+		n.logger.WithError(err).Warningf("failed to marshal webhook payload")
+		return
+	}
+
+	d := &webhook_delivery{id: id, typ: evt.Type, body: body}
+	if n.outbox != "" {
+		d.name = fmt.Sprintf("%020d-%v-%v.json", evt.Time.UnixNano(), evt.Type, id)
+	}
+
+	n.enqueue(d)
+}
+
+// persist writes outbox files of queued deliveries, called by deliver_loop.
+func (n *webhookNotifier) persist() {
+	n.mtx.Lock()
+	var ds []*webhook_delivery
+	for _, d := range n.queue {
+		if d.name != "" && d.file == "" {
+			ds = append(ds, d)
+		}
+	}
+	n.mtx.Unlock()
+
+	for _, d := range ds {
+		file := filepath.Join(n.outbox, d.name)
+		err := write_webhook_outbox_file(file, d.body)
+
+		n.mtx.Lock()
+		if err != nil {
+			n.logger.WithError(err).Warningf("failed to write webhook outbox, keep event in memory")
+			d.name = ""
+		} else {
+			d.file = file
+			// dropped while writing.
+			if d.dropped {
+				n.remove(d)
+			}
+		}
+		n.mtx.Unlock()
+	}
+}
+
+// write_webhook_outbox_file writes body to a temp file, syncs and renames it
+// to file, so outbox files are complete after power loss.
+func write_webhook_outbox_file(file string, body []byte) error {
+	dir := filepath.Dir(file)
+
+	f, err := ioutil.TempFile(dir, WEBHOOK_OUTBOX_TEMP_PREFIX)
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	_, err = f.Write(body)
+	if err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(tmp, file)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+
+	return nil
+}
+
+func (n *webhookNotifier) enqueue(ds ...*webhook_delivery) {
+	n.mtx.Lock()
+	n.queue = append(n.queue, ds...)
+	for len(n.queue) > n.max_pending {
+		n.logger.WithField("delivery", n.queue[0].id).Warningf("too many pending webhook events, drop the oldest")
+		n.queue[0].dropped = true
+		n.remove(n.queue[0])
+		n.queue = n.queue[1:]
+	}
+	n.mtx.Unlock()
+
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (n *webhookNotifier) remove(d *webhook_delivery) {
+	if d.file != "" {
+		if err := os.Remove(d.file); err != nil && !os.IsNotExist(err) {
+			n.logger.WithError(err).Warningf("failed to remove webhook outbox file")
+		}
+	}
+}
+
+func (n *webhookNotifier) head() *webhook_delivery {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	if len(n.queue) == 0 {
+		return nil
+	}
+	return n.queue[0]
+}
+
+// done removes delivery from queue, if not dropped by enqueue.
+func (n *webhookNotifier) done(d *webhook_delivery) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	if len(n.queue) > 0 && n.queue[0] == d {
+		n.queue = n.queue[1:]
+		n.remove(d)
+	}
+}
+
+func (n *webhookNotifier) post(d *webhook_delivery) error {
+	req, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(d.body))
+	if err != nil {
+		return err
+	}
+
+	for key, val := range n.headers {
+		req.Header.Set(key, val)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WEBHOOK_HEADER_EVENT, d.typ)
+	req.Header.Set(WEBHOOK_HEADER_DELIVERY, d.id)
+	if len(n.secret) > 0 {
+		req.Header.Set(WEBHOOK_HEADER_SIGNATURE, n.sign(d.body))
+	}
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %v", res.Status)
+	}
+
+	return nil
+}
+
+func (n *webhookNotifier) backoff(attempts int) time.Duration {
+	interval := n.initial_interval
+	for i := 1; i < attempts && interval < n.max_interval; i++ {
+		interval *= 2
+	}
+
+	if interval > n.max_interval {
+		interval = n.max_interval
+	}
+
+	return interval
+}
+
+// wait waits for duration, outbox files of events enqueued meanwhile are written.
+func (n *webhookNotifier) wait(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			return
+		case <-n.wake:
+			if n.outbox != "" {
+				n.persist()
+			}
+		}
+	}
+}
+
+func (n *webhookNotifier) deliver_loop() {
+	for {
+		if n.outbox != "" {
+			n.persist()
+		}
+
+		d := n.head()
+		if d == nil {
+			<-n.wake
+			continue
+		}
+
+		err := n.post(d)
+		if err == nil {
+			n.done(d)
+			continue
+		}
+
+		d.attempts++
+		logger := n.logger.WithError(err).WithFields(log.Fields{"delivery": d.id, "attempts": d.attempts})
+		if n.max_attempts > 0 && d.attempts >= n.max_attempts {
+			logger.Warningf("failed to deliver webhook event, drop it")
+			n.done(d)
+			continue
+		}
+
+		logger.Debugf("failed to deliver webhook event, retry later")
+		n.wait(n.backoff(d.attempts))
+	}
+}
+
+// load_outbox loads pending events in outbox, in event order,
+// temp files left by interrupted writes are removed.
+func (n *webhookNotifier) load_outbox() error {
+	tmps, err := filepath.Glob(filepath.Join(n.outbox, WEBHOOK_OUTBOX_TEMP_PREFIX+"*"))
+	if err != nil {
+		return err
+	}
+	for _, name := range tmps {
+		os.Remove(name)
+	}
+
+	names, err := filepath.Glob(filepath.Join(n.outbox, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	var ds []*webhook_delivery
+	for _, name := range names {
+		body, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		var p webhook_payload
+		if err = json.Unmarshal(body, &p); err != nil {
+			n.logger.WithError(err).WithField("file", name).Warningf("invalid webhook outbox file, remove it")
+			os.Remove(name)
+			continue
+		}
+
+		ds = append(ds, &webhook_delivery{id: p.Id, typ: p.Type, body: body, file: name})
+	}
+
+	if len(ds) > 0 {
+		n.logger.WithField("events", len(ds)).Infof("load pending webhook events")
+		n.enqueue(ds...)
+	}
+
+	return nil
+}
+
+func get_webhook_seconds(opt *viper.Viper, key string, def time.Duration) time.Duration {
+	if val := opt.GetInt(key); val > 0 {
+		return time.Duration(val) * time.Second
+	}
+	return def
+}
+
+// validate_webhook_option returns driver.ConfigErrors of webhook options,
+// or nil if options are valid.
+func validate_webhook_option(opt *viper.Viper) error {
+	var errs driver.ConfigErrors
+	invalid := func(key string, reason string) {
+		errs = append(errs, &driver.InvalidConfigError{Key: "webhook." + key, Reason: reason})
+	}
+
+	url := opt.GetString("url")
+	if url == "" {
+		invalid("url", "required")
+	} else if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		invalid("url", "expect http or https url")
+	}
+
+	for _, typ := range cast.ToStringSlice(opt.Get("events")) {
+		known := false
+		for _, t := range webhook_event_types {
+			known = known || t == typ
+		}
+		if !known {
+			invalid("events", fmt.Sprintf("unknown event %v", typ))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func new_webhook_notifier(opt *viper.Viper, module string, logger log.FieldLogger) (*webhookNotifier, error) {
+	if err := validate_webhook_option(opt); err != nil {
+		return nil, err
+	}
+
+	events := map[string]bool{}
+	if opt.IsSet("events") {
+		for _, typ := range cast.ToStringSlice(opt.Get("events")) {
+			events[typ] = true
+		}
+	} else {
+		for _, typ := range webhook_event_types {
+			events[typ] = true
+		}
+	}
+
+	max_pending := WEBHOOK_DEFAULT_MAX_PENDING
+	if val := opt.GetInt("max_pending"); val > 0 {
+		max_pending = val
+	}
+
+	n := &webhookNotifier{
+		logger:           logger,
+		module:           module,
+		url:              opt.GetString("url"),
+		headers:          cast.ToStringMapString(opt.Get("headers")),
+		secret:           []byte(opt.GetString("secret")),
+		events:           events,
+		client:           &http.Client{Timeout: get_webhook_seconds(opt, "timeout", WEBHOOK_DEFAULT_TIMEOUT)},
+		initial_interval: get_webhook_seconds(opt, "retry.initial_interval", WEBHOOK_DEFAULT_INITIAL_INTERVAL),
+		max_interval:     get_webhook_seconds(opt, "retry.max_interval", WEBHOOK_DEFAULT_MAX_INTERVAL),
+		max_attempts:     opt.GetInt("retry.max_attempts"),
+		outbox:           opt.GetString("outbox"),
+		max_pending:      max_pending,
+		wake:             make(chan struct{}, 1),
+	}
+
+	if n.outbox != "" {
+		if err := os.MkdirAll(n.outbox, 0755); err != nil {
+			return nil, driver.ConfigErrors{{Key: "webhook.outbox", Reason: err.Error()}}
+		}
+
+		if err := n.load_outbox(); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+func (s *DigitVideoRecorderService) init_webhook(opt *viper.Viper) error {
+	n, err := new_webhook_notifier(opt, s.module.Name(), s.logger())
+	if err != nil {
+		return err
+	}
+
+	go n.deliver_loop()
+	s.drv.OnEvent(n.notify)
+
+	return nil
+}
@@ -0,0 +1,332 @@
+package digit_video_recorder_service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+
+	driver "github.com/nayotta/metathings-component-digit-video-recorder/pkg/digit_video_recorder/driver"
+)
+
+type test_webhook_request struct {
+	header  http.Header
+	payload webhook_payload
+	body    []byte
+}
+
+// test_webhook_server records requests, responds status by statuses in order,
+// the last status is used when statuses run out.
+type test_webhook_server struct {
+	*httptest.Server
+
+	mtx      sync.Mutex
+	statuses []int
+	reqs     []*test_webhook_request
+	ch       chan *test_webhook_request
+}
+
+func new_test_webhook_server(statuses ...int) *test_webhook_server {
+	s := &test_webhook_server{statuses: statuses, ch: make(chan *test_webhook_request, 100)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func (s *test_webhook_server) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	req := &test_webhook_request{header: r.Header, body: body}
+	json.Unmarshal(body, &req.payload)
+
+	s.mtx.Lock()
+	status := http.StatusOK
+	if len(s.statuses) > 0 {
+		status = s.statuses[0]
+		if len(s.statuses) > 1 {
+			s.statuses = s.statuses[1:]
+		}
+	}
+	s.reqs = append(s.reqs, req)
+	s.mtx.Unlock()
+
+	w.WriteHeader(status)
+	s.ch <- req
+}
+
+func (s *test_webhook_server) wait(t *testing.T) *test_webhook_request {
+	select {
+	case req := <-s.ch:
+		return req
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook request timeout")
+		return nil
+	}
+}
+
+func new_test_webhook_notifier(t *testing.T, opt map[string]interface{}) *webhookNotifier {
+	v := viper.New()
+	if err := v.MergeConfigMap(opt); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.New()
+	logger.SetOutput(ioutil.Discard)
+
+	n, err := new_webhook_notifier(v, "dvr", logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// seconds are too long for tests.
+	n.initial_interval = time.Millisecond
+	n.max_interval = 4 * time.Millisecond
+
+	return n
+}
+
+func new_test_event(typ string, sec int, state string) *driver.Event {
+	return &driver.Event{
+		Type: typ,
+		Time: time.Date(2020, 1, 2, 3, 4, sec, 0, time.UTC),
+		Data: map[string]interface{}{"state": state},
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	srv := new_test_webhook_server()
+	defer srv.Close()
+
+	n := new_test_webhook_notifier(t, map[string]interface{}{
+		"url":     srv.URL,
+		"secret":  "s3cret",
+		"headers": map[string]interface{}{"Authorization": "Bearer token"},
+	})
+	go n.deliver_loop()
+
+	n.notify(new_test_event(driver.EVENT_STATE_CHANGED, 0, "recording"))
+	req := srv.wait(t)
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(req.body)
+	if expect := "sha256=" + hex.EncodeToString(mac.Sum(nil)); req.header.Get(WEBHOOK_HEADER_SIGNATURE) != expect {
+		t.Errorf("expect signature %v, got %v", expect, req.header.Get(WEBHOOK_HEADER_SIGNATURE))
+	}
+
+	if val := req.header.Get(WEBHOOK_HEADER_EVENT); val != driver.EVENT_STATE_CHANGED {
+		t.Errorf("expect event header %v, got %v", driver.EVENT_STATE_CHANGED, val)
+	}
+
+	if val := req.header.Get(WEBHOOK_HEADER_DELIVERY); val == "" || val != req.payload.Id {
+		t.Errorf("expect delivery header %v, got %v", req.payload.Id, val)
+	}
+
+	if val := req.header.Get("Authorization"); val != "Bearer token" {
+		t.Errorf("expect extra header, got %v", val)
+	}
+
+	if req.payload.Module != "dvr" || req.payload.Data["state"] != "recording" {
+		t.Errorf("unexpected payload %+v", req.payload)
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	srv := new_test_webhook_server(http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK)
+	defer srv.Close()
+
+	n := new_test_webhook_notifier(t, map[string]interface{}{"url": srv.URL})
+	go n.deliver_loop()
+
+	n.notify(new_test_event(driver.EVENT_STATE_CHANGED, 0, "recording"))
+	n.notify(new_test_event(driver.EVENT_STATE_CHANGED, 1, "stopped"))
+
+	// the failed event blocks later events until delivered.
+	var states []interface{}
+	ids := map[string]int{}
+	for i := 0; i < 4; i++ {
+		req := srv.wait(t)
+		states = append(states, req.payload.Data["state"])
+		ids[req.payload.Id]++
+	}
+
+	expect := []interface{}{"recording", "recording", "recording", "stopped"}
+	for i := range expect {
+		if states[i] != expect[i] {
+			t.Fatalf("expect deliveries %v, got %v", expect, states)
+		}
+	}
+
+	if len(ids) != 2 {
+		t.Errorf("expect retries with the same delivery id, got %v", ids)
+	}
+
+	for _, tc := range []struct {
+		attempts int
+		expect   time.Duration
+	}{
+		{1, time.Millisecond},
+		{2, 2 * time.Millisecond},
+		{3, 4 * time.Millisecond},
+		{10, 4 * time.Millisecond},
+	} {
+		if val := n.backoff(tc.attempts); val != tc.expect {
+			t.Errorf("backoff of %v attempts: expect %v, got %v", tc.attempts, tc.expect, val)
+		}
+	}
+}
+
+func TestWebhookMaxAttempts(t *testing.T) {
+	srv := new_test_webhook_server(http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK)
+	defer srv.Close()
+
+	n := new_test_webhook_notifier(t, map[string]interface{}{
+		"url":   srv.URL,
+		"retry": map[string]interface{}{"max_attempts": 2},
+	})
+	go n.deliver_loop()
+
+	n.notify(new_test_event(driver.EVENT_STATE_CHANGED, 0, "recording"))
+	n.notify(new_test_event(driver.EVENT_STATE_CHANGED, 1, "stopped"))
+
+	var states []interface{}
+	for i := 0; i < 3; i++ {
+		states = append(states, srv.wait(t).payload.Data["state"])
+	}
+
+	if states[0] != "recording" || states[1] != "recording" || states[2] != "stopped" {
+		t.Errorf("expect event dropped after 2 attempts, got %v", states)
+	}
+
+	select {
+	case req := <-srv.ch:
+		t.Errorf("unexpected delivery %v", req.payload.Data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWebhookOutbox(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtdvr-webhook-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	outbox := filepath.Join(dir, "outbox")
+	opt := map[string]interface{}{"url": "http://127.0.0.1:0", "outbox": outbox}
+
+	// events are not delivered before restart.
+	n := new_test_webhook_notifier(t, opt)
+	for i, state := range []string{"recording", "stopped", "recording"} {
+		n.notify(new_test_event(driver.EVENT_STATE_CHANGED, i, state))
+	}
+
+	// notify never writes outbox, files are written by delivery goroutine.
+	if names, _ := filepath.Glob(filepath.Join(outbox, "*")); len(names) != 0 {
+		t.Fatalf("expect outbox not written by notify, got %v", names)
+	}
+	n.persist()
+	if names, _ := filepath.Glob(filepath.Join(outbox, "*.json")); len(names) != 3 {
+		t.Fatalf("expect 3 outbox files, got %v", names)
+	}
+
+	// file of an interrupted write.
+	if err = ioutil.WriteFile(filepath.Join(outbox, WEBHOOK_OUTBOX_TEMP_PREFIX+"123"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := new_test_webhook_server()
+	defer srv.Close()
+
+	opt["url"] = srv.URL
+	n = new_test_webhook_notifier(t, opt)
+	go n.deliver_loop()
+
+	var states []interface{}
+	for i := 0; i < 3; i++ {
+		states = append(states, srv.wait(t).payload.Data["state"])
+	}
+
+	if states[0] != "recording" || states[1] != "stopped" || states[2] != "recording" {
+		t.Errorf("expect outbox delivered in event order, got %v", states)
+	}
+
+	// outbox file is removed after delivery.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		names, _ := filepath.Glob(filepath.Join(outbox, "*"))
+		if len(names) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expect empty outbox, got %v", names)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebhookOutboxDropped(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtdvr-webhook-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	n := new_test_webhook_notifier(t, map[string]interface{}{
+		"url":         "http://127.0.0.1:0",
+		"outbox":      dir,
+		"max_pending": 2,
+	})
+	for i, state := range []string{"recording", "stopped"} {
+		n.notify(new_test_event(driver.EVENT_STATE_CHANGED, i, state))
+	}
+	n.persist()
+
+	// the oldest event is dropped with its outbox file, the newest is not written yet.
+	n.notify(new_test_event(driver.EVENT_STATE_CHANGED, 2, "recording"))
+	if names, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(names) != 1 {
+		t.Errorf("expect outbox file of dropped event removed, got %v", names)
+	}
+
+	n.persist()
+	if names, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(names) != 2 {
+		t.Errorf("expect outbox files of pending events, got %v", names)
+	}
+}
+
+func TestValidateWebhookOption(t *testing.T) {
+	for _, tc := range []struct {
+		opt    map[string]interface{}
+		expect []string
+	}{
+		{map[string]interface{}{"url": "http://127.0.0.1:8080/hook"}, nil},
+		{map[string]interface{}{"url": "https://example.com/hook", "events": []string{driver.EVENT_ERROR}}, nil},
+		{map[string]interface{}{}, []string{"webhook.url"}},
+		{map[string]interface{}{"url": "ftp://example.com"}, []string{"webhook.url"}},
+		{map[string]interface{}{"url": "http://127.0.0.1:8080/hook", "events": []string{"unknown"}}, []string{"webhook.events"}},
+	} {
+		v := viper.New()
+		if err := v.MergeConfigMap(tc.opt); err != nil {
+			t.Fatal(err)
+		}
+
+		var keys []string
+		if err := validate_webhook_option(v); err != nil {
+			for _, e := range err.(driver.ConfigErrors) {
+				keys = append(keys, e.Key)
+			}
+		}
+
+		if len(keys) != len(tc.expect) || (len(keys) > 0 && keys[0] != tc.expect[0]) {
+			t.Errorf("%v: expect errors of %v, got %v", tc.opt, tc.expect, keys)
+		}
+	}
+}
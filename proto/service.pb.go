@@ -7,6 +7,7 @@ import (
 	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	duration "github.com/golang/protobuf/ptypes/duration"
 	empty "github.com/golang/protobuf/ptypes/empty"
 	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	wrappers "github.com/golang/protobuf/ptypes/wrappers"
@@ -27,13 +28,102 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type GetTimelineRequestBucket_ int32
+
+const (
+	GetTimelineRequest_NONE   GetTimelineRequestBucket_ = 0
+	GetTimelineRequest_MINUTE GetTimelineRequestBucket_ = 1
+	GetTimelineRequest_HOUR   GetTimelineRequestBucket_ = 2
+)
+
+var GetTimelineRequestBucket__name = map[int32]string{
+	0: "NONE",
+	1: "MINUTE",
+	2: "HOUR",
+}
+
+var GetTimelineRequestBucket__value = map[string]int32{
+	"NONE":   0,
+	"MINUTE": 1,
+	"HOUR":   2,
+}
+
+func (x GetTimelineRequestBucket_) String() string {
+	return proto.EnumName(GetTimelineRequestBucket__name, int32(x))
+}
+
+func (GetTimelineRequestBucket_) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{15, 0}
+}
+
+type ImportRecordsRequestMode_ int32
+
+const (
+	// register files in place.
+	ImportRecordsRequest_KEEP ImportRecordsRequestMode_ = 0
+	// copy or move files into output file template layout.
+	ImportRecordsRequest_COPY ImportRecordsRequestMode_ = 1
+	ImportRecordsRequest_MOVE ImportRecordsRequestMode_ = 2
+)
+
+var ImportRecordsRequestMode__name = map[int32]string{
+	0: "KEEP",
+	1: "COPY",
+	2: "MOVE",
+}
+
+var ImportRecordsRequestMode__value = map[string]int32{
+	"KEEP": 0,
+	"COPY": 1,
+	"MOVE": 2,
+}
+
+func (x ImportRecordsRequestMode_) String() string {
+	return proto.EnumName(ImportRecordsRequestMode__name, int32(x))
+}
+
+func (ImportRecordsRequestMode_) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{19, 0}
+}
+
+type PrivacyMaskMode_ int32
+
+const (
+	PrivacyMask_FILL     PrivacyMaskMode_ = 0
+	PrivacyMask_PIXELATE PrivacyMaskMode_ = 1
+)
+
+var PrivacyMaskMode__name = map[int32]string{
+	0: "FILL",
+	1: "PIXELATE",
+}
+
+var PrivacyMaskMode__value = map[string]int32{
+	"FILL":     0,
+	"PIXELATE": 1,
+}
+
+func (x PrivacyMaskMode_) String() string {
+	return proto.EnumName(PrivacyMaskMode__name, int32(x))
+}
+
+func (PrivacyMaskMode_) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{28, 0}
+}
+
 type Record struct {
-	Id                   string               `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	StartAt              *timestamp.Timestamp `protobuf:"bytes,2,opt,name=start_at,json=startAt,proto3" json:"start_at,omitempty"`
-	EndAt                *timestamp.Timestamp `protobuf:"bytes,3,opt,name=end_at,json=endAt,proto3" json:"end_at,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
-	XXX_unrecognized     []byte               `json:"-"`
-	XXX_sizecache        int32                `json:"-"`
+	Id      string               `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	StartAt *timestamp.Timestamp `protobuf:"bytes,2,opt,name=start_at,json=startAt,proto3" json:"start_at,omitempty"`
+	EndAt   *timestamp.Timestamp `protobuf:"bytes,3,opt,name=end_at,json=endAt,proto3" json:"end_at,omitempty"`
+	// output profile of record.
+	Profile string `protobuf:"bytes,4,opt,name=profile,proto3" json:"profile,omitempty"`
+	// recorded in audio mode, no video stream.
+	AudioOnly bool `protobuf:"varint,5,opt,name=audio_only,json=audioOnly,proto3" json:"audio_only,omitempty"`
+	// post-processing results by hook name.
+	Hooks                map[string]*HookResult `protobuf:"bytes,6,rep,name=hooks,proto3" json:"hooks,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
 }
 
 func (m *Record) Reset()         { *m = Record{} }
@@ -82,6 +172,83 @@ func (m *Record) GetEndAt() *timestamp.Timestamp {
 	return nil
 }
 
+func (m *Record) GetProfile() string {
+	if m != nil {
+		return m.Profile
+	}
+	return ""
+}
+
+func (m *Record) GetAudioOnly() bool {
+	if m != nil {
+		return m.AudioOnly
+	}
+	return false
+}
+
+func (m *Record) GetHooks() map[string]*HookResult {
+	if m != nil {
+		return m.Hooks
+	}
+	return nil
+}
+
+type HookResult struct {
+	// ok, failed, timeout or skipped.
+	Status               string               `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string               `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	FinishedAt           *timestamp.Timestamp `protobuf:"bytes,3,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *HookResult) Reset()         { *m = HookResult{} }
+func (m *HookResult) String() string { return proto.CompactTextString(m) }
+func (*HookResult) ProtoMessage()    {}
+func (*HookResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{1}
+}
+
+func (m *HookResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HookResult.Unmarshal(m, b)
+}
+func (m *HookResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HookResult.Marshal(b, m, deterministic)
+}
+func (m *HookResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HookResult.Merge(m, src)
+}
+func (m *HookResult) XXX_Size() int {
+	return xxx_messageInfo_HookResult.Size(m)
+}
+func (m *HookResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_HookResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HookResult proto.InternalMessageInfo
+
+func (m *HookResult) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *HookResult) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *HookResult) GetFinishedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.FinishedAt
+	}
+	return nil
+}
+
 type OpRecord struct {
 	Id                   *wrappers.StringValue `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	StartAt              *timestamp.Timestamp  `protobuf:"bytes,2,opt,name=start_at,json=startAt,proto3" json:"start_at,omitempty"`
@@ -95,7 +262,7 @@ func (m *OpRecord) Reset()         { *m = OpRecord{} }
 func (m *OpRecord) String() string { return proto.CompactTextString(m) }
 func (*OpRecord) ProtoMessage()    {}
 func (*OpRecord) Descriptor() ([]byte, []int) {
-	return fileDescriptor_a0b84a42fa06f626, []int{1}
+	return fileDescriptor_a0b84a42fa06f626, []int{2}
 }
 
 func (m *OpRecord) XXX_Unmarshal(b []byte) error {
@@ -148,7 +315,7 @@ func (m *GetRecordRequest) Reset()         { *m = GetRecordRequest{} }
 func (m *GetRecordRequest) String() string { return proto.CompactTextString(m) }
 func (*GetRecordRequest) ProtoMessage()    {}
 func (*GetRecordRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_a0b84a42fa06f626, []int{2}
+	return fileDescriptor_a0b84a42fa06f626, []int{3}
 }
 
 func (m *GetRecordRequest) XXX_Unmarshal(b []byte) error {
@@ -187,7 +354,7 @@ func (m *GetRecordResponse) Reset()         { *m = GetRecordResponse{} }
 func (m *GetRecordResponse) String() string { return proto.CompactTextString(m) }
 func (*GetRecordResponse) ProtoMessage()    {}
 func (*GetRecordResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_a0b84a42fa06f626, []int{3}
+	return fileDescriptor_a0b84a42fa06f626, []int{4}
 }
 
 func (m *GetRecordResponse) XXX_Unmarshal(b []byte) error {
@@ -218,17 +385,19 @@ func (m *GetRecordResponse) GetRecord() *Record {
 type ListRecordsRequest struct {
 	// Types that are valid to be assigned to Filter:
 	//	*ListRecordsRequest_Range
-	Filter               isListRecordsRequest_Filter `protobuf_oneof:"filter"`
-	XXX_NoUnkeyedLiteral struct{}                    `json:"-"`
-	XXX_unrecognized     []byte                      `json:"-"`
-	XXX_sizecache        int32                       `json:"-"`
+	Filter isListRecordsRequest_Filter `protobuf_oneof:"filter"`
+	// output profile, all profiles if not set.
+	Profile              *wrappers.StringValue `protobuf:"bytes,2,opt,name=profile,proto3" json:"profile,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
 }
 
 func (m *ListRecordsRequest) Reset()         { *m = ListRecordsRequest{} }
 func (m *ListRecordsRequest) String() string { return proto.CompactTextString(m) }
 func (*ListRecordsRequest) ProtoMessage()    {}
 func (*ListRecordsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_a0b84a42fa06f626, []int{4}
+	return fileDescriptor_a0b84a42fa06f626, []int{5}
 }
 
 func (m *ListRecordsRequest) XXX_Unmarshal(b []byte) error {
@@ -273,6 +442,13 @@ func (m *ListRecordsRequest) GetRange() *ListRecordsRequestRange_ {
 	return nil
 }
 
+func (m *ListRecordsRequest) GetProfile() *wrappers.StringValue {
+	if m != nil {
+		return m.Profile
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*ListRecordsRequest) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
@@ -292,7 +468,7 @@ func (m *ListRecordsRequestRange_) Reset()         { *m = ListRecordsRequestRang
 func (m *ListRecordsRequestRange_) String() string { return proto.CompactTextString(m) }
 func (*ListRecordsRequestRange_) ProtoMessage()    {}
 func (*ListRecordsRequestRange_) Descriptor() ([]byte, []int) {
-	return fileDescriptor_a0b84a42fa06f626, []int{4, 0}
+	return fileDescriptor_a0b84a42fa06f626, []int{5, 0}
 }
 
 func (m *ListRecordsRequestRange_) XXX_Unmarshal(b []byte) error {
@@ -338,7 +514,7 @@ func (m *ListRecordsResponse) Reset()         { *m = ListRecordsResponse{} }
 func (m *ListRecordsResponse) String() string { return proto.CompactTextString(m) }
 func (*ListRecordsResponse) ProtoMessage()    {}
 func (*ListRecordsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_a0b84a42fa06f626, []int{5}
+	return fileDescriptor_a0b84a42fa06f626, []int{6}
 }
 
 func (m *ListRecordsResponse) XXX_Unmarshal(b []byte) error {
@@ -366,162 +542,1971 @@ func (m *ListRecordsResponse) GetRecords() []*Record {
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*Record)(nil), "ai.metathings.component.service.digit_video_recorder.Record")
-	proto.RegisterType((*OpRecord)(nil), "ai.metathings.component.service.digit_video_recorder.OpRecord")
-	proto.RegisterType((*GetRecordRequest)(nil), "ai.metathings.component.service.digit_video_recorder.GetRecordRequest")
-	proto.RegisterType((*GetRecordResponse)(nil), "ai.metathings.component.service.digit_video_recorder.GetRecordResponse")
-	proto.RegisterType((*ListRecordsRequest)(nil), "ai.metathings.component.service.digit_video_recorder.ListRecordsRequest")
-	proto.RegisterType((*ListRecordsRequestRange_)(nil), "ai.metathings.component.service.digit_video_recorder.ListRecordsRequest.range_")
-	proto.RegisterType((*ListRecordsResponse)(nil), "ai.metathings.component.service.digit_video_recorder.ListRecordsResponse")
+type ConfigError struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Reason               string   `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func init() { proto.RegisterFile("service.proto", fileDescriptor_a0b84a42fa06f626) }
+func (m *ConfigError) Reset()         { *m = ConfigError{} }
+func (m *ConfigError) String() string { return proto.CompactTextString(m) }
+func (*ConfigError) ProtoMessage()    {}
+func (*ConfigError) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{7}
+}
 
-var fileDescriptor_a0b84a42fa06f626 = []byte{
-	// 462 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x93, 0x41, 0x6b, 0x13, 0x41,
-	0x14, 0xc7, 0x33, 0x89, 0xd9, 0xa6, 0x2f, 0x28, 0x3a, 0x82, 0xc4, 0x55, 0x34, 0xec, 0xa9, 0x07,
-	0x99, 0x62, 0x54, 0x50, 0x10, 0xa1, 0xa2, 0xb6, 0x82, 0x50, 0xd8, 0x94, 0x5c, 0xc3, 0x36, 0xfb,
-	0xba, 0x8e, 0x64, 0x77, 0xc6, 0x99, 0x97, 0x8a, 0x57, 0x3f, 0x88, 0x07, 0xf1, 0x43, 0xf9, 0x5d,
-	0xbc, 0xc8, 0xee, 0xcc, 0x96, 0x76, 0x83, 0x28, 0xdb, 0xd0, 0xeb, 0xcc, 0x7b, 0xbf, 0xff, 0xff,
-	0xbd, 0xff, 0x0c, 0x5c, 0xb7, 0x68, 0x4e, 0xe5, 0x02, 0x85, 0x36, 0x8a, 0x14, 0x7f, 0x9a, 0x48,
-	0x91, 0x23, 0x25, 0xf4, 0x51, 0x16, 0x99, 0x15, 0x0b, 0x95, 0x6b, 0x55, 0x60, 0x41, 0xa2, 0x2e,
-	0x4b, 0x65, 0x26, 0x69, 0x7e, 0x2a, 0x53, 0x54, 0x73, 0x83, 0x0b, 0x65, 0x52, 0x34, 0xe1, 0xbd,
-	0x4c, 0xa9, 0x6c, 0x89, 0xbb, 0x15, 0xe3, 0x78, 0x75, 0xb2, 0x8b, 0xb9, 0xa6, 0xaf, 0x0e, 0x19,
-	0x3e, 0x68, 0x5e, 0x7e, 0x31, 0x89, 0xd6, 0x68, 0xac, 0xbf, 0x7f, 0xd8, 0xbc, 0x27, 0x99, 0xa3,
-	0xa5, 0x24, 0xd7, 0xae, 0x20, 0xfa, 0xc6, 0x20, 0x88, 0x2b, 0x29, 0x7e, 0x03, 0xba, 0x32, 0x1d,
-	0xb1, 0x31, 0xdb, 0xd9, 0x8e, 0xbb, 0x32, 0xe5, 0xcf, 0x60, 0x60, 0x29, 0x31, 0x34, 0x4f, 0x68,
-	0xd4, 0x1d, 0xb3, 0x9d, 0xe1, 0x24, 0x14, 0x0e, 0x27, 0x6a, 0x9c, 0x38, 0xaa, 0x71, 0xf1, 0x56,
-	0x55, 0xbb, 0x47, 0xfc, 0x31, 0x04, 0x58, 0xa4, 0x65, 0x53, 0xef, 0x9f, 0x4d, 0x7d, 0x2c, 0xd2,
-	0x3d, 0x8a, 0x7e, 0x30, 0x18, 0x1c, 0x6a, 0x6f, 0xe3, 0xd1, 0x99, 0x8d, 0xe1, 0xe4, 0xfe, 0x5a,
-	0xef, 0x94, 0x8c, 0x2c, 0xb2, 0x59, 0xb2, 0x5c, 0xe1, 0x15, 0x9b, 0xfc, 0x04, 0x37, 0xf7, 0x91,
-	0x9c, 0xc9, 0x18, 0x3f, 0xaf, 0xd0, 0x12, 0x9f, 0x41, 0xe0, 0x72, 0xf2, 0x7e, 0x5f, 0x89, 0x36,
-	0x11, 0x8b, 0x7a, 0xf6, 0xd8, 0xd3, 0x22, 0x09, 0xb7, 0xce, 0x69, 0x59, 0xad, 0x0a, 0x8b, 0xfc,
-	0xa8, 0x21, 0xf6, 0xb2, 0x9d, 0x58, 0x43, 0xea, 0x37, 0x03, 0xfe, 0x41, 0x5a, 0x2f, 0x66, 0xeb,
-	0xc9, 0x32, 0xe8, 0x9b, 0xa4, 0xc8, 0xd0, 0x6b, 0x1d, 0xb6, 0xd3, 0x5a, 0x07, 0x8b, 0x8a, 0x3a,
-	0x3f, 0xe8, 0xc4, 0x8e, 0x1f, 0x1a, 0x08, 0xdc, 0xd1, 0x85, 0x28, 0x59, 0x9b, 0x28, 0xbb, 0xff,
-	0x19, 0xe5, 0xeb, 0x01, 0x04, 0x27, 0x72, 0x49, 0x68, 0xa2, 0x1c, 0x6e, 0x5f, 0xf0, 0xe8, 0x57,
-	0x3d, 0x83, 0x2d, 0x37, 0x83, 0x1d, 0xb1, 0x71, 0xef, 0xd2, 0xbb, 0xae, 0x61, 0x93, 0x5f, 0x3d,
-	0xb8, 0xfb, 0xa6, 0x2c, 0x9c, 0x95, 0x75, 0xb1, 0x2f, 0x9b, 0x3a, 0x04, 0x7f, 0x01, 0xfd, 0x69,
-	0x39, 0x14, 0xbf, 0xb3, 0x36, 0xc2, 0xdb, 0xf2, 0xcf, 0x87, 0x7f, 0x39, 0x8f, 0x3a, 0xfc, 0x39,
-	0x5c, 0x9b, 0x92, 0xd2, 0x2d, 0x3a, 0xbf, 0x33, 0xd8, 0x3e, 0x7b, 0x6b, 0xfc, 0x5d, 0xbb, 0x39,
-	0x9b, 0x1f, 0x23, 0xdc, 0xbf, 0x34, 0xc7, 0x25, 0x11, 0x75, 0xf8, 0x4f, 0x06, 0xc3, 0x73, 0x19,
-	0xf1, 0x83, 0x4d, 0x3d, 0xc5, 0xf0, 0xfd, 0x06, 0x48, 0xb5, 0xcd, 0xe3, 0xa0, 0xda, 0xec, 0x93,
-	0x3f, 0x01, 0x00, 0x00, 0xff, 0xff, 0xcd, 0xe9, 0x43, 0xea, 0xf4, 0x05, 0x00, 0x00,
+func (m *ConfigError) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ConfigError.Unmarshal(m, b)
+}
+func (m *ConfigError) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ConfigError.Marshal(b, m, deterministic)
+}
+func (m *ConfigError) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConfigError.Merge(m, src)
+}
+func (m *ConfigError) XXX_Size() int {
+	return xxx_messageInfo_ConfigError.Size(m)
+}
+func (m *ConfigError) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConfigError.DiscardUnknown(m)
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+var xxx_messageInfo_ConfigError proto.InternalMessageInfo
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func (m *ConfigError) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
 
-// DigitVideoRecorderServiceClient is the client API for DigitVideoRecorderService service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type DigitVideoRecorderServiceClient interface {
-	Start(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
-	Stop(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
-	GetRecord(ctx context.Context, in *GetRecordRequest, opts ...grpc.CallOption) (*GetRecordResponse, error)
-	ListRecords(ctx context.Context, in *ListRecordsRequest, opts ...grpc.CallOption) (*ListRecordsResponse, error)
+func (m *ConfigError) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
 }
 
-type digitVideoRecorderServiceClient struct {
-	cc *grpc.ClientConn
+type ValidateConfigRequest struct {
+	// driver config in yaml, validate current driver, playback, metrics and webhook config if not set.
+	Config               *wrappers.StringValue `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
 }
 
-func NewDigitVideoRecorderServiceClient(cc *grpc.ClientConn) DigitVideoRecorderServiceClient {
-	return &digitVideoRecorderServiceClient{cc}
+func (m *ValidateConfigRequest) Reset()         { *m = ValidateConfigRequest{} }
+func (m *ValidateConfigRequest) String() string { return proto.CompactTextString(m) }
+func (*ValidateConfigRequest) ProtoMessage()    {}
+func (*ValidateConfigRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{8}
 }
 
-func (c *digitVideoRecorderServiceClient) Start(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error) {
-	out := new(empty.Empty)
-	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/Start", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *ValidateConfigRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ValidateConfigRequest.Unmarshal(m, b)
+}
+func (m *ValidateConfigRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ValidateConfigRequest.Marshal(b, m, deterministic)
+}
+func (m *ValidateConfigRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ValidateConfigRequest.Merge(m, src)
+}
+func (m *ValidateConfigRequest) XXX_Size() int {
+	return xxx_messageInfo_ValidateConfigRequest.Size(m)
+}
+func (m *ValidateConfigRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ValidateConfigRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ValidateConfigRequest proto.InternalMessageInfo
+
+func (m *ValidateConfigRequest) GetConfig() *wrappers.StringValue {
+	if m != nil {
+		return m.Config
 	}
-	return out, nil
+	return nil
 }
 
-func (c *digitVideoRecorderServiceClient) Stop(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error) {
-	out := new(empty.Empty)
-	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/Stop", in, out, opts...)
-	if err != nil {
-		return nil, err
+type ValidateConfigResponse struct {
+	Valid                bool           `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	Errors               []*ConfigError `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *ValidateConfigResponse) Reset()         { *m = ValidateConfigResponse{} }
+func (m *ValidateConfigResponse) String() string { return proto.CompactTextString(m) }
+func (*ValidateConfigResponse) ProtoMessage()    {}
+func (*ValidateConfigResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{9}
+}
+
+func (m *ValidateConfigResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ValidateConfigResponse.Unmarshal(m, b)
+}
+func (m *ValidateConfigResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ValidateConfigResponse.Marshal(b, m, deterministic)
+}
+func (m *ValidateConfigResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ValidateConfigResponse.Merge(m, src)
+}
+func (m *ValidateConfigResponse) XXX_Size() int {
+	return xxx_messageInfo_ValidateConfigResponse.Size(m)
+}
+func (m *ValidateConfigResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ValidateConfigResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ValidateConfigResponse proto.InternalMessageInfo
+
+func (m *ValidateConfigResponse) GetValid() bool {
+	if m != nil {
+		return m.Valid
 	}
-	return out, nil
+	return false
 }
 
-func (c *digitVideoRecorderServiceClient) GetRecord(ctx context.Context, in *GetRecordRequest, opts ...grpc.CallOption) (*GetRecordResponse, error) {
-	out := new(GetRecordResponse)
-	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/GetRecord", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *ValidateConfigResponse) GetErrors() []*ConfigError {
+	if m != nil {
+		return m.Errors
 	}
-	return out, nil
+	return nil
 }
 
-func (c *digitVideoRecorderServiceClient) ListRecords(ctx context.Context, in *ListRecordsRequest, opts ...grpc.CallOption) (*ListRecordsResponse, error) {
-	out := new(ListRecordsResponse)
-	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/ListRecords", in, out, opts...)
-	if err != nil {
-		return nil, err
+type ReconfigureRequest struct {
+	// driver config in yaml.
+	Config               *wrappers.StringValue `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *ReconfigureRequest) Reset()         { *m = ReconfigureRequest{} }
+func (m *ReconfigureRequest) String() string { return proto.CompactTextString(m) }
+func (*ReconfigureRequest) ProtoMessage()    {}
+func (*ReconfigureRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{10}
+}
+
+func (m *ReconfigureRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReconfigureRequest.Unmarshal(m, b)
+}
+func (m *ReconfigureRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReconfigureRequest.Marshal(b, m, deterministic)
+}
+func (m *ReconfigureRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReconfigureRequest.Merge(m, src)
+}
+func (m *ReconfigureRequest) XXX_Size() int {
+	return xxx_messageInfo_ReconfigureRequest.Size(m)
+}
+func (m *ReconfigureRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReconfigureRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReconfigureRequest proto.InternalMessageInfo
+
+func (m *ReconfigureRequest) GetConfig() *wrappers.StringValue {
+	if m != nil {
+		return m.Config
 	}
-	return out, nil
+	return nil
 }
 
-// DigitVideoRecorderServiceServer is the server API for DigitVideoRecorderService service.
-type DigitVideoRecorderServiceServer interface {
-	Start(context.Context, *empty.Empty) (*empty.Empty, error)
-	Stop(context.Context, *empty.Empty) (*empty.Empty, error)
-	GetRecord(context.Context, *GetRecordRequest) (*GetRecordResponse, error)
-	ListRecords(context.Context, *ListRecordsRequest) (*ListRecordsResponse, error)
+type RecordingStats struct {
+	State  string  `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	Frames uint64  `protobuf:"varint,2,opt,name=frames,proto3" json:"frames,omitempty"`
+	Fps    float64 `protobuf:"fixed64,3,opt,name=fps,proto3" json:"fps,omitempty"`
+	// kbits/s
+	Bitrate              float64              `protobuf:"fixed64,4,opt,name=bitrate,proto3" json:"bitrate,omitempty"`
+	TotalSize            uint64               `protobuf:"varint,5,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	OutTime              *duration.Duration   `protobuf:"bytes,6,opt,name=out_time,json=outTime,proto3" json:"out_time,omitempty"`
+	DupFrames            uint64               `protobuf:"varint,7,opt,name=dup_frames,json=dupFrames,proto3" json:"dup_frames,omitempty"`
+	DropFrames           uint64               `protobuf:"varint,8,opt,name=drop_frames,json=dropFrames,proto3" json:"drop_frames,omitempty"`
+	Speed                float64              `protobuf:"fixed64,9,opt,name=speed,proto3" json:"speed,omitempty"`
+	SegmentFile          string               `protobuf:"bytes,10,opt,name=segment_file,json=segmentFile,proto3" json:"segment_file,omitempty"`
+	SegmentSize          uint64               `protobuf:"varint,11,opt,name=segment_size,json=segmentSize,proto3" json:"segment_size,omitempty"`
+	StartedAt            *timestamp.Timestamp `protobuf:"bytes,12,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	UpdatedAt            *timestamp.Timestamp `protobuf:"bytes,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Restarts             uint64               `protobuf:"varint,14,opt,name=restarts,proto3" json:"restarts,omitempty"`
+	SegmentsCommitted    uint64               `protobuf:"varint,15,opt,name=segments_committed,json=segmentsCommitted,proto3" json:"segments_committed,omitempty"`
+	SegmentFailures      uint64               `protobuf:"varint,16,opt,name=segment_failures,json=segmentFailures,proto3" json:"segment_failures,omitempty"`
+	BytesWritten         uint64               `protobuf:"varint,17,opt,name=bytes_written,json=bytesWritten,proto3" json:"bytes_written,omitempty"`
+	Stalls               uint64               `protobuf:"varint,18,opt,name=stalls,proto3" json:"stalls,omitempty"`
+	LastStallAt          *timestamp.Timestamp `protobuf:"bytes,19,opt,name=last_stall_at,json=lastStallAt,proto3" json:"last_stall_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
-// UnimplementedDigitVideoRecorderServiceServer can be embedded to have forward compatible implementations.
-type UnimplementedDigitVideoRecorderServiceServer struct {
+func (m *RecordingStats) Reset()         { *m = RecordingStats{} }
+func (m *RecordingStats) String() string { return proto.CompactTextString(m) }
+func (*RecordingStats) ProtoMessage()    {}
+func (*RecordingStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{11}
 }
 
-func (*UnimplementedDigitVideoRecorderServiceServer) Start(ctx context.Context, req *empty.Empty) (*empty.Empty, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Start not implemented")
+func (m *RecordingStats) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecordingStats.Unmarshal(m, b)
 }
-func (*UnimplementedDigitVideoRecorderServiceServer) Stop(ctx context.Context, req *empty.Empty) (*empty.Empty, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Stop not implemented")
+func (m *RecordingStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecordingStats.Marshal(b, m, deterministic)
 }
-func (*UnimplementedDigitVideoRecorderServiceServer) GetRecord(ctx context.Context, req *GetRecordRequest) (*GetRecordResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetRecord not implemented")
+func (m *RecordingStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecordingStats.Merge(m, src)
 }
-func (*UnimplementedDigitVideoRecorderServiceServer) ListRecords(ctx context.Context, req *ListRecordsRequest) (*ListRecordsResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ListRecords not implemented")
+func (m *RecordingStats) XXX_Size() int {
+	return xxx_messageInfo_RecordingStats.Size(m)
+}
+func (m *RecordingStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecordingStats.DiscardUnknown(m)
 }
 
-func RegisterDigitVideoRecorderServiceServer(s *grpc.Server, srv DigitVideoRecorderServiceServer) {
-	s.RegisterService(&_DigitVideoRecorderService_serviceDesc, srv)
+var xxx_messageInfo_RecordingStats proto.InternalMessageInfo
+
+func (m *RecordingStats) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
 }
 
-func _DigitVideoRecorderService_Start_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(empty.Empty)
-	if err := dec(in); err != nil {
-		return nil, err
+func (m *RecordingStats) GetFrames() uint64 {
+	if m != nil {
+		return m.Frames
 	}
-	if interceptor == nil {
-		return srv.(DigitVideoRecorderServiceServer).Start(ctx, in)
+	return 0
+}
+
+func (m *RecordingStats) GetFps() float64 {
+	if m != nil {
+		return m.Fps
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/Start",
+	return 0
+}
+
+func (m *RecordingStats) GetBitrate() float64 {
+	if m != nil {
+		return m.Bitrate
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(DigitVideoRecorderServiceServer).Start(ctx, req.(*empty.Empty))
+	return 0
+}
+
+func (m *RecordingStats) GetTotalSize() uint64 {
+	if m != nil {
+		return m.TotalSize
 	}
-	return interceptor(ctx, in, info, handler)
+	return 0
 }
 
-func _DigitVideoRecorderService_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(empty.Empty)
+func (m *RecordingStats) GetOutTime() *duration.Duration {
+	if m != nil {
+		return m.OutTime
+	}
+	return nil
+}
+
+func (m *RecordingStats) GetDupFrames() uint64 {
+	if m != nil {
+		return m.DupFrames
+	}
+	return 0
+}
+
+func (m *RecordingStats) GetDropFrames() uint64 {
+	if m != nil {
+		return m.DropFrames
+	}
+	return 0
+}
+
+func (m *RecordingStats) GetSpeed() float64 {
+	if m != nil {
+		return m.Speed
+	}
+	return 0
+}
+
+func (m *RecordingStats) GetSegmentFile() string {
+	if m != nil {
+		return m.SegmentFile
+	}
+	return ""
+}
+
+func (m *RecordingStats) GetSegmentSize() uint64 {
+	if m != nil {
+		return m.SegmentSize
+	}
+	return 0
+}
+
+func (m *RecordingStats) GetStartedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.StartedAt
+	}
+	return nil
+}
+
+func (m *RecordingStats) GetUpdatedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.UpdatedAt
+	}
+	return nil
+}
+
+func (m *RecordingStats) GetRestarts() uint64 {
+	if m != nil {
+		return m.Restarts
+	}
+	return 0
+}
+
+func (m *RecordingStats) GetSegmentsCommitted() uint64 {
+	if m != nil {
+		return m.SegmentsCommitted
+	}
+	return 0
+}
+
+func (m *RecordingStats) GetSegmentFailures() uint64 {
+	if m != nil {
+		return m.SegmentFailures
+	}
+	return 0
+}
+
+func (m *RecordingStats) GetBytesWritten() uint64 {
+	if m != nil {
+		return m.BytesWritten
+	}
+	return 0
+}
+
+func (m *RecordingStats) GetStalls() uint64 {
+	if m != nil {
+		return m.Stalls
+	}
+	return 0
+}
+
+func (m *RecordingStats) GetLastStallAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastStallAt
+	}
+	return nil
+}
+
+type GetStatsResponse struct {
+	Stats                *RecordingStats `protobuf:"bytes,1,opt,name=stats,proto3" json:"stats,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *GetStatsResponse) Reset()         { *m = GetStatsResponse{} }
+func (m *GetStatsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetStatsResponse) ProtoMessage()    {}
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{12}
+}
+
+func (m *GetStatsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetStatsResponse.Unmarshal(m, b)
+}
+func (m *GetStatsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetStatsResponse.Marshal(b, m, deterministic)
+}
+func (m *GetStatsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetStatsResponse.Merge(m, src)
+}
+func (m *GetStatsResponse) XXX_Size() int {
+	return xxx_messageInfo_GetStatsResponse.Size(m)
+}
+func (m *GetStatsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetStatsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetStatsResponse proto.InternalMessageInfo
+
+func (m *GetStatsResponse) GetStats() *RecordingStats {
+	if m != nil {
+		return m.Stats
+	}
+	return nil
+}
+
+type TimelineSpan struct {
+	StartAt              *timestamp.Timestamp `protobuf:"bytes,1,opt,name=start_at,json=startAt,proto3" json:"start_at,omitempty"`
+	EndAt                *timestamp.Timestamp `protobuf:"bytes,2,opt,name=end_at,json=endAt,proto3" json:"end_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *TimelineSpan) Reset()         { *m = TimelineSpan{} }
+func (m *TimelineSpan) String() string { return proto.CompactTextString(m) }
+func (*TimelineSpan) ProtoMessage()    {}
+func (*TimelineSpan) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{13}
+}
+
+func (m *TimelineSpan) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TimelineSpan.Unmarshal(m, b)
+}
+func (m *TimelineSpan) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TimelineSpan.Marshal(b, m, deterministic)
+}
+func (m *TimelineSpan) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TimelineSpan.Merge(m, src)
+}
+func (m *TimelineSpan) XXX_Size() int {
+	return xxx_messageInfo_TimelineSpan.Size(m)
+}
+func (m *TimelineSpan) XXX_DiscardUnknown() {
+	xxx_messageInfo_TimelineSpan.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TimelineSpan proto.InternalMessageInfo
+
+func (m *TimelineSpan) GetStartAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.StartAt
+	}
+	return nil
+}
+
+func (m *TimelineSpan) GetEndAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.EndAt
+	}
+	return nil
+}
+
+type TimelineBucket struct {
+	StartAt *timestamp.Timestamp `protobuf:"bytes,1,opt,name=start_at,json=startAt,proto3" json:"start_at,omitempty"`
+	EndAt   *timestamp.Timestamp `protobuf:"bytes,2,opt,name=end_at,json=endAt,proto3" json:"end_at,omitempty"`
+	// recorded duration in bucket.
+	Recorded             *duration.Duration `protobuf:"bytes,3,opt,name=recorded,proto3" json:"recorded,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *TimelineBucket) Reset()         { *m = TimelineBucket{} }
+func (m *TimelineBucket) String() string { return proto.CompactTextString(m) }
+func (*TimelineBucket) ProtoMessage()    {}
+func (*TimelineBucket) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{14}
+}
+
+func (m *TimelineBucket) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TimelineBucket.Unmarshal(m, b)
+}
+func (m *TimelineBucket) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TimelineBucket.Marshal(b, m, deterministic)
+}
+func (m *TimelineBucket) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TimelineBucket.Merge(m, src)
+}
+func (m *TimelineBucket) XXX_Size() int {
+	return xxx_messageInfo_TimelineBucket.Size(m)
+}
+func (m *TimelineBucket) XXX_DiscardUnknown() {
+	xxx_messageInfo_TimelineBucket.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TimelineBucket proto.InternalMessageInfo
+
+func (m *TimelineBucket) GetStartAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.StartAt
+	}
+	return nil
+}
+
+func (m *TimelineBucket) GetEndAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.EndAt
+	}
+	return nil
+}
+
+func (m *TimelineBucket) GetRecorded() *duration.Duration {
+	if m != nil {
+		return m.Recorded
+	}
+	return nil
+}
+
+type GetTimelineRequest struct {
+	StartAt *timestamp.Timestamp      `protobuf:"bytes,1,opt,name=start_at,json=startAt,proto3" json:"start_at,omitempty"`
+	EndAt   *timestamp.Timestamp      `protobuf:"bytes,2,opt,name=end_at,json=endAt,proto3" json:"end_at,omitempty"`
+	Bucket  GetTimelineRequestBucket_ `protobuf:"varint,3,opt,name=bucket,proto3,enum=ai.metathings.component.service.digit_video_recorder.GetTimelineRequestBucket_" json:"bucket,omitempty"`
+	// records closer than tolerance are merged into one span, default 0.
+	Tolerance *duration.Duration `protobuf:"bytes,4,opt,name=tolerance,proto3" json:"tolerance,omitempty"`
+	// output profile, all profiles if not set.
+	Profile              *wrappers.StringValue `protobuf:"bytes,5,opt,name=profile,proto3" json:"profile,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *GetTimelineRequest) Reset()         { *m = GetTimelineRequest{} }
+func (m *GetTimelineRequest) String() string { return proto.CompactTextString(m) }
+func (*GetTimelineRequest) ProtoMessage()    {}
+func (*GetTimelineRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{15}
+}
+
+func (m *GetTimelineRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTimelineRequest.Unmarshal(m, b)
+}
+func (m *GetTimelineRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTimelineRequest.Marshal(b, m, deterministic)
+}
+func (m *GetTimelineRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTimelineRequest.Merge(m, src)
+}
+func (m *GetTimelineRequest) XXX_Size() int {
+	return xxx_messageInfo_GetTimelineRequest.Size(m)
+}
+func (m *GetTimelineRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTimelineRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTimelineRequest proto.InternalMessageInfo
+
+func (m *GetTimelineRequest) GetStartAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.StartAt
+	}
+	return nil
+}
+
+func (m *GetTimelineRequest) GetEndAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.EndAt
+	}
+	return nil
+}
+
+func (m *GetTimelineRequest) GetBucket() GetTimelineRequestBucket_ {
+	if m != nil {
+		return m.Bucket
+	}
+	return GetTimelineRequest_NONE
+}
+
+func (m *GetTimelineRequest) GetTolerance() *duration.Duration {
+	if m != nil {
+		return m.Tolerance
+	}
+	return nil
+}
+
+func (m *GetTimelineRequest) GetProfile() *wrappers.StringValue {
+	if m != nil {
+		return m.Profile
+	}
+	return nil
+}
+
+type GetTimelineResponse struct {
+	Spans                []*TimelineSpan   `protobuf:"bytes,1,rep,name=spans,proto3" json:"spans,omitempty"`
+	Gaps                 []*TimelineSpan   `protobuf:"bytes,2,rep,name=gaps,proto3" json:"gaps,omitempty"`
+	Buckets              []*TimelineBucket `protobuf:"bytes,3,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *GetTimelineResponse) Reset()         { *m = GetTimelineResponse{} }
+func (m *GetTimelineResponse) String() string { return proto.CompactTextString(m) }
+func (*GetTimelineResponse) ProtoMessage()    {}
+func (*GetTimelineResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{16}
+}
+
+func (m *GetTimelineResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTimelineResponse.Unmarshal(m, b)
+}
+func (m *GetTimelineResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTimelineResponse.Marshal(b, m, deterministic)
+}
+func (m *GetTimelineResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTimelineResponse.Merge(m, src)
+}
+func (m *GetTimelineResponse) XXX_Size() int {
+	return xxx_messageInfo_GetTimelineResponse.Size(m)
+}
+func (m *GetTimelineResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTimelineResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTimelineResponse proto.InternalMessageInfo
+
+func (m *GetTimelineResponse) GetSpans() []*TimelineSpan {
+	if m != nil {
+		return m.Spans
+	}
+	return nil
+}
+
+func (m *GetTimelineResponse) GetGaps() []*TimelineSpan {
+	if m != nil {
+		return m.Gaps
+	}
+	return nil
+}
+
+func (m *GetTimelineResponse) GetBuckets() []*TimelineBucket {
+	if m != nil {
+		return m.Buckets
+	}
+	return nil
+}
+
+type FindRecordAtRequest struct {
+	Timestamp *timestamp.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// output profile, all profiles if not set.
+	Profile              *wrappers.StringValue `protobuf:"bytes,2,opt,name=profile,proto3" json:"profile,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *FindRecordAtRequest) Reset()         { *m = FindRecordAtRequest{} }
+func (m *FindRecordAtRequest) String() string { return proto.CompactTextString(m) }
+func (*FindRecordAtRequest) ProtoMessage()    {}
+func (*FindRecordAtRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{17}
+}
+
+func (m *FindRecordAtRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FindRecordAtRequest.Unmarshal(m, b)
+}
+func (m *FindRecordAtRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FindRecordAtRequest.Marshal(b, m, deterministic)
+}
+func (m *FindRecordAtRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FindRecordAtRequest.Merge(m, src)
+}
+func (m *FindRecordAtRequest) XXX_Size() int {
+	return xxx_messageInfo_FindRecordAtRequest.Size(m)
+}
+func (m *FindRecordAtRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FindRecordAtRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FindRecordAtRequest proto.InternalMessageInfo
+
+func (m *FindRecordAtRequest) GetTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.Timestamp
+	}
+	return nil
+}
+
+func (m *FindRecordAtRequest) GetProfile() *wrappers.StringValue {
+	if m != nil {
+		return m.Profile
+	}
+	return nil
+}
+
+type FindRecordAtResponse struct {
+	// record covering timestamp, not set if timestamp in a gap.
+	Record *Record `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`
+	// offset of timestamp into record.
+	Offset *duration.Duration `protobuf:"bytes,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	// nearest records around the gap, set if timestamp in a gap.
+	Previous             *Record  `protobuf:"bytes,3,opt,name=previous,proto3" json:"previous,omitempty"`
+	Next                 *Record  `protobuf:"bytes,4,opt,name=next,proto3" json:"next,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FindRecordAtResponse) Reset()         { *m = FindRecordAtResponse{} }
+func (m *FindRecordAtResponse) String() string { return proto.CompactTextString(m) }
+func (*FindRecordAtResponse) ProtoMessage()    {}
+func (*FindRecordAtResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{18}
+}
+
+func (m *FindRecordAtResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FindRecordAtResponse.Unmarshal(m, b)
+}
+func (m *FindRecordAtResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FindRecordAtResponse.Marshal(b, m, deterministic)
+}
+func (m *FindRecordAtResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FindRecordAtResponse.Merge(m, src)
+}
+func (m *FindRecordAtResponse) XXX_Size() int {
+	return xxx_messageInfo_FindRecordAtResponse.Size(m)
+}
+func (m *FindRecordAtResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_FindRecordAtResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FindRecordAtResponse proto.InternalMessageInfo
+
+func (m *FindRecordAtResponse) GetRecord() *Record {
+	if m != nil {
+		return m.Record
+	}
+	return nil
+}
+
+func (m *FindRecordAtResponse) GetOffset() *duration.Duration {
+	if m != nil {
+		return m.Offset
+	}
+	return nil
+}
+
+func (m *FindRecordAtResponse) GetPrevious() *Record {
+	if m != nil {
+		return m.Previous
+	}
+	return nil
+}
+
+func (m *FindRecordAtResponse) GetNext() *Record {
+	if m != nil {
+		return m.Next
+	}
+	return nil
+}
+
+type ImportRecordsRequest struct {
+	// files or directories on recorder, under import_roots of driver.
+	Paths []string                  `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
+	Mode  ImportRecordsRequestMode_ `protobuf:"varint,2,opt,name=mode,proto3,enum=ai.metathings.component.service.digit_video_recorder.ImportRecordsRequestMode_" json:"mode,omitempty"`
+	// output profile, the first profile if not set.
+	Profile *wrappers.StringValue `protobuf:"bytes,3,opt,name=profile,proto3" json:"profile,omitempty"`
+	// go time layout of file name, like `cam1-20060102-150405`.
+	TimeLayout           *wrappers.StringValue `protobuf:"bytes,4,opt,name=time_layout,json=timeLayout,proto3" json:"time_layout,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *ImportRecordsRequest) Reset()         { *m = ImportRecordsRequest{} }
+func (m *ImportRecordsRequest) String() string { return proto.CompactTextString(m) }
+func (*ImportRecordsRequest) ProtoMessage()    {}
+func (*ImportRecordsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{19}
+}
+
+func (m *ImportRecordsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ImportRecordsRequest.Unmarshal(m, b)
+}
+func (m *ImportRecordsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ImportRecordsRequest.Marshal(b, m, deterministic)
+}
+func (m *ImportRecordsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ImportRecordsRequest.Merge(m, src)
+}
+func (m *ImportRecordsRequest) XXX_Size() int {
+	return xxx_messageInfo_ImportRecordsRequest.Size(m)
+}
+func (m *ImportRecordsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ImportRecordsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ImportRecordsRequest proto.InternalMessageInfo
+
+func (m *ImportRecordsRequest) GetPaths() []string {
+	if m != nil {
+		return m.Paths
+	}
+	return nil
+}
+
+func (m *ImportRecordsRequest) GetMode() ImportRecordsRequestMode_ {
+	if m != nil {
+		return m.Mode
+	}
+	return ImportRecordsRequest_KEEP
+}
+
+func (m *ImportRecordsRequest) GetProfile() *wrappers.StringValue {
+	if m != nil {
+		return m.Profile
+	}
+	return nil
+}
+
+func (m *ImportRecordsRequest) GetTimeLayout() *wrappers.StringValue {
+	if m != nil {
+		return m.TimeLayout
+	}
+	return nil
+}
+
+type ImportFailure struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Reason               string   `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ImportFailure) Reset()         { *m = ImportFailure{} }
+func (m *ImportFailure) String() string { return proto.CompactTextString(m) }
+func (*ImportFailure) ProtoMessage()    {}
+func (*ImportFailure) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{20}
+}
+
+func (m *ImportFailure) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ImportFailure.Unmarshal(m, b)
+}
+func (m *ImportFailure) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ImportFailure.Marshal(b, m, deterministic)
+}
+func (m *ImportFailure) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ImportFailure.Merge(m, src)
+}
+func (m *ImportFailure) XXX_Size() int {
+	return xxx_messageInfo_ImportFailure.Size(m)
+}
+func (m *ImportFailure) XXX_DiscardUnknown() {
+	xxx_messageInfo_ImportFailure.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ImportFailure proto.InternalMessageInfo
+
+func (m *ImportFailure) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *ImportFailure) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type ImportRecordsResponse struct {
+	Imported []*Record `protobuf:"bytes,1,rep,name=imported,proto3" json:"imported,omitempty"`
+	// files imported before.
+	Skipped              []string         `protobuf:"bytes,2,rep,name=skipped,proto3" json:"skipped,omitempty"`
+	Failed               []*ImportFailure `protobuf:"bytes,3,rep,name=failed,proto3" json:"failed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *ImportRecordsResponse) Reset()         { *m = ImportRecordsResponse{} }
+func (m *ImportRecordsResponse) String() string { return proto.CompactTextString(m) }
+func (*ImportRecordsResponse) ProtoMessage()    {}
+func (*ImportRecordsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{21}
+}
+
+func (m *ImportRecordsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ImportRecordsResponse.Unmarshal(m, b)
+}
+func (m *ImportRecordsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ImportRecordsResponse.Marshal(b, m, deterministic)
+}
+func (m *ImportRecordsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ImportRecordsResponse.Merge(m, src)
+}
+func (m *ImportRecordsResponse) XXX_Size() int {
+	return xxx_messageInfo_ImportRecordsResponse.Size(m)
+}
+func (m *ImportRecordsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ImportRecordsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ImportRecordsResponse proto.InternalMessageInfo
+
+func (m *ImportRecordsResponse) GetImported() []*Record {
+	if m != nil {
+		return m.Imported
+	}
+	return nil
+}
+
+func (m *ImportRecordsResponse) GetSkipped() []string {
+	if m != nil {
+		return m.Skipped
+	}
+	return nil
+}
+
+func (m *ImportRecordsResponse) GetFailed() []*ImportFailure {
+	if m != nil {
+		return m.Failed
+	}
+	return nil
+}
+
+type ReindexRecordsResponse struct {
+	Recovered []*Record `protobuf:"bytes,1,rep,name=recovered,proto3" json:"recovered,omitempty"`
+	// files already in record storage.
+	Indexed int32 `protobuf:"varint,2,opt,name=indexed,proto3" json:"indexed,omitempty"`
+	// files not mapped to records.
+	Unmapped             []*ImportFailure `protobuf:"bytes,3,rep,name=unmapped,proto3" json:"unmapped,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *ReindexRecordsResponse) Reset()         { *m = ReindexRecordsResponse{} }
+func (m *ReindexRecordsResponse) String() string { return proto.CompactTextString(m) }
+func (*ReindexRecordsResponse) ProtoMessage()    {}
+func (*ReindexRecordsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{22}
+}
+
+func (m *ReindexRecordsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReindexRecordsResponse.Unmarshal(m, b)
+}
+func (m *ReindexRecordsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReindexRecordsResponse.Marshal(b, m, deterministic)
+}
+func (m *ReindexRecordsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReindexRecordsResponse.Merge(m, src)
+}
+func (m *ReindexRecordsResponse) XXX_Size() int {
+	return xxx_messageInfo_ReindexRecordsResponse.Size(m)
+}
+func (m *ReindexRecordsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReindexRecordsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReindexRecordsResponse proto.InternalMessageInfo
+
+func (m *ReindexRecordsResponse) GetRecovered() []*Record {
+	if m != nil {
+		return m.Recovered
+	}
+	return nil
+}
+
+func (m *ReindexRecordsResponse) GetIndexed() int32 {
+	if m != nil {
+		return m.Indexed
+	}
+	return 0
+}
+
+func (m *ReindexRecordsResponse) GetUnmapped() []*ImportFailure {
+	if m != nil {
+		return m.Unmapped
+	}
+	return nil
+}
+
+type IndexBackup struct {
+	// backup file path relative to backup_dir of driver.
+	Path      string               `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Records   int32                `protobuf:"varint,2,opt,name=records,proto3" json:"records,omitempty"`
+	CreatedAt *timestamp.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// sha256 of backup file content.
+	Checksum             string   `protobuf:"bytes,4,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *IndexBackup) Reset()         { *m = IndexBackup{} }
+func (m *IndexBackup) String() string { return proto.CompactTextString(m) }
+func (*IndexBackup) ProtoMessage()    {}
+func (*IndexBackup) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{23}
+}
+
+func (m *IndexBackup) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_IndexBackup.Unmarshal(m, b)
+}
+func (m *IndexBackup) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_IndexBackup.Marshal(b, m, deterministic)
+}
+func (m *IndexBackup) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IndexBackup.Merge(m, src)
+}
+func (m *IndexBackup) XXX_Size() int {
+	return xxx_messageInfo_IndexBackup.Size(m)
+}
+func (m *IndexBackup) XXX_DiscardUnknown() {
+	xxx_messageInfo_IndexBackup.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IndexBackup proto.InternalMessageInfo
+
+func (m *IndexBackup) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *IndexBackup) GetRecords() int32 {
+	if m != nil {
+		return m.Records
+	}
+	return 0
+}
+
+func (m *IndexBackup) GetCreatedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.CreatedAt
+	}
+	return nil
+}
+
+func (m *IndexBackup) GetChecksum() string {
+	if m != nil {
+		return m.Checksum
+	}
+	return ""
+}
+
+type BackupIndexRequest struct {
+	// backup file path relative to backup_dir of driver,
+	// absolute paths and paths out of backup_dir are refused.
+	Path                 *wrappers.StringValue `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *BackupIndexRequest) Reset()         { *m = BackupIndexRequest{} }
+func (m *BackupIndexRequest) String() string { return proto.CompactTextString(m) }
+func (*BackupIndexRequest) ProtoMessage()    {}
+func (*BackupIndexRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{24}
+}
+
+func (m *BackupIndexRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BackupIndexRequest.Unmarshal(m, b)
+}
+func (m *BackupIndexRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BackupIndexRequest.Marshal(b, m, deterministic)
+}
+func (m *BackupIndexRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BackupIndexRequest.Merge(m, src)
+}
+func (m *BackupIndexRequest) XXX_Size() int {
+	return xxx_messageInfo_BackupIndexRequest.Size(m)
+}
+func (m *BackupIndexRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BackupIndexRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BackupIndexRequest proto.InternalMessageInfo
+
+func (m *BackupIndexRequest) GetPath() *wrappers.StringValue {
+	if m != nil {
+		return m.Path
+	}
+	return nil
+}
+
+type BackupIndexResponse struct {
+	Backup               *IndexBackup `protobuf:"bytes,1,opt,name=backup,proto3" json:"backup,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *BackupIndexResponse) Reset()         { *m = BackupIndexResponse{} }
+func (m *BackupIndexResponse) String() string { return proto.CompactTextString(m) }
+func (*BackupIndexResponse) ProtoMessage()    {}
+func (*BackupIndexResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{25}
+}
+
+func (m *BackupIndexResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BackupIndexResponse.Unmarshal(m, b)
+}
+func (m *BackupIndexResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BackupIndexResponse.Marshal(b, m, deterministic)
+}
+func (m *BackupIndexResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BackupIndexResponse.Merge(m, src)
+}
+func (m *BackupIndexResponse) XXX_Size() int {
+	return xxx_messageInfo_BackupIndexResponse.Size(m)
+}
+func (m *BackupIndexResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BackupIndexResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BackupIndexResponse proto.InternalMessageInfo
+
+func (m *BackupIndexResponse) GetBackup() *IndexBackup {
+	if m != nil {
+		return m.Backup
+	}
+	return nil
+}
+
+type RestoreIndexRequest struct {
+	// backup file path relative to backup_dir of driver.
+	Path                 *wrappers.StringValue `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *RestoreIndexRequest) Reset()         { *m = RestoreIndexRequest{} }
+func (m *RestoreIndexRequest) String() string { return proto.CompactTextString(m) }
+func (*RestoreIndexRequest) ProtoMessage()    {}
+func (*RestoreIndexRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{26}
+}
+
+func (m *RestoreIndexRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RestoreIndexRequest.Unmarshal(m, b)
+}
+func (m *RestoreIndexRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RestoreIndexRequest.Marshal(b, m, deterministic)
+}
+func (m *RestoreIndexRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RestoreIndexRequest.Merge(m, src)
+}
+func (m *RestoreIndexRequest) XXX_Size() int {
+	return xxx_messageInfo_RestoreIndexRequest.Size(m)
+}
+func (m *RestoreIndexRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RestoreIndexRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RestoreIndexRequest proto.InternalMessageInfo
+
+func (m *RestoreIndexRequest) GetPath() *wrappers.StringValue {
+	if m != nil {
+		return m.Path
+	}
+	return nil
+}
+
+type RestoreIndexResponse struct {
+	Backup               *IndexBackup `protobuf:"bytes,1,opt,name=backup,proto3" json:"backup,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *RestoreIndexResponse) Reset()         { *m = RestoreIndexResponse{} }
+func (m *RestoreIndexResponse) String() string { return proto.CompactTextString(m) }
+func (*RestoreIndexResponse) ProtoMessage()    {}
+func (*RestoreIndexResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{27}
+}
+
+func (m *RestoreIndexResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RestoreIndexResponse.Unmarshal(m, b)
+}
+func (m *RestoreIndexResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RestoreIndexResponse.Marshal(b, m, deterministic)
+}
+func (m *RestoreIndexResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RestoreIndexResponse.Merge(m, src)
+}
+func (m *RestoreIndexResponse) XXX_Size() int {
+	return xxx_messageInfo_RestoreIndexResponse.Size(m)
+}
+func (m *RestoreIndexResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RestoreIndexResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RestoreIndexResponse proto.InternalMessageInfo
+
+func (m *RestoreIndexResponse) GetBackup() *IndexBackup {
+	if m != nil {
+		return m.Backup
+	}
+	return nil
+}
+
+type PrivacyMask struct {
+	Name *wrappers.StringValue `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// one of rect and polygon, pixels of input frame.
+	Rect    *PrivacyMask_Rect    `protobuf:"bytes,2,opt,name=rect,proto3" json:"rect,omitempty"`
+	Polygon []*PrivacyMask_Point `protobuf:"bytes,3,rep,name=polygon,proto3" json:"polygon,omitempty"`
+	Mode    PrivacyMaskMode_     `protobuf:"varint,4,opt,name=mode,proto3,enum=ai.metathings.component.service.digit_video_recorder.PrivacyMaskMode_" json:"mode,omitempty"`
+	// fill color, like `#000000`.
+	Color *wrappers.StringValue `protobuf:"bytes,5,opt,name=color,proto3" json:"color,omitempty"`
+	// pixel block size of pixelate.
+	PixelSize            *wrappers.Int32Value `protobuf:"bytes,6,opt,name=pixel_size,json=pixelSize,proto3" json:"pixel_size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *PrivacyMask) Reset()         { *m = PrivacyMask{} }
+func (m *PrivacyMask) String() string { return proto.CompactTextString(m) }
+func (*PrivacyMask) ProtoMessage()    {}
+func (*PrivacyMask) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{28}
+}
+
+func (m *PrivacyMask) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PrivacyMask.Unmarshal(m, b)
+}
+func (m *PrivacyMask) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PrivacyMask.Marshal(b, m, deterministic)
+}
+func (m *PrivacyMask) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PrivacyMask.Merge(m, src)
+}
+func (m *PrivacyMask) XXX_Size() int {
+	return xxx_messageInfo_PrivacyMask.Size(m)
+}
+func (m *PrivacyMask) XXX_DiscardUnknown() {
+	xxx_messageInfo_PrivacyMask.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PrivacyMask proto.InternalMessageInfo
+
+func (m *PrivacyMask) GetName() *wrappers.StringValue {
+	if m != nil {
+		return m.Name
+	}
+	return nil
+}
+
+func (m *PrivacyMask) GetRect() *PrivacyMask_Rect {
+	if m != nil {
+		return m.Rect
+	}
+	return nil
+}
+
+func (m *PrivacyMask) GetPolygon() []*PrivacyMask_Point {
+	if m != nil {
+		return m.Polygon
+	}
+	return nil
+}
+
+func (m *PrivacyMask) GetMode() PrivacyMaskMode_ {
+	if m != nil {
+		return m.Mode
+	}
+	return PrivacyMask_FILL
+}
+
+func (m *PrivacyMask) GetColor() *wrappers.StringValue {
+	if m != nil {
+		return m.Color
+	}
+	return nil
+}
+
+func (m *PrivacyMask) GetPixelSize() *wrappers.Int32Value {
+	if m != nil {
+		return m.PixelSize
+	}
+	return nil
+}
+
+type PrivacyMask_Point struct {
+	X                    int32    `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y                    int32    `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PrivacyMask_Point) Reset()         { *m = PrivacyMask_Point{} }
+func (m *PrivacyMask_Point) String() string { return proto.CompactTextString(m) }
+func (*PrivacyMask_Point) ProtoMessage()    {}
+func (*PrivacyMask_Point) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{28, 0}
+}
+
+func (m *PrivacyMask_Point) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PrivacyMask_Point.Unmarshal(m, b)
+}
+func (m *PrivacyMask_Point) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PrivacyMask_Point.Marshal(b, m, deterministic)
+}
+func (m *PrivacyMask_Point) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PrivacyMask_Point.Merge(m, src)
+}
+func (m *PrivacyMask_Point) XXX_Size() int {
+	return xxx_messageInfo_PrivacyMask_Point.Size(m)
+}
+func (m *PrivacyMask_Point) XXX_DiscardUnknown() {
+	xxx_messageInfo_PrivacyMask_Point.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PrivacyMask_Point proto.InternalMessageInfo
+
+func (m *PrivacyMask_Point) GetX() int32 {
+	if m != nil {
+		return m.X
+	}
+	return 0
+}
+
+func (m *PrivacyMask_Point) GetY() int32 {
+	if m != nil {
+		return m.Y
+	}
+	return 0
+}
+
+type PrivacyMask_Rect struct {
+	X                    int32    `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y                    int32    `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	Width                int32    `protobuf:"varint,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height               int32    `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PrivacyMask_Rect) Reset()         { *m = PrivacyMask_Rect{} }
+func (m *PrivacyMask_Rect) String() string { return proto.CompactTextString(m) }
+func (*PrivacyMask_Rect) ProtoMessage()    {}
+func (*PrivacyMask_Rect) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{28, 1}
+}
+
+func (m *PrivacyMask_Rect) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PrivacyMask_Rect.Unmarshal(m, b)
+}
+func (m *PrivacyMask_Rect) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PrivacyMask_Rect.Marshal(b, m, deterministic)
+}
+func (m *PrivacyMask_Rect) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PrivacyMask_Rect.Merge(m, src)
+}
+func (m *PrivacyMask_Rect) XXX_Size() int {
+	return xxx_messageInfo_PrivacyMask_Rect.Size(m)
+}
+func (m *PrivacyMask_Rect) XXX_DiscardUnknown() {
+	xxx_messageInfo_PrivacyMask_Rect.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PrivacyMask_Rect proto.InternalMessageInfo
+
+func (m *PrivacyMask_Rect) GetX() int32 {
+	if m != nil {
+		return m.X
+	}
+	return 0
+}
+
+func (m *PrivacyMask_Rect) GetY() int32 {
+	if m != nil {
+		return m.Y
+	}
+	return 0
+}
+
+func (m *PrivacyMask_Rect) GetWidth() int32 {
+	if m != nil {
+		return m.Width
+	}
+	return 0
+}
+
+func (m *PrivacyMask_Rect) GetHeight() int32 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+type SetPrivacyMasksRequest struct {
+	// replaces masks in config, no masks if empty.
+	Masks                []*PrivacyMask `protobuf:"bytes,1,rep,name=masks,proto3" json:"masks,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *SetPrivacyMasksRequest) Reset()         { *m = SetPrivacyMasksRequest{} }
+func (m *SetPrivacyMasksRequest) String() string { return proto.CompactTextString(m) }
+func (*SetPrivacyMasksRequest) ProtoMessage()    {}
+func (*SetPrivacyMasksRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{29}
+}
+
+func (m *SetPrivacyMasksRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetPrivacyMasksRequest.Unmarshal(m, b)
+}
+func (m *SetPrivacyMasksRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetPrivacyMasksRequest.Marshal(b, m, deterministic)
+}
+func (m *SetPrivacyMasksRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetPrivacyMasksRequest.Merge(m, src)
+}
+func (m *SetPrivacyMasksRequest) XXX_Size() int {
+	return xxx_messageInfo_SetPrivacyMasksRequest.Size(m)
+}
+func (m *SetPrivacyMasksRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetPrivacyMasksRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetPrivacyMasksRequest proto.InternalMessageInfo
+
+func (m *SetPrivacyMasksRequest) GetMasks() []*PrivacyMask {
+	if m != nil {
+		return m.Masks
+	}
+	return nil
+}
+
+type GetRecordURLRequest struct {
+	Record *OpRecord `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`
+	// url ttl, playback default ttl if not set, refused if over playback max ttl.
+	Ttl                  *duration.Duration `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *GetRecordURLRequest) Reset()         { *m = GetRecordURLRequest{} }
+func (m *GetRecordURLRequest) String() string { return proto.CompactTextString(m) }
+func (*GetRecordURLRequest) ProtoMessage()    {}
+func (*GetRecordURLRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{30}
+}
+
+func (m *GetRecordURLRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetRecordURLRequest.Unmarshal(m, b)
+}
+func (m *GetRecordURLRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetRecordURLRequest.Marshal(b, m, deterministic)
+}
+func (m *GetRecordURLRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRecordURLRequest.Merge(m, src)
+}
+func (m *GetRecordURLRequest) XXX_Size() int {
+	return xxx_messageInfo_GetRecordURLRequest.Size(m)
+}
+func (m *GetRecordURLRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRecordURLRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetRecordURLRequest proto.InternalMessageInfo
+
+func (m *GetRecordURLRequest) GetRecord() *OpRecord {
+	if m != nil {
+		return m.Record
+	}
+	return nil
+}
+
+func (m *GetRecordURLRequest) GetTtl() *duration.Duration {
+	if m != nil {
+		return m.Ttl
+	}
+	return nil
+}
+
+type GetRecordURLResponse struct {
+	Url                  string               `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	ExpiresAt            *timestamp.Timestamp `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *GetRecordURLResponse) Reset()         { *m = GetRecordURLResponse{} }
+func (m *GetRecordURLResponse) String() string { return proto.CompactTextString(m) }
+func (*GetRecordURLResponse) ProtoMessage()    {}
+func (*GetRecordURLResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a0b84a42fa06f626, []int{31}
+}
+
+func (m *GetRecordURLResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetRecordURLResponse.Unmarshal(m, b)
+}
+func (m *GetRecordURLResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetRecordURLResponse.Marshal(b, m, deterministic)
+}
+func (m *GetRecordURLResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRecordURLResponse.Merge(m, src)
+}
+func (m *GetRecordURLResponse) XXX_Size() int {
+	return xxx_messageInfo_GetRecordURLResponse.Size(m)
+}
+func (m *GetRecordURLResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRecordURLResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetRecordURLResponse proto.InternalMessageInfo
+
+func (m *GetRecordURLResponse) GetUrl() string {
+	if m != nil {
+		return m.Url
+	}
+	return ""
+}
+
+func (m *GetRecordURLResponse) GetExpiresAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.ExpiresAt
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterEnum("ai.metathings.component.service.digit_video_recorder.GetTimelineRequestBucket_", GetTimelineRequestBucket__name, GetTimelineRequestBucket__value)
+	proto.RegisterEnum("ai.metathings.component.service.digit_video_recorder.ImportRecordsRequestMode_", ImportRecordsRequestMode__name, ImportRecordsRequestMode__value)
+	proto.RegisterEnum("ai.metathings.component.service.digit_video_recorder.PrivacyMaskMode_", PrivacyMaskMode__name, PrivacyMaskMode__value)
+	proto.RegisterType((*Record)(nil), "ai.metathings.component.service.digit_video_recorder.Record")
+	proto.RegisterMapType((map[string]*HookResult)(nil), "ai.metathings.component.service.digit_video_recorder.Record.HooksEntry")
+	proto.RegisterType((*HookResult)(nil), "ai.metathings.component.service.digit_video_recorder.HookResult")
+	proto.RegisterType((*OpRecord)(nil), "ai.metathings.component.service.digit_video_recorder.OpRecord")
+	proto.RegisterType((*GetRecordRequest)(nil), "ai.metathings.component.service.digit_video_recorder.GetRecordRequest")
+	proto.RegisterType((*GetRecordResponse)(nil), "ai.metathings.component.service.digit_video_recorder.GetRecordResponse")
+	proto.RegisterType((*ListRecordsRequest)(nil), "ai.metathings.component.service.digit_video_recorder.ListRecordsRequest")
+	proto.RegisterType((*ListRecordsRequestRange_)(nil), "ai.metathings.component.service.digit_video_recorder.ListRecordsRequest.range_")
+	proto.RegisterType((*ListRecordsResponse)(nil), "ai.metathings.component.service.digit_video_recorder.ListRecordsResponse")
+	proto.RegisterType((*ConfigError)(nil), "ai.metathings.component.service.digit_video_recorder.ConfigError")
+	proto.RegisterType((*ValidateConfigRequest)(nil), "ai.metathings.component.service.digit_video_recorder.ValidateConfigRequest")
+	proto.RegisterType((*ValidateConfigResponse)(nil), "ai.metathings.component.service.digit_video_recorder.ValidateConfigResponse")
+	proto.RegisterType((*ReconfigureRequest)(nil), "ai.metathings.component.service.digit_video_recorder.ReconfigureRequest")
+	proto.RegisterType((*RecordingStats)(nil), "ai.metathings.component.service.digit_video_recorder.RecordingStats")
+	proto.RegisterType((*GetStatsResponse)(nil), "ai.metathings.component.service.digit_video_recorder.GetStatsResponse")
+	proto.RegisterType((*TimelineSpan)(nil), "ai.metathings.component.service.digit_video_recorder.TimelineSpan")
+	proto.RegisterType((*TimelineBucket)(nil), "ai.metathings.component.service.digit_video_recorder.TimelineBucket")
+	proto.RegisterType((*GetTimelineRequest)(nil), "ai.metathings.component.service.digit_video_recorder.GetTimelineRequest")
+	proto.RegisterType((*GetTimelineResponse)(nil), "ai.metathings.component.service.digit_video_recorder.GetTimelineResponse")
+	proto.RegisterType((*FindRecordAtRequest)(nil), "ai.metathings.component.service.digit_video_recorder.FindRecordAtRequest")
+	proto.RegisterType((*FindRecordAtResponse)(nil), "ai.metathings.component.service.digit_video_recorder.FindRecordAtResponse")
+	proto.RegisterType((*ImportRecordsRequest)(nil), "ai.metathings.component.service.digit_video_recorder.ImportRecordsRequest")
+	proto.RegisterType((*ImportFailure)(nil), "ai.metathings.component.service.digit_video_recorder.ImportFailure")
+	proto.RegisterType((*ImportRecordsResponse)(nil), "ai.metathings.component.service.digit_video_recorder.ImportRecordsResponse")
+	proto.RegisterType((*ReindexRecordsResponse)(nil), "ai.metathings.component.service.digit_video_recorder.ReindexRecordsResponse")
+	proto.RegisterType((*IndexBackup)(nil), "ai.metathings.component.service.digit_video_recorder.IndexBackup")
+	proto.RegisterType((*BackupIndexRequest)(nil), "ai.metathings.component.service.digit_video_recorder.BackupIndexRequest")
+	proto.RegisterType((*BackupIndexResponse)(nil), "ai.metathings.component.service.digit_video_recorder.BackupIndexResponse")
+	proto.RegisterType((*RestoreIndexRequest)(nil), "ai.metathings.component.service.digit_video_recorder.RestoreIndexRequest")
+	proto.RegisterType((*RestoreIndexResponse)(nil), "ai.metathings.component.service.digit_video_recorder.RestoreIndexResponse")
+	proto.RegisterType((*PrivacyMask)(nil), "ai.metathings.component.service.digit_video_recorder.PrivacyMask")
+	proto.RegisterType((*PrivacyMask_Point)(nil), "ai.metathings.component.service.digit_video_recorder.PrivacyMask.Point")
+	proto.RegisterType((*PrivacyMask_Rect)(nil), "ai.metathings.component.service.digit_video_recorder.PrivacyMask.Rect")
+	proto.RegisterType((*SetPrivacyMasksRequest)(nil), "ai.metathings.component.service.digit_video_recorder.SetPrivacyMasksRequest")
+	proto.RegisterType((*GetRecordURLRequest)(nil), "ai.metathings.component.service.digit_video_recorder.GetRecordURLRequest")
+	proto.RegisterType((*GetRecordURLResponse)(nil), "ai.metathings.component.service.digit_video_recorder.GetRecordURLResponse")
+}
+
+func init() { proto.RegisterFile("service.proto", fileDescriptor_a0b84a42fa06f626) }
+
+var fileDescriptor_a0b84a42fa06f626 = []byte{
+	// 2087 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x59, 0xcb, 0x6f, 0x1c, 0x49,
+	0x19, 0x77, 0xcf, 0xcb, 0x33, 0xdf, 0x38, 0xce, 0xa4, 0xec, 0xb5, 0x7a, 0x67, 0x81, 0x84, 0x5e,
+	0x21, 0x79, 0x05, 0xcc, 0x82, 0x37, 0xb0, 0xd9, 0x5d, 0x58, 0x31, 0x49, 0x6c, 0x67, 0x1c, 0x27,
+	0xb6, 0xca, 0x89, 0x37, 0xc9, 0x0a, 0x46, 0xed, 0xe9, 0xf2, 0xb8, 0x70, 0x4f, 0x77, 0xa7, 0xaa,
+	0xda, 0xf1, 0xe4, 0x8c, 0x84, 0xb8, 0x72, 0xe0, 0xc0, 0x81, 0x03, 0x42, 0x5a, 0x09, 0x09, 0x09,
+	0xc4, 0xbf, 0xc0, 0x8d, 0x3f, 0x80, 0x3f, 0x80, 0x0b, 0x37, 0xfe, 0x85, 0x55, 0x3d, 0xba, 0xdd,
+	0xe3, 0xf1, 0x6b, 0x7b, 0x26, 0xb9, 0xcd, 0x57, 0xfd, 0xd5, 0xef, 0x7b, 0xd4, 0xf7, 0xaa, 0x1a,
+	0xb8, 0xc6, 0x09, 0x3b, 0xa2, 0x3d, 0xd2, 0x8a, 0x58, 0x28, 0x42, 0x74, 0xdb, 0xa5, 0xad, 0x01,
+	0x11, 0xae, 0x38, 0xa0, 0x41, 0x9f, 0xb7, 0x7a, 0xe1, 0x20, 0x0a, 0x03, 0x12, 0x88, 0x56, 0xc2,
+	0xe6, 0xd1, 0x3e, 0x15, 0xdd, 0x23, 0xea, 0x91, 0xb0, 0xcb, 0x48, 0x2f, 0x64, 0x1e, 0x61, 0xcd,
+	0xf7, 0xfa, 0x61, 0xd8, 0xf7, 0xc9, 0x87, 0x0a, 0x63, 0x2f, 0xde, 0xff, 0x90, 0x0c, 0x22, 0x31,
+	0xd4, 0x90, 0xcd, 0xef, 0x9c, 0xfe, 0xf8, 0x8a, 0xb9, 0x51, 0x44, 0x18, 0x37, 0xdf, 0x6f, 0x9e,
+	0xfe, 0x2e, 0xe8, 0x80, 0x70, 0xe1, 0x0e, 0xa2, 0xf3, 0x00, 0xbc, 0x98, 0xb9, 0x82, 0x86, 0x81,
+	0xfe, 0xee, 0xfc, 0xa1, 0x08, 0x15, 0xac, 0x54, 0x41, 0xf3, 0x50, 0xa0, 0x9e, 0x6d, 0xdd, 0xb2,
+	0x96, 0x6b, 0xb8, 0x40, 0x3d, 0xf4, 0x13, 0xa8, 0x72, 0xe1, 0x32, 0xd1, 0x75, 0x85, 0x5d, 0xb8,
+	0x65, 0x2d, 0xd7, 0x57, 0x9a, 0x2d, 0x8d, 0xd6, 0x4a, 0xd0, 0x5a, 0x4f, 0x12, 0x71, 0x78, 0x56,
+	0xf1, 0xb6, 0x05, 0xfa, 0x31, 0x54, 0x48, 0xe0, 0xc9, 0x4d, 0xc5, 0x4b, 0x37, 0x95, 0x49, 0xe0,
+	0xb5, 0x05, 0xb2, 0x61, 0x36, 0x62, 0xe1, 0x3e, 0xf5, 0x89, 0x5d, 0x52, 0xe2, 0x13, 0x12, 0x7d,
+	0x1b, 0xc0, 0x8d, 0x3d, 0x1a, 0x76, 0xc3, 0xc0, 0x1f, 0xda, 0xe5, 0x5b, 0xd6, 0x72, 0x15, 0xd7,
+	0xd4, 0xca, 0x56, 0xe0, 0x0f, 0xd1, 0x2f, 0xa1, 0x7c, 0x10, 0x86, 0x87, 0xdc, 0xae, 0xdc, 0x2a,
+	0x2e, 0xd7, 0x57, 0xd6, 0x5b, 0x79, 0x4e, 0xa0, 0xa5, 0xed, 0x6f, 0x3d, 0x90, 0x48, 0xab, 0x81,
+	0x60, 0x43, 0xac, 0x51, 0x9b, 0xaf, 0x01, 0x4e, 0x16, 0x51, 0x03, 0x8a, 0x87, 0x64, 0x68, 0x1c,
+	0x24, 0x7f, 0xa2, 0x5d, 0x28, 0x1f, 0xb9, 0x7e, 0x4c, 0x8c, 0x7b, 0x7e, 0x91, 0x4f, 0xbc, 0x14,
+	0x81, 0x09, 0x8f, 0x7d, 0x81, 0x35, 0xdc, 0xa7, 0x85, 0x3b, 0x96, 0xf3, 0x0a, 0xe0, 0xe4, 0x03,
+	0x5a, 0x82, 0x0a, 0x17, 0xae, 0x88, 0xb9, 0x11, 0x6f, 0x28, 0xb4, 0x08, 0x65, 0xc2, 0x58, 0xc8,
+	0x94, 0x06, 0x35, 0xac, 0x09, 0xf4, 0x19, 0xd4, 0xf7, 0x69, 0x40, 0xf9, 0x01, 0xb9, 0xe2, 0x39,
+	0x40, 0xc2, 0xde, 0x16, 0xce, 0x9f, 0x2d, 0xa8, 0x6e, 0x45, 0x26, 0x26, 0x7e, 0x90, 0xc6, 0x44,
+	0x7d, 0xe5, 0x5b, 0x63, 0x00, 0x3b, 0x82, 0xd1, 0xa0, 0xbf, 0x2b, 0x75, 0x7e, 0xbb, 0x11, 0xe3,
+	0xfc, 0x1a, 0x1a, 0xeb, 0x44, 0x68, 0x25, 0x31, 0x79, 0x19, 0x13, 0x2e, 0xd0, 0x2e, 0x54, 0xb4,
+	0x4f, 0x8d, 0xbe, 0x9f, 0xe7, 0x3b, 0x8e, 0xc4, 0x76, 0x6c, 0xd0, 0x1c, 0x0a, 0x37, 0x32, 0xb2,
+	0x78, 0x14, 0x06, 0x9c, 0xa0, 0x27, 0xa7, 0x84, 0xfd, 0x6c, 0x92, 0xd0, 0x4b, 0x45, 0xfd, 0xb3,
+	0x00, 0x68, 0x93, 0x72, 0x23, 0x8c, 0x27, 0x96, 0xf5, 0xa1, 0xcc, 0xdc, 0xa0, 0x4f, 0x8c, 0xac,
+	0xad, 0x7c, 0xb2, 0xc6, 0x81, 0x5b, 0x0a, 0xb5, 0xfb, 0x60, 0x06, 0x6b, 0x7c, 0xf4, 0xd3, 0x93,
+	0x44, 0x2c, 0x5c, 0xe1, 0xcc, 0x13, 0xe6, 0x26, 0x83, 0x8a, 0x86, 0x1a, 0x09, 0x01, 0x2b, 0x4f,
+	0x08, 0x14, 0xae, 0x18, 0x02, 0x77, 0xab, 0x50, 0xd9, 0xa7, 0xbe, 0x20, 0xcc, 0x19, 0xc0, 0xc2,
+	0x88, 0x6d, 0xe6, 0x88, 0x76, 0x61, 0x56, 0xdb, 0x2e, 0x93, 0xa6, 0x38, 0xf1, 0x19, 0x25, 0x60,
+	0xce, 0xc7, 0x50, 0xbf, 0x17, 0x06, 0xfb, 0xb4, 0xbf, 0xaa, 0x92, 0x6d, 0xbc, 0x2c, 0x2c, 0xc9,
+	0xd8, 0x70, 0x79, 0x18, 0x98, 0xac, 0x34, 0x94, 0xf3, 0x08, 0xde, 0xd9, 0x75, 0x7d, 0xea, 0xb9,
+	0x82, 0x68, 0x80, 0xe4, 0x7c, 0x6f, 0x43, 0xa5, 0xa7, 0x16, 0xae, 0x94, 0x69, 0x86, 0xd7, 0xf9,
+	0x9d, 0x05, 0x4b, 0xa7, 0xf1, 0x8c, 0xe9, 0x8b, 0xaa, 0x30, 0x99, 0xcc, 0xad, 0x62, 0x4d, 0xa0,
+	0xe7, 0x50, 0x51, 0xf5, 0x81, 0xdb, 0x05, 0xe5, 0x8f, 0x76, 0x3e, 0x7f, 0x64, 0x8c, 0xc7, 0x06,
+	0xd0, 0xd9, 0x00, 0x24, 0xdd, 0x24, 0x3f, 0xc4, 0x8c, 0x4c, 0x66, 0xd7, 0xbf, 0xca, 0x30, 0xaf,
+	0x7d, 0x4e, 0x83, 0xfe, 0x8e, 0x70, 0x85, 0x2a, 0x73, 0xb2, 0xe0, 0x11, 0xe3, 0x65, 0x4d, 0x48,
+	0x3f, 0xef, 0x33, 0x77, 0x40, 0xb8, 0xf2, 0x73, 0x09, 0x1b, 0x4a, 0x9e, 0xc8, 0x7e, 0xc4, 0x55,
+	0x31, 0xb1, 0xb0, 0xfc, 0x29, 0x1b, 0xcc, 0x1e, 0x15, 0x4c, 0x22, 0x94, 0xd4, 0x6a, 0x42, 0xca,
+	0x06, 0x23, 0x42, 0xe1, 0xfa, 0x5d, 0x4e, 0x5f, 0x13, 0xd5, 0x60, 0x4a, 0xb8, 0xa6, 0x56, 0x76,
+	0xe8, 0x6b, 0x82, 0x6e, 0x43, 0x35, 0x8c, 0x45, 0x57, 0x76, 0x55, 0xbb, 0xa2, 0x6c, 0x78, 0x77,
+	0xcc, 0x86, 0xfb, 0xa6, 0xa3, 0xe2, 0xd9, 0x30, 0x16, 0x32, 0x4c, 0x25, 0xa8, 0x17, 0x47, 0x5d,
+	0xa3, 0xdc, 0xac, 0x06, 0xf5, 0xe2, 0x68, 0x4d, 0xeb, 0x77, 0x13, 0xea, 0x1e, 0x0b, 0xd3, 0xef,
+	0x55, 0xf5, 0x1d, 0xe4, 0x92, 0x61, 0x90, 0xe6, 0x46, 0x84, 0x78, 0x76, 0x4d, 0x29, 0xab, 0x09,
+	0xf4, 0x5d, 0x98, 0xe3, 0xa4, 0x3f, 0x20, 0x81, 0xe8, 0xaa, 0x0c, 0x05, 0xe5, 0x8b, 0xba, 0x59,
+	0x5b, 0x93, 0xed, 0x32, 0xc3, 0xa2, 0xec, 0xa9, 0x2b, 0xe8, 0x84, 0x45, 0x59, 0xf4, 0x09, 0x80,
+	0x4a, 0x3a, 0xdd, 0x1a, 0xe6, 0x2e, 0xcd, 0xb6, 0x9a, 0xe1, 0x6e, 0x0b, 0xb9, 0x35, 0x8e, 0x3c,
+	0xd7, 0x6c, 0xbd, 0x76, 0xf9, 0x56, 0xc3, 0xdd, 0x16, 0xa8, 0x09, 0x55, 0x46, 0x14, 0x12, 0xb7,
+	0xe7, 0x95, 0x52, 0x29, 0x8d, 0x7e, 0x08, 0xc8, 0x28, 0xc8, 0xbb, 0xbd, 0x70, 0x30, 0xa0, 0x42,
+	0x10, 0xcf, 0xbe, 0xae, 0xb8, 0x6e, 0x24, 0x5f, 0xee, 0x25, 0x1f, 0xd0, 0x07, 0xd0, 0x48, 0xdd,
+	0xe0, 0x52, 0x3f, 0x66, 0x84, 0xdb, 0x0d, 0xc5, 0x7c, 0x3d, 0x71, 0x85, 0x59, 0x46, 0xef, 0xc3,
+	0xb5, 0xbd, 0xa1, 0x20, 0xbc, 0xfb, 0x8a, 0xc9, 0xbd, 0x81, 0x7d, 0x43, 0xf1, 0xcd, 0xa9, 0xc5,
+	0x2f, 0xf4, 0x9a, 0x69, 0xad, 0xbe, 0xcf, 0x6d, 0xa4, 0xa3, 0x48, 0x53, 0xe8, 0x73, 0xb8, 0xe6,
+	0xbb, 0x5c, 0x74, 0x15, 0x29, 0x0d, 0x5e, 0xb8, 0xd4, 0xe0, 0xba, 0xdc, 0xb0, 0x23, 0xf9, 0xdb,
+	0xc2, 0x09, 0x54, 0x8b, 0x52, 0xf1, 0x9b, 0xe6, 0xe5, 0x0b, 0x1d, 0xc7, 0xdc, 0xe4, 0xc3, 0xfd,
+	0x49, 0x0a, 0x52, 0x92, 0x1c, 0x3a, 0x1b, 0xb8, 0x73, 0x0c, 0x73, 0x52, 0x13, 0x9f, 0x06, 0x64,
+	0x27, 0x72, 0x83, 0xb7, 0x57, 0x89, 0x9d, 0x7f, 0x58, 0x30, 0x9f, 0x88, 0xbe, 0x1b, 0xf7, 0x0e,
+	0x89, 0x78, 0x7b, 0xc2, 0xa5, 0x24, 0xe3, 0x18, 0xcf, 0x2e, 0x5e, 0x96, 0xa1, 0x29, 0xab, 0xf3,
+	0x9b, 0x22, 0xa0, 0x75, 0x22, 0x12, 0xb5, 0x93, 0x8a, 0xf5, 0xf6, 0xf4, 0x3e, 0x80, 0xca, 0x9e,
+	0xf2, 0x95, 0xd2, 0x7a, 0x7e, 0x65, 0x3b, 0x5f, 0x2c, 0x8c, 0xdb, 0xd0, 0xd2, 0xb0, 0x5d, 0x6c,
+	0xf0, 0xd1, 0xc7, 0x50, 0x13, 0xa1, 0x4f, 0x98, 0x1b, 0xf4, 0x74, 0xf9, 0xbb, 0xd0, 0x45, 0x27,
+	0xbc, 0xd9, 0x69, 0xa0, 0xfc, 0x0d, 0xa6, 0x01, 0xe7, 0x03, 0x98, 0x35, 0x3a, 0xa0, 0x2a, 0x94,
+	0x1e, 0x6f, 0x3d, 0x5e, 0x6d, 0xcc, 0x20, 0x80, 0xca, 0xa3, 0xce, 0xe3, 0xa7, 0x4f, 0x56, 0x1b,
+	0x96, 0x5c, 0x7d, 0xb0, 0xf5, 0x14, 0x37, 0x0a, 0xce, 0x57, 0x05, 0x58, 0x18, 0x31, 0xc1, 0x24,
+	0xca, 0x33, 0x59, 0x01, 0xdd, 0x20, 0xe9, 0xdc, 0x77, 0xf3, 0x39, 0x27, 0x9b, 0x0f, 0x58, 0x03,
+	0xa2, 0x5d, 0x28, 0xf5, 0xdd, 0x28, 0x69, 0x81, 0xd3, 0x00, 0x56, 0x78, 0xe8, 0x57, 0x89, 0xd1,
+	0xb2, 0xf1, 0x14, 0xf3, 0x27, 0xf7, 0x68, 0x22, 0xe1, 0x04, 0xd4, 0xf9, 0xad, 0x05, 0x0b, 0x6b,
+	0x34, 0xf0, 0x74, 0xf2, 0xb7, 0x45, 0x12, 0xb1, 0x77, 0xa0, 0x96, 0xde, 0xf9, 0xae, 0x10, 0xb2,
+	0x27, 0xcc, 0x79, 0x87, 0x3d, 0xe7, 0xdf, 0x05, 0x58, 0x1c, 0xd5, 0xe4, 0x4d, 0xce, 0xc4, 0x32,
+	0xb7, 0xc2, 0xfd, 0x7d, 0x4e, 0x92, 0xdc, 0xba, 0x20, 0x76, 0x0d, 0x23, 0x7a, 0x06, 0xd5, 0x88,
+	0x91, 0x23, 0x1a, 0xc6, 0xdc, 0x2e, 0x4e, 0x41, 0x95, 0x14, 0x0d, 0x6d, 0x43, 0x29, 0x20, 0xc7,
+	0xc2, 0x2e, 0x4d, 0x01, 0x55, 0x21, 0x39, 0x7f, 0x2f, 0xc0, 0x62, 0x67, 0x10, 0x85, 0xec, 0xf4,
+	0xd0, 0xbf, 0x08, 0xe5, 0xc8, 0x15, 0x07, 0x3a, 0x05, 0x6a, 0x58, 0x13, 0xc8, 0x83, 0xd2, 0x20,
+	0xf4, 0xf4, 0x89, 0xe5, 0x2e, 0x1a, 0x67, 0xc9, 0x6b, 0x49, 0xd8, 0x2e, 0x56, 0xe8, 0xd9, 0xd0,
+	0x28, 0x7e, 0x83, 0xd0, 0x40, 0x3f, 0x87, 0xba, 0x8c, 0xaf, 0xae, 0xef, 0x0e, 0xc3, 0x38, 0xf1,
+	0xd2, 0xc5, 0x7b, 0x41, 0x6e, 0xd8, 0x54, 0xfc, 0xce, 0xf7, 0xa0, 0xac, 0xb4, 0x90, 0x05, 0xe2,
+	0xe1, 0xea, 0xea, 0x76, 0x63, 0x46, 0xfe, 0xba, 0xb7, 0xb5, 0xfd, 0x5c, 0x17, 0x8d, 0x47, 0x5b,
+	0xbb, 0xab, 0x8d, 0x82, 0xf3, 0x19, 0x5c, 0xd3, 0x16, 0x98, 0x46, 0x8f, 0x10, 0x94, 0xa4, 0x77,
+	0xcc, 0x74, 0xa8, 0x7e, 0x9f, 0x3b, 0x84, 0xff, 0xcf, 0x82, 0x77, 0x4e, 0xd9, 0x9f, 0xd6, 0x9c,
+	0x2a, 0x55, 0x1f, 0x88, 0x37, 0x95, 0x0b, 0x43, 0x8a, 0x26, 0xc7, 0x4f, 0x7e, 0x48, 0xa3, 0x88,
+	0x78, 0xaa, 0xec, 0xd4, 0x70, 0x42, 0xa2, 0x2f, 0xa1, 0x22, 0x87, 0x18, 0xd5, 0xbb, 0xa4, 0xc4,
+	0x7b, 0x93, 0x1c, 0xa8, 0x71, 0x07, 0x36, 0x90, 0xce, 0xff, 0x2d, 0x58, 0xc2, 0x84, 0x06, 0x1e,
+	0x39, 0x3e, 0x6d, 0xeb, 0x0b, 0xa8, 0xc9, 0xcd, 0x47, 0x84, 0x4d, 0xc9, 0xd8, 0x13, 0x38, 0x69,
+	0xad, 0x92, 0xa9, 0xac, 0xb5, 0x96, 0xcb, 0x38, 0x21, 0x51, 0x17, 0xaa, 0x71, 0x30, 0x70, 0xa3,
+	0x68, 0xba, 0xf6, 0xa6, 0xa0, 0xce, 0xef, 0x2d, 0xa8, 0x77, 0xa4, 0xb0, 0xbb, 0x6e, 0xef, 0x30,
+	0x8e, 0xce, 0x0c, 0x0c, 0xfb, 0xe4, 0x5a, 0x68, 0xd4, 0x33, 0xa4, 0x9c, 0x6f, 0x7b, 0x8c, 0x24,
+	0xf3, 0xed, 0xe5, 0x6f, 0x11, 0x35, 0xc3, 0xad, 0xe7, 0xdb, 0xde, 0x01, 0xe9, 0x1d, 0xf2, 0x78,
+	0x60, 0x9e, 0xb0, 0x52, 0xda, 0x59, 0x03, 0xa4, 0xd5, 0xe9, 0xe8, 0x93, 0xd0, 0xe9, 0xfd, 0xa3,
+	0x8c, 0x6a, 0x97, 0xe5, 0x88, 0xe2, 0x74, 0x22, 0x58, 0x18, 0xc1, 0x31, 0x47, 0xf9, 0x1c, 0x2a,
+	0x7b, 0x6a, 0xd9, 0x40, 0xe5, 0xbc, 0xd5, 0x65, 0xdc, 0x86, 0x0d, 0xa0, 0xb3, 0x0e, 0x0b, 0x98,
+	0x70, 0x11, 0x32, 0x32, 0xa1, 0xea, 0x2f, 0x61, 0x71, 0x14, 0xe8, 0xcd, 0xeb, 0xfe, 0xdf, 0x12,
+	0xd4, 0xb7, 0x19, 0x3d, 0x72, 0x7b, 0xc3, 0x47, 0x2e, 0x3f, 0x94, 0x4a, 0x07, 0xee, 0x80, 0x5c,
+	0x4d, 0x69, 0xc9, 0x89, 0x5e, 0x40, 0x89, 0x91, 0x5e, 0xd2, 0x76, 0xd6, 0xf2, 0xa9, 0x96, 0x51,
+	0x41, 0xa6, 0x8a, 0xc0, 0x0a, 0x13, 0xb9, 0x30, 0x1b, 0x85, 0xfe, 0xb0, 0x1f, 0x06, 0x76, 0x71,
+	0x92, 0xa7, 0xcb, 0x2c, 0xfc, 0x76, 0x48, 0x03, 0x81, 0x13, 0x5c, 0xf4, 0xa5, 0xe9, 0x14, 0x25,
+	0xd5, 0x29, 0xa6, 0x80, 0x9f, 0x6d, 0x10, 0x2b, 0x50, 0xee, 0x85, 0x7e, 0xc8, 0xae, 0x34, 0x18,
+	0x6a, 0x56, 0xf4, 0x29, 0x40, 0x44, 0x8f, 0x89, 0xb9, 0x6a, 0xeb, 0xdb, 0xf4, 0x7b, 0x63, 0x1b,
+	0x3b, 0x81, 0xf8, 0x68, 0x45, 0xef, 0xab, 0x29, 0x76, 0x79, 0x6b, 0x6d, 0xbe, 0x0f, 0x65, 0x65,
+	0x1e, 0x9a, 0x03, 0xeb, 0x58, 0x9d, 0x61, 0x19, 0x5b, 0xc7, 0x92, 0x1a, 0x9a, 0x2c, 0xb6, 0x86,
+	0xcd, 0x6d, 0x28, 0x49, 0x17, 0x5f, 0xc4, 0x23, 0xbb, 0xea, 0x2b, 0xea, 0x89, 0x03, 0x95, 0xde,
+	0x65, 0xac, 0x09, 0xd9, 0x2c, 0x0e, 0x08, 0xed, 0x1f, 0xe8, 0x96, 0x55, 0xc6, 0x86, 0x72, 0x6e,
+	0x66, 0x1a, 0xd2, 0x5a, 0x67, 0x73, 0xb3, 0x31, 0x83, 0xe6, 0xa0, 0xba, 0xdd, 0x79, 0xb6, 0xba,
+	0xd9, 0x96, 0x93, 0xac, 0xf3, 0x12, 0x96, 0x76, 0x88, 0xc8, 0x78, 0x29, 0x6d, 0xdf, 0x5f, 0x40,
+	0x79, 0x20, 0x69, 0x53, 0x5d, 0xdb, 0x13, 0xfb, 0x1f, 0x6b, 0x3c, 0xe7, 0x8f, 0x96, 0x1a, 0x99,
+	0x75, 0xdd, 0x7d, 0x8a, 0x37, 0xdf, 0xf0, 0xf3, 0x27, 0xfa, 0x3e, 0x14, 0x85, 0xf0, 0x2f, 0x1f,
+	0xbe, 0x24, 0x97, 0xd3, 0x83, 0xc5, 0x51, 0xdd, 0x4c, 0xa2, 0x37, 0xa0, 0x18, 0x33, 0x3f, 0x79,
+	0x24, 0x8b, 0x99, 0x2f, 0x8b, 0x2d, 0x39, 0x8e, 0x28, 0x23, 0xfc, 0x6a, 0xd7, 0xa6, 0x9a, 0xe1,
+	0x6e, 0x8b, 0x95, 0xff, 0x34, 0xe0, 0xdd, 0xfb, 0x52, 0xf7, 0x5d, 0xa9, 0x3a, 0x36, 0x9a, 0xef,
+	0x68, 0xab, 0xd0, 0x27, 0x50, 0xde, 0x11, 0x2e, 0x13, 0x68, 0x69, 0x0c, 0x6d, 0x55, 0xfe, 0xb3,
+	0xd2, 0x3c, 0x67, 0xdd, 0x99, 0x41, 0x77, 0xa0, 0xb4, 0x23, 0xc2, 0x28, 0xc7, 0xce, 0x3f, 0x59,
+	0x50, 0x4b, 0x0d, 0x47, 0x6b, 0xb9, 0xef, 0x72, 0x23, 0x2f, 0xda, 0xcd, 0xf5, 0x89, 0x71, 0xb4,
+	0xfb, 0x9d, 0x19, 0xf4, 0x17, 0x0b, 0xea, 0x99, 0x47, 0x52, 0xf4, 0x60, 0x5a, 0x6f, 0xc8, 0xcd,
+	0xce, 0x14, 0x90, 0x52, 0x35, 0xff, 0x66, 0xc1, 0xfc, 0xe8, 0x9b, 0x26, 0x7a, 0x98, 0x0f, 0xff,
+	0xcc, 0x97, 0xd6, 0xe6, 0xe6, 0x74, 0xc0, 0x52, 0x7d, 0x5f, 0x42, 0x3d, 0xf3, 0xee, 0x99, 0xd7,
+	0xab, 0xe3, 0x4f, 0xa7, 0x17, 0x84, 0x9a, 0x0f, 0xd5, 0xe4, 0x5d, 0xe9, 0xdc, 0x40, 0xcd, 0x1f,
+	0x80, 0x23, 0xef, 0x55, 0xce, 0x0c, 0xfa, 0xca, 0x82, 0xb9, 0x6c, 0x46, 0xa3, 0xce, 0x84, 0x31,
+	0x79, 0x52, 0xb1, 0x9a, 0x1b, 0xd3, 0x80, 0x1a, 0x89, 0xf0, 0xcc, 0x53, 0x42, 0xde, 0xb3, 0x18,
+	0x7f, 0x50, 0x69, 0x76, 0xa6, 0x80, 0x34, 0xe2, 0xd0, 0xec, 0xed, 0x39, 0xaf, 0x43, 0xcf, 0x78,
+	0x0b, 0x68, 0x6e, 0x4c, 0x03, 0x2a, 0xd5, 0xf4, 0xaf, 0x56, 0x72, 0xcf, 0x4a, 0x8a, 0xc6, 0xc6,
+	0xf4, 0xae, 0x9b, 0xcd, 0x87, 0x53, 0xc1, 0x4a, 0x95, 0x3d, 0x96, 0xff, 0x19, 0x64, 0xaf, 0x3a,
+	0xe7, 0xe6, 0xc6, 0x66, 0xde, 0x1c, 0x3d, 0xeb, 0x22, 0x65, 0xe2, 0x2e, 0x33, 0x97, 0xe7, 0x8d,
+	0xbb, 0xf1, 0x2b, 0x42, 0xb3, 0x33, 0x05, 0xa4, 0x91, 0xb8, 0xcb, 0xce, 0xe0, 0x79, 0xe3, 0xee,
+	0x8c, 0x0b, 0x41, 0x73, 0x63, 0x1a, 0x50, 0xa9, 0xa6, 0x43, 0xb8, 0x7e, 0x6a, 0xa6, 0x42, 0x39,
+	0xcf, 0xec, 0xec, 0xd1, 0xec, 0xfc, 0xda, 0xba, 0x57, 0x51, 0x2b, 0x1f, 0x7d, 0x3d, 0x00, 0x8f,
+	0x1b, 0xff, 0x76, 0xd9, 0x21, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// DigitVideoRecorderServiceClient is the client API for DigitVideoRecorderService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type DigitVideoRecorderServiceClient interface {
+	Start(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
+	Stop(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
+	GetRecord(ctx context.Context, in *GetRecordRequest, opts ...grpc.CallOption) (*GetRecordResponse, error)
+	ListRecords(ctx context.Context, in *ListRecordsRequest, opts ...grpc.CallOption) (*ListRecordsResponse, error)
+	ValidateConfig(ctx context.Context, in *ValidateConfigRequest, opts ...grpc.CallOption) (*ValidateConfigResponse, error)
+	Reconfigure(ctx context.Context, in *ReconfigureRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	GetStats(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*GetStatsResponse, error)
+	GetRecordURL(ctx context.Context, in *GetRecordURLRequest, opts ...grpc.CallOption) (*GetRecordURLResponse, error)
+	GetTimeline(ctx context.Context, in *GetTimelineRequest, opts ...grpc.CallOption) (*GetTimelineResponse, error)
+	FindRecordAt(ctx context.Context, in *FindRecordAtRequest, opts ...grpc.CallOption) (*FindRecordAtResponse, error)
+	ImportRecords(ctx context.Context, in *ImportRecordsRequest, opts ...grpc.CallOption) (*ImportRecordsResponse, error)
+	ReindexRecords(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ReindexRecordsResponse, error)
+	BackupIndex(ctx context.Context, in *BackupIndexRequest, opts ...grpc.CallOption) (*BackupIndexResponse, error)
+	RestoreIndex(ctx context.Context, in *RestoreIndexRequest, opts ...grpc.CallOption) (*RestoreIndexResponse, error)
+	// restarts ffmpeg if recording and masks changed, current segment is finished early.
+	SetPrivacyMasks(ctx context.Context, in *SetPrivacyMasksRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+}
+
+type digitVideoRecorderServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewDigitVideoRecorderServiceClient(cc *grpc.ClientConn) DigitVideoRecorderServiceClient {
+	return &digitVideoRecorderServiceClient{cc}
+}
+
+func (c *digitVideoRecorderServiceClient) Start(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/Start", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *digitVideoRecorderServiceClient) Stop(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/Stop", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *digitVideoRecorderServiceClient) GetRecord(ctx context.Context, in *GetRecordRequest, opts ...grpc.CallOption) (*GetRecordResponse, error) {
+	out := new(GetRecordResponse)
+	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/GetRecord", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *digitVideoRecorderServiceClient) ListRecords(ctx context.Context, in *ListRecordsRequest, opts ...grpc.CallOption) (*ListRecordsResponse, error) {
+	out := new(ListRecordsResponse)
+	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/ListRecords", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *digitVideoRecorderServiceClient) ValidateConfig(ctx context.Context, in *ValidateConfigRequest, opts ...grpc.CallOption) (*ValidateConfigResponse, error) {
+	out := new(ValidateConfigResponse)
+	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/ValidateConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *digitVideoRecorderServiceClient) Reconfigure(ctx context.Context, in *ReconfigureRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/Reconfigure", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *digitVideoRecorderServiceClient) GetStats(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*GetStatsResponse, error) {
+	out := new(GetStatsResponse)
+	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/GetStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *digitVideoRecorderServiceClient) GetRecordURL(ctx context.Context, in *GetRecordURLRequest, opts ...grpc.CallOption) (*GetRecordURLResponse, error) {
+	out := new(GetRecordURLResponse)
+	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/GetRecordURL", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *digitVideoRecorderServiceClient) GetTimeline(ctx context.Context, in *GetTimelineRequest, opts ...grpc.CallOption) (*GetTimelineResponse, error) {
+	out := new(GetTimelineResponse)
+	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/GetTimeline", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *digitVideoRecorderServiceClient) FindRecordAt(ctx context.Context, in *FindRecordAtRequest, opts ...grpc.CallOption) (*FindRecordAtResponse, error) {
+	out := new(FindRecordAtResponse)
+	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/FindRecordAt", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *digitVideoRecorderServiceClient) ImportRecords(ctx context.Context, in *ImportRecordsRequest, opts ...grpc.CallOption) (*ImportRecordsResponse, error) {
+	out := new(ImportRecordsResponse)
+	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/ImportRecords", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *digitVideoRecorderServiceClient) ReindexRecords(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ReindexRecordsResponse, error) {
+	out := new(ReindexRecordsResponse)
+	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/ReindexRecords", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *digitVideoRecorderServiceClient) BackupIndex(ctx context.Context, in *BackupIndexRequest, opts ...grpc.CallOption) (*BackupIndexResponse, error) {
+	out := new(BackupIndexResponse)
+	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/BackupIndex", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *digitVideoRecorderServiceClient) RestoreIndex(ctx context.Context, in *RestoreIndexRequest, opts ...grpc.CallOption) (*RestoreIndexResponse, error) {
+	out := new(RestoreIndexResponse)
+	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/RestoreIndex", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *digitVideoRecorderServiceClient) SetPrivacyMasks(ctx context.Context, in *SetPrivacyMasksRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/SetPrivacyMasks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DigitVideoRecorderServiceServer is the server API for DigitVideoRecorderService service.
+type DigitVideoRecorderServiceServer interface {
+	Start(context.Context, *empty.Empty) (*empty.Empty, error)
+	Stop(context.Context, *empty.Empty) (*empty.Empty, error)
+	GetRecord(context.Context, *GetRecordRequest) (*GetRecordResponse, error)
+	ListRecords(context.Context, *ListRecordsRequest) (*ListRecordsResponse, error)
+	ValidateConfig(context.Context, *ValidateConfigRequest) (*ValidateConfigResponse, error)
+	Reconfigure(context.Context, *ReconfigureRequest) (*empty.Empty, error)
+	GetStats(context.Context, *empty.Empty) (*GetStatsResponse, error)
+	GetRecordURL(context.Context, *GetRecordURLRequest) (*GetRecordURLResponse, error)
+	GetTimeline(context.Context, *GetTimelineRequest) (*GetTimelineResponse, error)
+	FindRecordAt(context.Context, *FindRecordAtRequest) (*FindRecordAtResponse, error)
+	ImportRecords(context.Context, *ImportRecordsRequest) (*ImportRecordsResponse, error)
+	ReindexRecords(context.Context, *empty.Empty) (*ReindexRecordsResponse, error)
+	BackupIndex(context.Context, *BackupIndexRequest) (*BackupIndexResponse, error)
+	RestoreIndex(context.Context, *RestoreIndexRequest) (*RestoreIndexResponse, error)
+	// restarts ffmpeg if recording and masks changed, current segment is finished early.
+	SetPrivacyMasks(context.Context, *SetPrivacyMasksRequest) (*empty.Empty, error)
+}
+
+// UnimplementedDigitVideoRecorderServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedDigitVideoRecorderServiceServer struct {
+}
+
+func (*UnimplementedDigitVideoRecorderServiceServer) Start(ctx context.Context, req *empty.Empty) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Start not implemented")
+}
+func (*UnimplementedDigitVideoRecorderServiceServer) Stop(ctx context.Context, req *empty.Empty) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stop not implemented")
+}
+func (*UnimplementedDigitVideoRecorderServiceServer) GetRecord(ctx context.Context, req *GetRecordRequest) (*GetRecordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecord not implemented")
+}
+func (*UnimplementedDigitVideoRecorderServiceServer) ListRecords(ctx context.Context, req *ListRecordsRequest) (*ListRecordsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRecords not implemented")
+}
+func (*UnimplementedDigitVideoRecorderServiceServer) ValidateConfig(ctx context.Context, req *ValidateConfigRequest) (*ValidateConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateConfig not implemented")
+}
+func (*UnimplementedDigitVideoRecorderServiceServer) Reconfigure(ctx context.Context, req *ReconfigureRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reconfigure not implemented")
+}
+func (*UnimplementedDigitVideoRecorderServiceServer) GetStats(ctx context.Context, req *empty.Empty) (*GetStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (*UnimplementedDigitVideoRecorderServiceServer) GetRecordURL(ctx context.Context, req *GetRecordURLRequest) (*GetRecordURLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecordURL not implemented")
+}
+func (*UnimplementedDigitVideoRecorderServiceServer) GetTimeline(ctx context.Context, req *GetTimelineRequest) (*GetTimelineResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTimeline not implemented")
+}
+func (*UnimplementedDigitVideoRecorderServiceServer) FindRecordAt(ctx context.Context, req *FindRecordAtRequest) (*FindRecordAtResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindRecordAt not implemented")
+}
+func (*UnimplementedDigitVideoRecorderServiceServer) ImportRecords(ctx context.Context, req *ImportRecordsRequest) (*ImportRecordsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportRecords not implemented")
+}
+func (*UnimplementedDigitVideoRecorderServiceServer) ReindexRecords(ctx context.Context, req *empty.Empty) (*ReindexRecordsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReindexRecords not implemented")
+}
+func (*UnimplementedDigitVideoRecorderServiceServer) BackupIndex(ctx context.Context, req *BackupIndexRequest) (*BackupIndexResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BackupIndex not implemented")
+}
+func (*UnimplementedDigitVideoRecorderServiceServer) RestoreIndex(ctx context.Context, req *RestoreIndexRequest) (*RestoreIndexResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreIndex not implemented")
+}
+func (*UnimplementedDigitVideoRecorderServiceServer) SetPrivacyMasks(ctx context.Context, req *SetPrivacyMasksRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPrivacyMasks not implemented")
+}
+
+func RegisterDigitVideoRecorderServiceServer(s *grpc.Server, srv DigitVideoRecorderServiceServer) {
+	s.RegisterService(&_DigitVideoRecorderService_serviceDesc, srv)
+}
+
+func _DigitVideoRecorderService_Start_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DigitVideoRecorderServiceServer).Start(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/Start",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DigitVideoRecorderServiceServer).Start(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DigitVideoRecorderService_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -574,6 +2559,204 @@ func _DigitVideoRecorderService_ListRecords_Handler(srv interface{}, ctx context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DigitVideoRecorderService_ValidateConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DigitVideoRecorderServiceServer).ValidateConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/ValidateConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DigitVideoRecorderServiceServer).ValidateConfig(ctx, req.(*ValidateConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DigitVideoRecorderService_Reconfigure_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReconfigureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DigitVideoRecorderServiceServer).Reconfigure(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/Reconfigure",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DigitVideoRecorderServiceServer).Reconfigure(ctx, req.(*ReconfigureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DigitVideoRecorderService_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DigitVideoRecorderServiceServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/GetStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DigitVideoRecorderServiceServer).GetStats(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DigitVideoRecorderService_GetRecordURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRecordURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DigitVideoRecorderServiceServer).GetRecordURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/GetRecordURL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DigitVideoRecorderServiceServer).GetRecordURL(ctx, req.(*GetRecordURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DigitVideoRecorderService_GetTimeline_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTimelineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DigitVideoRecorderServiceServer).GetTimeline(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/GetTimeline",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DigitVideoRecorderServiceServer).GetTimeline(ctx, req.(*GetTimelineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DigitVideoRecorderService_FindRecordAt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindRecordAtRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DigitVideoRecorderServiceServer).FindRecordAt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/FindRecordAt",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DigitVideoRecorderServiceServer).FindRecordAt(ctx, req.(*FindRecordAtRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DigitVideoRecorderService_ImportRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DigitVideoRecorderServiceServer).ImportRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/ImportRecords",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DigitVideoRecorderServiceServer).ImportRecords(ctx, req.(*ImportRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DigitVideoRecorderService_ReindexRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DigitVideoRecorderServiceServer).ReindexRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/ReindexRecords",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DigitVideoRecorderServiceServer).ReindexRecords(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DigitVideoRecorderService_BackupIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BackupIndexRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DigitVideoRecorderServiceServer).BackupIndex(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/BackupIndex",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DigitVideoRecorderServiceServer).BackupIndex(ctx, req.(*BackupIndexRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DigitVideoRecorderService_RestoreIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreIndexRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DigitVideoRecorderServiceServer).RestoreIndex(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/RestoreIndex",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DigitVideoRecorderServiceServer).RestoreIndex(ctx, req.(*RestoreIndexRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DigitVideoRecorderService_SetPrivacyMasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetPrivacyMasksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DigitVideoRecorderServiceServer).SetPrivacyMasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService/SetPrivacyMasks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DigitVideoRecorderServiceServer).SetPrivacyMasks(ctx, req.(*SetPrivacyMasksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _DigitVideoRecorderService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "ai.metathings.component.service.digit_video_recorder.DigitVideoRecorderService",
 	HandlerType: (*DigitVideoRecorderServiceServer)(nil),
@@ -594,6 +2777,50 @@ var _DigitVideoRecorderService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ListRecords",
 			Handler:    _DigitVideoRecorderService_ListRecords_Handler,
 		},
+		{
+			MethodName: "ValidateConfig",
+			Handler:    _DigitVideoRecorderService_ValidateConfig_Handler,
+		},
+		{
+			MethodName: "Reconfigure",
+			Handler:    _DigitVideoRecorderService_Reconfigure_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _DigitVideoRecorderService_GetStats_Handler,
+		},
+		{
+			MethodName: "GetRecordURL",
+			Handler:    _DigitVideoRecorderService_GetRecordURL_Handler,
+		},
+		{
+			MethodName: "GetTimeline",
+			Handler:    _DigitVideoRecorderService_GetTimeline_Handler,
+		},
+		{
+			MethodName: "FindRecordAt",
+			Handler:    _DigitVideoRecorderService_FindRecordAt_Handler,
+		},
+		{
+			MethodName: "ImportRecords",
+			Handler:    _DigitVideoRecorderService_ImportRecords_Handler,
+		},
+		{
+			MethodName: "ReindexRecords",
+			Handler:    _DigitVideoRecorderService_ReindexRecords_Handler,
+		},
+		{
+			MethodName: "BackupIndex",
+			Handler:    _DigitVideoRecorderService_BackupIndex_Handler,
+		},
+		{
+			MethodName: "RestoreIndex",
+			Handler:    _DigitVideoRecorderService_RestoreIndex_Handler,
+		},
+		{
+			MethodName: "SetPrivacyMasks",
+			Handler:    _DigitVideoRecorderService_SetPrivacyMasks_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "service.proto",
@@ -7,6 +7,7 @@ import (
 	fmt "fmt"
 	math "math"
 	proto "github.com/golang/protobuf/proto"
+	_ "github.com/golang/protobuf/ptypes/duration"
 	_ "github.com/golang/protobuf/ptypes/empty"
 	_ "github.com/golang/protobuf/ptypes/wrappers"
 	_ "github.com/golang/protobuf/ptypes/timestamp"
@@ -29,6 +30,15 @@ func (this *Record) Validate() error {
 			return github_com_mwitkow_go_proto_validators.FieldError("EndAt", err)
 		}
 	}
+	// Validation of proto3 map<> fields is unsupported.
+	return nil
+}
+func (this *HookResult) Validate() error {
+	if this.FinishedAt != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.FinishedAt); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("FinishedAt", err)
+		}
+	}
 	return nil
 }
 func (this *OpRecord) Validate() error {
@@ -73,6 +83,11 @@ func (this *ListRecordsRequest) Validate() error {
 			}
 		}
 	}
+	if this.Profile != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Profile); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Profile", err)
+		}
+	}
 	return nil
 }
 func (this *ListRecordsRequestRange_) Validate() error {
@@ -98,3 +113,334 @@ func (this *ListRecordsResponse) Validate() error {
 	}
 	return nil
 }
+func (this *ConfigError) Validate() error {
+	return nil
+}
+func (this *ValidateConfigRequest) Validate() error {
+	if this.Config != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Config); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Config", err)
+		}
+	}
+	return nil
+}
+func (this *ValidateConfigResponse) Validate() error {
+	for _, item := range this.Errors {
+		if item != nil {
+			if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(item); err != nil {
+				return github_com_mwitkow_go_proto_validators.FieldError("Errors", err)
+			}
+		}
+	}
+	return nil
+}
+func (this *ReconfigureRequest) Validate() error {
+	if this.Config != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Config); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Config", err)
+		}
+	}
+	return nil
+}
+func (this *RecordingStats) Validate() error {
+	if this.OutTime != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.OutTime); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("OutTime", err)
+		}
+	}
+	if this.StartedAt != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.StartedAt); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("StartedAt", err)
+		}
+	}
+	if this.UpdatedAt != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.UpdatedAt); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("UpdatedAt", err)
+		}
+	}
+	if this.LastStallAt != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.LastStallAt); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("LastStallAt", err)
+		}
+	}
+	return nil
+}
+func (this *GetStatsResponse) Validate() error {
+	if this.Stats != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Stats); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Stats", err)
+		}
+	}
+	return nil
+}
+func (this *TimelineSpan) Validate() error {
+	if this.StartAt != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.StartAt); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("StartAt", err)
+		}
+	}
+	if this.EndAt != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.EndAt); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("EndAt", err)
+		}
+	}
+	return nil
+}
+func (this *TimelineBucket) Validate() error {
+	if this.StartAt != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.StartAt); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("StartAt", err)
+		}
+	}
+	if this.EndAt != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.EndAt); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("EndAt", err)
+		}
+	}
+	if this.Recorded != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Recorded); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Recorded", err)
+		}
+	}
+	return nil
+}
+func (this *GetTimelineRequest) Validate() error {
+	if this.StartAt != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.StartAt); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("StartAt", err)
+		}
+	}
+	if this.EndAt != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.EndAt); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("EndAt", err)
+		}
+	}
+	if this.Tolerance != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Tolerance); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Tolerance", err)
+		}
+	}
+	if this.Profile != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Profile); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Profile", err)
+		}
+	}
+	return nil
+}
+func (this *GetTimelineResponse) Validate() error {
+	for _, item := range this.Spans {
+		if item != nil {
+			if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(item); err != nil {
+				return github_com_mwitkow_go_proto_validators.FieldError("Spans", err)
+			}
+		}
+	}
+	for _, item := range this.Gaps {
+		if item != nil {
+			if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(item); err != nil {
+				return github_com_mwitkow_go_proto_validators.FieldError("Gaps", err)
+			}
+		}
+	}
+	for _, item := range this.Buckets {
+		if item != nil {
+			if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(item); err != nil {
+				return github_com_mwitkow_go_proto_validators.FieldError("Buckets", err)
+			}
+		}
+	}
+	return nil
+}
+func (this *FindRecordAtRequest) Validate() error {
+	if this.Timestamp != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Timestamp); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Timestamp", err)
+		}
+	}
+	if this.Profile != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Profile); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Profile", err)
+		}
+	}
+	return nil
+}
+func (this *FindRecordAtResponse) Validate() error {
+	if this.Record != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Record); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Record", err)
+		}
+	}
+	if this.Offset != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Offset); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Offset", err)
+		}
+	}
+	if this.Previous != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Previous); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Previous", err)
+		}
+	}
+	if this.Next != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Next); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Next", err)
+		}
+	}
+	return nil
+}
+func (this *ImportRecordsRequest) Validate() error {
+	if this.Profile != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Profile); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Profile", err)
+		}
+	}
+	if this.TimeLayout != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.TimeLayout); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("TimeLayout", err)
+		}
+	}
+	return nil
+}
+func (this *ImportFailure) Validate() error {
+	return nil
+}
+func (this *ImportRecordsResponse) Validate() error {
+	for _, item := range this.Imported {
+		if item != nil {
+			if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(item); err != nil {
+				return github_com_mwitkow_go_proto_validators.FieldError("Imported", err)
+			}
+		}
+	}
+	for _, item := range this.Failed {
+		if item != nil {
+			if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(item); err != nil {
+				return github_com_mwitkow_go_proto_validators.FieldError("Failed", err)
+			}
+		}
+	}
+	return nil
+}
+func (this *ReindexRecordsResponse) Validate() error {
+	for _, item := range this.Recovered {
+		if item != nil {
+			if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(item); err != nil {
+				return github_com_mwitkow_go_proto_validators.FieldError("Recovered", err)
+			}
+		}
+	}
+	for _, item := range this.Unmapped {
+		if item != nil {
+			if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(item); err != nil {
+				return github_com_mwitkow_go_proto_validators.FieldError("Unmapped", err)
+			}
+		}
+	}
+	return nil
+}
+func (this *IndexBackup) Validate() error {
+	if this.CreatedAt != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.CreatedAt); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("CreatedAt", err)
+		}
+	}
+	return nil
+}
+func (this *BackupIndexRequest) Validate() error {
+	if this.Path != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Path); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Path", err)
+		}
+	}
+	return nil
+}
+func (this *BackupIndexResponse) Validate() error {
+	if this.Backup != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Backup); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Backup", err)
+		}
+	}
+	return nil
+}
+func (this *RestoreIndexRequest) Validate() error {
+	if this.Path != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Path); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Path", err)
+		}
+	}
+	return nil
+}
+func (this *RestoreIndexResponse) Validate() error {
+	if this.Backup != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Backup); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Backup", err)
+		}
+	}
+	return nil
+}
+func (this *PrivacyMask) Validate() error {
+	if this.Name != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Name); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Name", err)
+		}
+	}
+	if this.Rect != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Rect); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Rect", err)
+		}
+	}
+	for _, item := range this.Polygon {
+		if item != nil {
+			if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(item); err != nil {
+				return github_com_mwitkow_go_proto_validators.FieldError("Polygon", err)
+			}
+		}
+	}
+	if this.Color != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Color); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Color", err)
+		}
+	}
+	if this.PixelSize != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.PixelSize); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("PixelSize", err)
+		}
+	}
+	return nil
+}
+func (this *PrivacyMask_Point) Validate() error {
+	return nil
+}
+func (this *PrivacyMask_Rect) Validate() error {
+	return nil
+}
+func (this *SetPrivacyMasksRequest) Validate() error {
+	for _, item := range this.Masks {
+		if item != nil {
+			if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(item); err != nil {
+				return github_com_mwitkow_go_proto_validators.FieldError("Masks", err)
+			}
+		}
+	}
+	return nil
+}
+func (this *GetRecordURLRequest) Validate() error {
+	if this.Record != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Record); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Record", err)
+		}
+	}
+	if this.Ttl != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Ttl); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Ttl", err)
+		}
+	}
+	return nil
+}
+func (this *GetRecordURLResponse) Validate() error {
+	if this.ExpiresAt != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.ExpiresAt); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("ExpiresAt", err)
+		}
+	}
+	return nil
+}